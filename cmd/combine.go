@@ -2,12 +2,21 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"agentexec/pkg/combine"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // combineCmd represents the combine command
@@ -18,11 +27,31 @@ var combineCmd = &cobra.Command{
 
 This command allows you to merge multiple files into a single output file.
 You can specify various options such as the output path, tree structure output,
-maximum file size, number of concurrent workers, ignore patterns, and verbosity.`,
+maximum file size, number of concurrent workers, ignore patterns, and verbosity.
+
+Exit codes:
+  0  success
+  1  fatal error, e.g. the output could not be written
+  2  partial success, some files could not be read and are missing from the output
+  3  nothing to combine, all files were ignored or filtered out
+  4  aborted at the binary file prompt`,
 	Args: cobra.ArbitraryArgs, // Allow any number of positional arguments
 	RunE: runCombine,          // Use RunE for enhanced error handling
 }
 
+// tracingShutdownTimeout bounds how long exporting the remaining spans may delay exiting.
+const tracingShutdownTimeout = 5 * time.Second
+
+// exitAfter is a zap fatal hook that runs flush before exiting, since logger.Fatal exits without
+// running deferred functions.
+type exitAfter func()
+
+// OnWrite implements zapcore.CheckWriteHook.
+func (flush exitAfter) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {
+	flush()
+	os.Exit(1)
+}
+
 // runCombine is the main execution function for the combine command.
 func runCombine(cmd *cobra.Command, args []string) error {
 	// Retrieve the logger from the context
@@ -37,10 +66,108 @@ func runCombine(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Layer configuration files over the command line, later files taking precedence
+	configFiles, err := cmd.Flags().GetStringArray("from-config")
+	if err != nil {
+		logger.Error("Failed to parse 'from-config' flag", zap.Error(err))
+		return fmt.Errorf("invalid 'from-config' flag: %w", err)
+	}
+	for _, configFile := range configFiles {
+		fileArgs, err := combine.LoadConfigFile(configFile)
+		if err != nil {
+			logger.Error("Failed to load config file", zap.String("file", configFile), zap.Error(err))
+			return fmt.Errorf("invalid 'from-config' flag: %w", err)
+		}
+		combineArgs = combine.MergeConfig(combineArgs, fileArgs)
+		logger.Debug("Merged config file", zap.String("file", configFile))
+	}
+	if err := combineArgs.Validate(); err != nil {
+		logger.Error("Invalid arguments", zap.Error(err))
+		return err
+	}
+	logger.Debug("Effective arguments", zap.Strings("flags", combineArgs.ToFlags()))
+
+	// Redirect error logs to a separate file so they cannot corrupt piped output
+	errorOutputFile, err := cmd.Flags().GetString("error-output-file")
+	if err != nil {
+		logger.Error("Failed to parse 'error-output-file' flag", zap.Error(err))
+		return fmt.Errorf("invalid 'error-output-file' flag: %w", err)
+	}
+	if errorOutputFile != "" {
+		errFile, err := os.OpenFile(errorOutputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			logger.Error("Failed to open error output file", zap.String("file", errorOutputFile), zap.Error(err))
+			return fmt.Errorf("failed to open error output file: %w", err)
+		}
+		defer errFile.Close()
+
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return combine.NewErrorRedirectCore(core, zapcore.AddSync(errFile))
+		}))
+	}
+
+	// In GitHub Actions mode, surface warnings and errors as workflow annotations
+	if combineArgs.GitHubAction {
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, combine.NewGitHubActionsCore(os.Stdout))
+		}))
+	}
+
+	// Export the spans of each run to an OpenTelemetry collector, also when the run fails
+	if combineArgs.OTLPEndpoint != "" {
+		shutdown, err := combine.StartTracing(cmd.Context(), combineArgs.OTLPEndpoint)
+		if err != nil {
+			logger.Error("Failed to start tracing", zap.String("endpoint", combineArgs.OTLPEndpoint), zap.Error(err))
+			return fmt.Errorf("invalid 'otlp-endpoint' flag: %w", err)
+		}
+		flush := func() {
+			ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+			defer cancel()
+			if err := shutdown(ctx); err != nil {
+				logger.Warn("Failed to export traces", zap.String("endpoint", combineArgs.OTLPEndpoint), zap.Error(err))
+			}
+		}
+		defer flush()
+		logger = logger.WithOptions(zap.WithFatalHook(exitAfter(flush)))
+	}
+
+	// In watch mode, keep re-running until interrupted
+	if combineArgs.Watch {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := combine.WatchWithContext(ctx, combineArgs, logger); err != nil {
+			logger.Fatal("Combine watch failed", zap.Error(err))
+		}
+		_ = logger.Sync()
+		return nil
+	}
+
 	// Execute the combine process with the provided arguments
-	if err := combine.ExecuteWithArgs(combineArgs, logger); err != nil {
+	result, err := combine.ExecuteWithContext(cmd.Context(), combineArgs, logger)
+	if err != nil {
 		logger.Fatal("Combine execution failed", zap.Error(err))
 	}
+	exitCode = result.ExitCode
+
+	if combineArgs.SummaryTable {
+		if err := combine.WriteSummaryTable(os.Stderr, result); err != nil {
+			logger.Warn("Failed to write summary table", zap.Error(err))
+		}
+	}
+
+	if warning := combine.LargeOutputWarning(combineArgs, result); warning != "" {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+
+	if combineArgs.NotifyDone {
+		combine.NotifyDone(cmd.Context(), result, logger)
+	}
+
+	if combineArgs.Open && result.OutputPath != "" && result.OutputPath != combine.StdoutPath {
+		if err := combine.OpenFile(result.OutputPath); err != nil {
+			logger.Warn("Failed to open combined output", zap.String("outputFile", result.OutputPath), zap.Error(err))
+		}
+	}
 
 	return nil
 }
@@ -55,7 +182,8 @@ func getLogger(cmd *cobra.Command) (*zap.Logger, error) {
 	return logger, nil
 }
 
-// parseFlags parses and validates the flags for the combine command.
+// parseFlags parses the flags for the combine command. Their values are validated by
+// Arguments.Validate once configuration files have been merged.
 func parseFlags(cmd *cobra.Command, args []string, logger *zap.Logger) (combine.Arguments, error) {
 	output, err := cmd.Flags().GetString("output")
 	if err != nil {
@@ -81,52 +209,1008 @@ func parseFlags(cmd *cobra.Command, args []string, logger *zap.Logger) (combine.
 		return combine.Arguments{}, fmt.Errorf("invalid 'workers' flag: %w", err)
 	}
 
+	maxMemoryMB, err := cmd.Flags().GetInt("max-memory-mb")
+	if err != nil {
+		logger.Error("Failed to parse 'max-memory-mb' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'max-memory-mb' flag: %w", err)
+	}
+
+	maxWorkersPerDir, err := cmd.Flags().GetInt("max-workers-per-dir")
+	if err != nil {
+		logger.Error("Failed to parse 'max-workers-per-dir' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'max-workers-per-dir' flag: %w", err)
+	}
+
+	globalIgnoreFiles, err := cmd.Flags().GetStringArray("global-ignore")
+	if err != nil {
+		logger.Error("Failed to parse 'global-ignore' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'global-ignore' flag: %w", err)
+	}
+
 	ignorePatterns, err := cmd.Flags().GetStringSlice("ignore")
 	if err != nil {
 		logger.Error("Failed to parse 'ignore' flag", zap.Error(err))
 		return combine.Arguments{}, fmt.Errorf("invalid 'ignore' flag: %w", err)
 	}
 
+	errorOnEmptyPattern, err := cmd.Flags().GetBool("error-on-empty-pattern")
+	if err != nil {
+		logger.Error("Failed to parse 'error-on-empty-pattern' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'error-on-empty-pattern' flag: %w", err)
+	}
+	// The flag parser drops a lone empty value such as --ignore "", leaving no patterns at all
+	if errorOnEmptyPattern && cmd.Flags().Changed("ignore") && len(ignorePatterns) == 0 {
+		return combine.Arguments{}, fmt.Errorf("invalid 'ignore' flag: %w", combine.ErrEmptyPattern)
+	}
+
+	includePatterns, err := cmd.Flags().GetStringSlice("include-pattern")
+	if err != nil {
+		logger.Error("Failed to parse 'include-pattern' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'include-pattern' flag: %w", err)
+	}
+
+	ignoreSyntax, err := cmd.Flags().GetString("ignore-syntax")
+	if err != nil {
+		logger.Error("Failed to parse 'ignore-syntax' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'ignore-syntax' flag: %w", err)
+	}
+	ignoreSyntax = strings.ToLower(ignoreSyntax)
+
+	ignoreVCS, err := cmd.Flags().GetBool("ignore-vcs")
+	if err != nil {
+		logger.Error("Failed to parse 'ignore-vcs' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'ignore-vcs' flag: %w", err)
+	}
+
+	excludeJSArtifacts, err := cmd.Flags().GetBool("exclude-js-artifacts")
+	if err != nil {
+		logger.Error("Failed to parse 'exclude-js-artifacts' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'exclude-js-artifacts' flag: %w", err)
+	}
+
+	followSymlinks, err := cmd.Flags().GetBool("follow-symlinks")
+	if err != nil {
+		logger.Error("Failed to parse 'follow-symlinks' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'follow-symlinks' flag: %w", err)
+	}
+
+	followDirSymlinksOnly, err := cmd.Flags().GetBool("follow-symlinks-to-dirs-only")
+	if err != nil {
+		logger.Error("Failed to parse 'follow-symlinks-to-dirs-only' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'follow-symlinks-to-dirs-only' flag: %w", err)
+	}
+
+	respectGitignore, err := cmd.Flags().GetBool("respect-gitignore")
+	if err != nil {
+		logger.Error("Failed to parse 'respect-gitignore' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'respect-gitignore' flag: %w", err)
+	}
+
+	dockerOutput, err := cmd.Flags().GetBool("docker-output")
+	if err != nil {
+		logger.Error("Failed to parse 'docker-output' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'docker-output' flag: %w", err)
+	}
+
+	dockerVolumeCheck, err := cmd.Flags().GetBool("docker-volume-check")
+	if err != nil {
+		logger.Error("Failed to parse 'docker-volume-check' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'docker-volume-check' flag: %w", err)
+	}
+
 	verbose, err := cmd.Flags().GetBool("verbose")
 	if err != nil {
 		logger.Error("Failed to parse 'verbose' flag", zap.Error(err))
 		return combine.Arguments{}, fmt.Errorf("invalid 'verbose' flag: %w", err)
 	}
 
-	// If no paths are specified, default to current directory
+	watchOnStart, err := cmd.Flags().GetBool("watch-on-start")
+	if err != nil {
+		logger.Error("Failed to parse 'watch-on-start' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'watch-on-start' flag: %w", err)
+	}
+
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		logger.Error("Failed to parse 'format' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'format' flag: %w", err)
+	}
+	format = strings.ToLower(format)
+
+	treeFormat, err := cmd.Flags().GetString("tree-format")
+	if err != nil {
+		logger.Error("Failed to parse 'tree-format' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'tree-format' flag: %w", err)
+	}
+	treeFormat = strings.ToLower(treeFormat)
+
+	treeRelativeRoot, err := cmd.Flags().GetString("tree-relative-root")
+	if err != nil {
+		logger.Error("Failed to parse 'tree-relative-root' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'tree-relative-root' flag: %w", err)
+	}
+
+	countTokensPerFile, err := cmd.Flags().GetBool("count-tokens-per-file")
+	if err != nil {
+		logger.Error("Failed to parse 'count-tokens-per-file' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'count-tokens-per-file' flag: %w", err)
+	}
+
+	tokenCount, err := cmd.Flags().GetBool("token-count")
+	if err != nil {
+		logger.Error("Failed to parse 'token-count' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'token-count' flag: %w", err)
+	}
+
+	maxTokens, err := cmd.Flags().GetInt("max-tokens")
+	if err != nil {
+		logger.Error("Failed to parse 'max-tokens' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'max-tokens' flag: %w", err)
+	}
+
+	outputTemplateDir, err := cmd.Flags().GetString("output-template-dir")
+	if err != nil {
+		logger.Error("Failed to parse 'output-template-dir' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'output-template-dir' flag: %w", err)
+	}
+
+	limitToImports, err := cmd.Flags().GetString("limit-to-imports")
+	if err != nil {
+		logger.Error("Failed to parse 'limit-to-imports' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'limit-to-imports' flag: %w", err)
+	}
+
+	writeIfChanged, err := cmd.Flags().GetBool("write-if-changed")
+	if err != nil {
+		logger.Error("Failed to parse 'write-if-changed' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'write-if-changed' flag: %w", err)
+	}
+
+	readRetries, err := cmd.Flags().GetInt("read-retries")
+	if err != nil {
+		logger.Error("Failed to parse 'read-retries' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'read-retries' flag: %w", err)
+	}
+
+	readRetryDelay, err := cmd.Flags().GetDuration("read-retry-delay")
+	if err != nil {
+		logger.Error("Failed to parse 'read-retry-delay' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'read-retry-delay' flag: %w", err)
+	}
+
+	excludeOlderThanFlag, err := cmd.Flags().GetString("exclude-older-than")
+	if err != nil {
+		logger.Error("Failed to parse 'exclude-older-than' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'exclude-older-than' flag: %w", err)
+	}
+	var excludeOlderThan time.Duration
+	if excludeOlderThanFlag != "" {
+		excludeOlderThan, err = combine.ParseDuration(excludeOlderThanFlag)
+		if err != nil {
+			return combine.Arguments{}, fmt.Errorf("invalid 'exclude-older-than' flag: %w", err)
+		}
+		if excludeOlderThan <= 0 {
+			return combine.Arguments{}, fmt.Errorf("invalid 'exclude-older-than' flag: duration must be positive")
+		}
+	}
+
+	virtualRoot, err := cmd.Flags().GetString("virtual-root")
+	if err != nil {
+		logger.Error("Failed to parse 'virtual-root' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'virtual-root' flag: %w", err)
+	}
+	if virtualRoot != "" {
+		virtualRoot = filepath.Clean(virtualRoot)
+	}
+
+	fileCommentFormat, err := cmd.Flags().GetString("file-comment-format")
+	if err != nil {
+		logger.Error("Failed to parse 'file-comment-format' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'file-comment-format' flag: %w", err)
+	}
+	var commentPrefixes map[string]string
+	if fileCommentFormat != "" {
+		var raw map[string]string
+		if err := json.Unmarshal([]byte(fileCommentFormat), &raw); err != nil {
+			return combine.Arguments{}, fmt.Errorf("invalid 'file-comment-format' flag: %w", err)
+		}
+		commentPrefixes = make(map[string]string, len(raw))
+		for ext, prefix := range raw {
+			commentPrefixes[strings.ToLower(strings.TrimPrefix(ext, "."))] = strings.TrimSpace(prefix)
+		}
+	}
+
+	sectionPaddingBefore, err := cmd.Flags().GetInt("section-padding-before")
+	if err != nil {
+		logger.Error("Failed to parse 'section-padding-before' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'section-padding-before' flag: %w", err)
+	}
+
+	sectionPaddingAfter, err := cmd.Flags().GetInt("section-padding-after")
+	if err != nil {
+		logger.Error("Failed to parse 'section-padding-after' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'section-padding-after' flag: %w", err)
+	}
+
+	binaryThreshold, err := cmd.Flags().GetFloat64("binary-threshold")
+	if err != nil {
+		logger.Error("Failed to parse 'binary-threshold' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'binary-threshold' flag: %w", err)
+	}
+
+	binarySampleBytes, err := cmd.Flags().GetInt("binary-sample-bytes")
+	if err != nil {
+		logger.Error("Failed to parse 'binary-sample-bytes' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'binary-sample-bytes' flag: %w", err)
+	}
+
+	extraBinaryExt, err := cmd.Flags().GetStringSlice("extra-binary-ext")
+	if err != nil {
+		logger.Error("Failed to parse 'extra-binary-ext' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'extra-binary-ext' flag: %w", err)
+	}
+
+	notBinaryExt, err := cmd.Flags().GetStringSlice("not-binary-ext")
+	if err != nil {
+		logger.Error("Failed to parse 'not-binary-ext' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'not-binary-ext' flag: %w", err)
+	}
+
+	treatAsText, err := cmd.Flags().GetStringSlice("treat-as-text")
+	if err != nil {
+		logger.Error("Failed to parse 'treat-as-text' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'treat-as-text' flag: %w", err)
+	}
+
+	githubAction, err := cmd.Flags().GetBool("github-action")
+	if err != nil {
+		logger.Error("Failed to parse 'github-action' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'github-action' flag: %w", err)
+	}
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		githubAction = true
+	}
+
+	profilePatterns, err := cmd.Flags().GetBool("profile-patterns")
+	if err != nil {
+		logger.Error("Failed to parse 'profile-patterns' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'profile-patterns' flag: %w", err)
+	}
+
+	profileIO, err := cmd.Flags().GetBool("profile-io")
+	if err != nil {
+		logger.Error("Failed to parse 'profile-io' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'profile-io' flag: %w", err)
+	}
+
+	progress, err := cmd.Flags().GetBool("progress")
+	if err != nil {
+		logger.Error("Failed to parse 'progress' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'progress' flag: %w", err)
+	}
+
+	progressFile, err := cmd.Flags().GetString("progress-file")
+	if err != nil {
+		logger.Error("Failed to parse 'progress-file' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'progress-file' flag: %w", err)
+	}
+
+	progressInterval, err := cmd.Flags().GetInt("progress-interval")
+	if err != nil {
+		logger.Error("Failed to parse 'progress-interval' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'progress-interval' flag: %w", err)
+	}
+
+	sanitizePaths, err := cmd.Flags().GetBool("sanitize-paths")
+	if err != nil {
+		logger.Error("Failed to parse 'sanitize-paths' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'sanitize-paths' flag: %w", err)
+	}
+
+	maxSymlinkDepth, err := cmd.Flags().GetInt("max-symlink-depth")
+	if err != nil {
+		logger.Error("Failed to parse 'max-symlink-depth' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'max-symlink-depth' flag: %w", err)
+	}
+
+	maxDepth, err := cmd.Flags().GetInt("max-depth")
+	if err != nil {
+		logger.Error("Failed to parse 'max-depth' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'max-depth' flag: %w", err)
+	}
+
+	filterByRegex, err := cmd.Flags().GetString("filter-by-regex")
+	if err != nil {
+		logger.Error("Failed to parse 'filter-by-regex' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'filter-by-regex' flag: %w", err)
+	}
+
+	negateRegex, err := cmd.Flags().GetBool("negate-regex")
+	if err != nil {
+		logger.Error("Failed to parse 'negate-regex' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'negate-regex' flag: %w", err)
+	}
+
+	headerTemplate, err := cmd.Flags().GetString("header-template")
+	if err != nil {
+		logger.Error("Failed to parse 'header-template' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'header-template' flag: %w", err)
+	}
+
+	grepPattern, err := cmd.Flags().GetString("grep")
+	if err != nil {
+		logger.Error("Failed to parse 'grep' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'grep' flag: %w", err)
+	}
+
+	detectSecrets, err := cmd.Flags().GetBool("detect-secrets")
+	if err != nil {
+		logger.Error("Failed to parse 'detect-secrets' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'detect-secrets' flag: %w", err)
+	}
+
+	excludeSecrets, err := cmd.Flags().GetBool("exclude-secrets")
+	if err != nil {
+		logger.Error("Failed to parse 'exclude-secrets' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'exclude-secrets' flag: %w", err)
+	}
+
+	deduplicate, err := cmd.Flags().GetBool("deduplicate")
+	if err != nil {
+		logger.Error("Failed to parse 'deduplicate' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'deduplicate' flag: %w", err)
+	}
+
+	contentHashDedup, err := cmd.Flags().GetBool("content-hash-dedup")
+	if err != nil {
+		logger.Error("Failed to parse 'content-hash-dedup' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'content-hash-dedup' flag: %w", err)
+	}
+
+	combineChangelog, err := cmd.Flags().GetBool("combine-changelog")
+	if err != nil {
+		logger.Error("Failed to parse 'combine-changelog' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'combine-changelog' flag: %w", err)
+	}
+
+	diffOutput, err := cmd.Flags().GetString("diff-output")
+	if err != nil {
+		logger.Error("Failed to parse 'diff-output' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'diff-output' flag: %w", err)
+	}
+
+	incremental, err := cmd.Flags().GetBool("incremental")
+	if err != nil {
+		logger.Error("Failed to parse 'incremental' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'incremental' flag: %w", err)
+	}
+
+	checkpoint, err := cmd.Flags().GetString("checkpoint")
+	if err != nil {
+		logger.Error("Failed to parse 'checkpoint' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'checkpoint' flag: %w", err)
+	}
+
+	cacheFile, err := cmd.Flags().GetString("cache-file")
+	if err != nil {
+		logger.Error("Failed to parse 'cache-file' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'cache-file' flag: %w", err)
+	}
+
+	stats, err := cmd.Flags().GetBool("stats")
+	if err != nil {
+		logger.Error("Failed to parse 'stats' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'stats' flag: %w", err)
+	}
+
+	statsOutput, err := cmd.Flags().GetString("stats-output")
+	if err != nil {
+		logger.Error("Failed to parse 'stats-output' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'stats-output' flag: %w", err)
+	}
+
+	summaryTable, err := cmd.Flags().GetBool("summary-table")
+	if err != nil {
+		logger.Error("Failed to parse 'summary-table' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'summary-table' flag: %w", err)
+	}
+
+	warnOnLargeCombinedMB, err := cmd.Flags().GetInt("warn-on-large-combined")
+	if err != nil {
+		logger.Error("Failed to parse 'warn-on-large-combined' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'warn-on-large-combined' flag: %w", err)
+	}
+
+	notifyDone, err := cmd.Flags().GetBool("notify-done")
+	if err != nil {
+		logger.Error("Failed to parse 'notify-done' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'notify-done' flag: %w", err)
+	}
+
+	open, err := cmd.Flags().GetBool("open")
+	if err != nil {
+		logger.Error("Failed to parse 'open' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'open' flag: %w", err)
+	}
+
+	lock, err := cmd.Flags().GetBool("lock")
+	if err != nil {
+		logger.Error("Failed to parse 'lock' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'lock' flag: %w", err)
+	}
+
+	treeDirsLast, err := cmd.Flags().GetBool("tree-dirs-last")
+	if err != nil {
+		logger.Error("Failed to parse 'tree-dirs-last' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'tree-dirs-last' flag: %w", err)
+	}
+
+	includeFileCount, err := cmd.Flags().GetBool("include-file-count")
+	if err != nil {
+		logger.Error("Failed to parse 'include-file-count' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'include-file-count' flag: %w", err)
+	}
+
+	noHeader, err := cmd.Flags().GetBool("no-header")
+	if err != nil {
+		logger.Error("Failed to parse 'no-header' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'no-header' flag: %w", err)
+	}
+
+	treeShowIgnored, err := cmd.Flags().GetBool("tree-show-ignored")
+	if err != nil {
+		logger.Error("Failed to parse 'tree-show-ignored' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'tree-show-ignored' flag: %w", err)
+	}
+
+	treeIgnoredMarker, err := cmd.Flags().GetString("tree-ignored-marker")
+	if err != nil {
+		logger.Error("Failed to parse 'tree-ignored-marker' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'tree-ignored-marker' flag: %w", err)
+	}
+
+	treeCounts, err := cmd.Flags().GetBool("tree-counts")
+	if err != nil {
+		logger.Error("Failed to parse 'tree-counts' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'tree-counts' flag: %w", err)
+	}
+
+	treeAnnotations, err := cmd.Flags().GetBool("tree-annotations")
+	if err != nil {
+		logger.Error("Failed to parse 'tree-annotations' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'tree-annotations' flag: %w", err)
+	}
+
+	treeAnnotationFormat, err := cmd.Flags().GetString("tree-annotation-format")
+	if err != nil {
+		logger.Error("Failed to parse 'tree-annotation-format' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'tree-annotation-format' flag: %w", err)
+	}
+
+	trimTrailingWhitespace, err := cmd.Flags().GetBool("trim-trailing-whitespace")
+	if err != nil {
+		logger.Error("Failed to parse 'trim-trailing-whitespace' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'trim-trailing-whitespace' flag: %w", err)
+	}
+
+	stripDocComments, err := cmd.Flags().GetBool("strip-doc-comments")
+	if err != nil {
+		logger.Error("Failed to parse 'strip-doc-comments' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'strip-doc-comments' flag: %w", err)
+	}
+
+	contentReplacements, err := cmd.Flags().GetStringArray("content-regex-replace")
+	if err != nil {
+		logger.Error("Failed to parse 'content-regex-replace' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'content-regex-replace' flag: %w", err)
+	}
+
+	lineNumbers, err := cmd.Flags().GetBool("line-numbers")
+	if err != nil {
+		logger.Error("Failed to parse 'line-numbers' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'line-numbers' flag: %w", err)
+	}
+
+	outputMode, err := cmd.Flags().GetString("output-mode")
+	if err != nil {
+		logger.Error("Failed to parse 'output-mode' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'output-mode' flag: %w", err)
+	}
+	outputMode = strings.ToLower(outputMode)
+
+	onError, err := cmd.Flags().GetString("on-error")
+	if err != nil {
+		logger.Error("Failed to parse 'on-error' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'on-error' flag: %w", err)
+	}
+	onError = strings.ToLower(onError)
+
+	requireEncoding, err := cmd.Flags().GetString("require-encoding")
+	if err != nil {
+		logger.Error("Failed to parse 'require-encoding' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'require-encoding' flag: %w", err)
+	}
+	requireEncoding = strings.ToLower(requireEncoding)
+	if requireEncoding == "utf8" {
+		requireEncoding = combine.EncodingUTF8
+	}
+
+	minUniqueLines, err := cmd.Flags().GetInt("min-unique-lines")
+	if err != nil {
+		logger.Error("Failed to parse 'min-unique-lines' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'min-unique-lines' flag: %w", err)
+	}
+
+	excludeInitOnly, err := cmd.Flags().GetBool("exclude-init-only")
+	if err != nil {
+		logger.Error("Failed to parse 'exclude-init-only' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'exclude-init-only' flag: %w", err)
+	}
+
+	base64EncodeBinary, err := cmd.Flags().GetBool("base64-encode-binary")
+	if err != nil {
+		logger.Error("Failed to parse 'base64-encode-binary' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'base64-encode-binary' flag: %w", err)
+	}
+
+	combineIntoArchive, err := cmd.Flags().GetString("combine-into-archive")
+	if err != nil {
+		logger.Error("Failed to parse 'combine-into-archive' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'combine-into-archive' flag: %w", err)
+	}
+
+	preview, err := cmd.Flags().GetInt("preview")
+	if err != nil {
+		logger.Error("Failed to parse 'preview' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'preview' flag: %w", err)
+	}
+
+	includeGitLog, err := cmd.Flags().GetInt("include-git-log")
+	if err != nil {
+		logger.Error("Failed to parse 'include-git-log' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'include-git-log' flag: %w", err)
+	}
+
+	hashAlgorithm, err := cmd.Flags().GetString("hash-algorithm")
+	if err != nil {
+		logger.Error("Failed to parse 'hash-algorithm' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'hash-algorithm' flag: %w", err)
+	}
+	hashAlgorithm = strings.ToLower(hashAlgorithm)
+
+	parallelHash, err := cmd.Flags().GetBool("parallel-hash")
+	if err != nil {
+		logger.Error("Failed to parse 'parallel-hash' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'parallel-hash' flag: %w", err)
+	}
+
+	reportSkippedPatterns, err := cmd.Flags().GetBool("report-skipped-patterns")
+	if err != nil {
+		logger.Error("Failed to parse 'report-skipped-patterns' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'report-skipped-patterns' flag: %w", err)
+	}
+
+	pathNormalization, err := cmd.Flags().GetString("path-normalization")
+	if err != nil {
+		logger.Error("Failed to parse 'path-normalization' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'path-normalization' flag: %w", err)
+	}
+	pathNormalization = strings.ToLower(pathNormalization)
+
+	sortOrder, err := cmd.Flags().GetString("sort")
+	if err != nil {
+		logger.Error("Failed to parse 'sort' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'sort' flag: %w", err)
+	}
+	sortOrder = strings.ToLower(sortOrder)
+
+	groupByPackage, err := cmd.Flags().GetBool("group-by-package")
+	if err != nil {
+		logger.Error("Failed to parse 'group-by-package' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'group-by-package' flag: %w", err)
+	}
+
+	requireMinFiles, err := cmd.Flags().GetInt("require-min-files")
+	if err != nil {
+		logger.Error("Failed to parse 'require-min-files' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'require-min-files' flag: %w", err)
+	}
+
+	requireMaxFiles, err := cmd.Flags().GetInt("require-max-files")
+	if err != nil {
+		logger.Error("Failed to parse 'require-max-files' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'require-max-files' flag: %w", err)
+	}
+
+	outputPerExtension, err := cmd.Flags().GetBool("output-per-extension")
+	if err != nil {
+		logger.Error("Failed to parse 'output-per-extension' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'output-per-extension' flag: %w", err)
+	}
+
+	readChunkSize, err := cmd.Flags().GetInt("read-chunk-size")
+	if err != nil {
+		logger.Error("Failed to parse 'read-chunk-size' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'read-chunk-size' flag: %w", err)
+	}
+
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		logger.Error("Failed to parse 'dry-run' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'dry-run' flag: %w", err)
+	}
+
+	splitOnPattern, err := cmd.Flags().GetString("split-on-pattern")
+	if err != nil {
+		logger.Error("Failed to parse 'split-on-pattern' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'split-on-pattern' flag: %w", err)
+	}
+
+	splitBytes, err := cmd.Flags().GetInt("split-bytes")
+	if err != nil {
+		logger.Error("Failed to parse 'split-bytes' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'split-bytes' flag: %w", err)
+	}
+
+	outputJSONStream, err := cmd.Flags().GetBool("output-json-stream")
+	if err != nil {
+		logger.Error("Failed to parse 'output-json-stream' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'output-json-stream' flag: %w", err)
+	}
+
+	wrapInXMLTags, err := cmd.Flags().GetString("wrap-in-xml-tags")
+	if err != nil {
+		logger.Error("Failed to parse 'wrap-in-xml-tags' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'wrap-in-xml-tags' flag: %w", err)
+	}
+
+	encodingDeclaration, err := cmd.Flags().GetString("encoding-declaration-style")
+	if err != nil {
+		logger.Error("Failed to parse 'encoding-declaration-style' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'encoding-declaration-style' flag: %w", err)
+	}
+	encodingDeclaration = strings.ToLower(encodingDeclaration)
+
+	outputSplitTree, err := cmd.Flags().GetBool("output-split-tree")
+	if err != nil {
+		logger.Error("Failed to parse 'output-split-tree' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'output-split-tree' flag: %w", err)
+	}
+
+	watch, err := cmd.Flags().GetBool("watch")
+	if err != nil {
+		logger.Error("Failed to parse 'watch' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'watch' flag: %w", err)
+	}
+	watchAfter, err := cmd.Flags().GetStringArray("watch-after")
+	if err != nil {
+		logger.Error("Failed to parse 'watch-after' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'watch-after' flag: %w", err)
+	}
+
+	preCombineHook, err := cmd.Flags().GetString("pre-combine-hook")
+	if err != nil {
+		logger.Error("Failed to parse 'pre-combine-hook' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'pre-combine-hook' flag: %w", err)
+	}
+
+	postCombineHook, err := cmd.Flags().GetString("post-combine-hook")
+	if err != nil {
+		logger.Error("Failed to parse 'post-combine-hook' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'post-combine-hook' flag: %w", err)
+	}
+
+	otlpEndpoint, err := cmd.Flags().GetString("otlp-endpoint")
+	if err != nil {
+		logger.Error("Failed to parse 'otlp-endpoint' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'otlp-endpoint' flag: %w", err)
+	}
+
+	pathsFile, err := cmd.Flags().GetString("paths-file")
+	if err != nil {
+		logger.Error("Failed to parse 'paths-file' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'paths-file' flag: %w", err)
+	}
+
+	stdinExtension, err := cmd.Flags().GetString("stdin-extension")
+	if err != nil {
+		logger.Error("Failed to parse 'stdin-extension' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'stdin-extension' flag: %w", err)
+	}
+
+	prioritize, err := cmd.Flags().GetString("prioritize")
+	if err != nil {
+		logger.Error("Failed to parse 'prioritize' flag", zap.Error(err))
+		return combine.Arguments{}, fmt.Errorf("invalid 'prioritize' flag: %w", err)
+	}
+
+	// Merge positional paths with those listed in the paths file
 	paths := args
+	if pathsFile != "" {
+		filePaths, err := combine.ReadPathsFile(pathsFile)
+		if err != nil {
+			logger.Error("Failed to read paths file", zap.String("file", pathsFile), zap.Error(err))
+			return combine.Arguments{}, fmt.Errorf("invalid 'paths-file' flag: %w", err)
+		}
+		paths = combine.DedupPaths(append(append([]string{}, args...), filePaths...))
+		if len(paths) == 0 {
+			return combine.Arguments{}, fmt.Errorf("invalid 'paths-file' flag: %s lists no paths", pathsFile)
+		}
+		for _, path := range paths {
+			if _, err := os.Stat(path); err != nil {
+				return combine.Arguments{}, fmt.Errorf("invalid 'paths-file' flag: %w", err)
+			}
+		}
+		logger.Debug("Read paths file", zap.String("file", pathsFile), zap.Int("paths", len(filePaths)))
+	}
+
+	// If no paths are specified, default to current directory
 	if len(paths) == 0 {
 		paths = []string{"./"}
 	}
 
 	// Define the arguments based on flags and positional arguments
 	combineArgs := combine.Arguments{
-		Paths:          paths,
-		Output:         output,
-		Tree:           tree,
-		MaxFileSizeKB:  maxSize,
-		MaxWorkers:     workers,
-		IgnorePatterns: ignorePatterns, // Use ignore patterns from flags
-		Verbose:        verbose,        // Verbose logging flag
+		Paths:             paths,
+		PathsFile:         pathsFile,
+		StdinExtension:    stdinExtension,
+		Prioritize:        prioritize,
+		Output:            output,
+		Tree:              tree,
+		GlobalIgnoreFiles: globalIgnoreFiles,
+		MaxFileSizeKB:     maxSize,
+		MaxWorkers:        workers,
+		MaxMemoryMB:       maxMemoryMB,
+		MaxWorkersPerDir:  maxWorkersPerDir,
+		IgnorePatterns:    ignorePatterns,    // Use ignore patterns from flags
+		IncludePatterns:   includePatterns,   // Restrict collection to these patterns, if any
+		IgnoreSyntax:      ignoreSyntax,      // Syntax of the ignore patterns from flags
+		IgnoreVCS:         ignoreVCS,         // Ignore VCS metadata directories
+		RespectGitignore:  respectGitignore,  // Apply .gitignore files during traversal
+		FollowSymlinks:    followSymlinks,    // Traverse symlinked directories
+		DockerOutput:      dockerOutput,      // Warn about outputs lost when the container exits
+		DockerVolumeCheck: dockerVolumeCheck, // Require outputs to be on mounted volumes
+		Verbose:           verbose,           // Verbose logging flag
+		WatchOnStart:      &watchOnStart,
+
+		OutputFormat:          format,
+		OutputJSONStream:      outputJSONStream,
+		WrapInXMLTags:         wrapInXMLTags,
+		EncodingDeclaration:   encodingDeclaration,
+		TreeFormat:            treeFormat,
+		TreeRelativeRoot:      treeRelativeRoot,
+		TreeDirsLast:          treeDirsLast,
+		TreeShowIgnored:       treeShowIgnored,
+		IncludeFileCount:      includeFileCount,
+		NoHeader:              noHeader,
+		TreeIgnoredMarker:     treeIgnoredMarker,
+		TreeCounts:            treeCounts,
+		TreeAnnotations:       treeAnnotations,
+		TreeAnnotationFormat:  treeAnnotationFormat,
+		CountTokensPerFile:    countTokensPerFile,
+		TokenCount:            tokenCount,
+		MaxTokens:             maxTokens,
+		OutputTemplateDir:     outputTemplateDir,
+		LimitToImports:        limitToImports,
+		WriteIfChanged:        writeIfChanged,
+		HashAlgorithm:         hashAlgorithm,
+		OutputMode:            outputMode,
+		OnError:               onError,
+		RequireEncoding:       requireEncoding,
+		Preview:               preview,
+		DryRun:                dryRun,
+		Watch:                 watch,
+		WatchAfter:            watchAfter,
+		PreCombineHook:        preCombineHook,
+		PostCombineHook:       postCombineHook,
+		OTLPEndpoint:          otlpEndpoint,
+		CombineIntoArchive:    combineIntoArchive,
+		OutputPerExtension:    outputPerExtension,
+		SplitOnPattern:        splitOnPattern,
+		OutputSplitTree:       outputSplitTree,
+		SplitBytes:            splitBytes,
+		GitHubAction:          githubAction,
+		NonInteractive:        githubAction || pathsFile == combine.StdinPath, // CI runners and consumed stdin cannot answer prompts
+		ProfilePatterns:       profilePatterns,
+		ProfileIO:             profileIO,
+		Progress:              progress,
+		ProgressFile:          progressFile,
+		ProgressInterval:      progressInterval,
+		SanitizePaths:         sanitizePaths,
+		ReportSkippedPatterns: reportSkippedPatterns,
+		ExcludeJSArtifacts:    excludeJSArtifacts,
+		ErrorOnEmptyPattern:   errorOnEmptyPattern,
+		FollowDirSymlinksOnly: followDirSymlinksOnly,
+		CombineChangelog:      combineChangelog,
+		DiffOutput:            diffOutput,
+		FilterByRegex:         filterByRegex,
+		NegateRegex:           negateRegex,
+		GrepPattern:           grepPattern,
+		DetectSecrets:         detectSecrets,
+		ExcludeSecrets:        excludeSecrets,
+		Deduplicate:           deduplicate,
+		ContentHashDedup:      contentHashDedup,
+		HeaderTemplate:        headerTemplate,
+		Incremental:           incremental,
+		CacheFile:             cacheFile,
+		Checkpoint:            checkpoint,
+		NotifyDone:            notifyDone,
+		Open:                  open,
+		Lock:                  lock,
+		SummaryTable:          summaryTable,
+		WarnOnLargeCombinedMB: warnOnLargeCombinedMB,
+		Stats:                 stats,
+		StatsOutput:           statsOutput,
+		Base64EncodeBinary:    base64EncodeBinary,
+		MinUniqueLines:        minUniqueLines,
+		ExcludeInitOnly:       excludeInitOnly,
+		RequireMinFiles:       requireMinFiles,
+		RequireMaxFiles:       requireMaxFiles,
+		MaxSymlinkDepth:       maxSymlinkDepth,
+		MaxDepth:              maxDepth,
+		ExcludeOlderThan:      excludeOlderThan,
+		VirtualRoot:           virtualRoot,
+		CommentPrefixes:       commentPrefixes,
+
+		ReadRetries:    readRetries,
+		ReadRetryDelay: readRetryDelay,
+		ReadChunkSize:  readChunkSize,
+
+		TrimTrailingWhitespace: trimTrailingWhitespace,
+		StripDocComments:       stripDocComments,
+		LineNumbers:            lineNumbers,
+		IncludeGitLog:          includeGitLog,
+		ParallelHash:           parallelHash,
+
+		ContentReplacements: contentReplacements,
+
+		PathNormalization: pathNormalization,
+		Sort:              sortOrder,
+		GroupByPackage:    groupByPackage,
+
+		SectionPaddingBefore: &sectionPaddingBefore,
+		SectionPaddingAfter:  &sectionPaddingAfter,
+
+		BinaryThreshold:       binaryThreshold,
+		BinarySampleBytes:     binarySampleBytes,
+		ExtraBinaryExt:        extraBinaryExt,
+		NotBinaryExt:          notBinaryExt,
+		TreatAsTextExtensions: treatAsText,
 	}
 
 	return combineArgs, nil
 }
 
 func init() {
-	// Define flags specific to the combine command
-	combineCmd.Flags().StringP("output", "o", "debug/combined.txt", "Path to the combined output file")
-	combineCmd.Flags().StringP("tree", "t", "debug/tree.txt", "Path to the tree structure output file")
-	combineCmd.Flags().IntP("max-size", "m", 10240, "Maximum file size to process in KB (default: 10240KB)")
-	combineCmd.Flags().IntP("workers", "w", 4, "Number of concurrent workers for processing files (default: 4)")
-	combineCmd.Flags().StringSliceP("ignore", "i", []string{
-		".git/",
-		".combineignore",
-		"debug/",
-	}, "Ignore patterns (e.g., \"*.git\", \"build/\")")
-	combineCmd.Flags().BoolP("verbose", "v", false, "Enable verbose logging of skipped files")
+	addCombineFlags(combineCmd)
 
 	// Optionally, mark flags as required or provide validation here
 	// For example:
 	// combineCmd.MarkFlagRequired("output")
 }
+
+// addCombineFlags defines the flags of the combine command on cmd.
+func addCombineFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("output", "o", "debug/combined.txt", "Path to the combined output file, or - for stdout")
+	cmd.Flags().String("paths-file", "", "File listing additional paths to combine, one per line (blank lines and # comments skipped), or - for stdin")
+	cmd.Flags().String("stdin-extension", "", "Add content piped to stdin as the virtual file stdin<EXT> (e.g. .go for stdin.go), after the other files")
+	cmd.Flags().String("prioritize", "", "Put the given virtual file first in the output instead of last (stdin)")
+	cmd.Flags().StringP("tree", "t", "debug/tree.txt", "Path to the tree structure output file, or - for stdout")
+	cmd.Flags().IntP("max-size", "m", combine.DefaultMaxFileSizeKB, "Maximum file size to process in KB (default: 10240KB)")
+	cmd.Flags().IntP("workers", "w", 4, "Number of concurrent workers for processing files (default: 4)")
+	cmd.Flags().Int("max-memory-mb", 0, "Pause handing files to the workers while the heap is near this many MB, resuming once it drops (0 to disable)")
+	cmd.Flags().Int("max-workers-per-dir", 0, "Process at most this many files of the same directory at once, e.g. to reduce seeking on spinning disks (0 for no limit)")
+	cmd.Flags().StringSliceP("ignore", "i", []string{
+		".git/",
+		".combineignore",
+		"debug/",
+	}, "Ignore patterns (e.g., \"*.git\", \"build/\")")
+	cmd.Flags().StringArray("global-ignore", nil, "Global .combineignore file loaded before the local ones (repeatable, loaded in order; default $COMBINEIGNORE_GLOBAL)")
+	cmd.Flags().StringSliceP("include-pattern", "I", nil, "Only collect files matching at least one of these gitignore-style patterns (e.g., \"*.go\"); ignore patterns still apply")
+	cmd.Flags().Bool("error-on-empty-pattern", false, "Fail on blank --ignore patterns, e.g. from an unset variable in --ignore \"$PATTERNS\", instead of skipping them")
+	cmd.Flags().String("ignore-syntax", combine.SyntaxGitignore, "Syntax of --ignore patterns (gitignore, glob, regex); .combineignore files always use gitignore")
+	cmd.Flags().Bool("ignore-vcs", true, "Ignore metadata directories of common version control systems (.git, .svn, .hg, .bzr, CVS, _darcs)")
+	cmd.Flags().Bool("exclude-js-artifacts", false, "Ignore JavaScript build outputs and caches (node_modules/.cache, dist, build, coverage, .next, .nuxt, .svelte-kit, storybook-static)")
+	cmd.Flags().Bool("docker-output", false, "When running inside a container, warn if an output path is not on a volume mounted from the host")
+	cmd.Flags().Bool("docker-volume-check", false, "When running inside a container, fail before combining if an output path is not on a volume mounted from the host")
+	cmd.Flags().Bool("follow-symlinks", false, "Traverse symlinks to directories, visiting each real directory once; otherwise they are only listed in the tree")
+	cmd.Flags().Bool("follow-symlinks-to-dirs-only", false, "Traverse symlinks to directories like --follow-symlinks, but leave out symlinks to files found during traversal, e.g. ones pointing outside the project")
+	cmd.Flags().Bool("respect-gitignore", false, "Apply .gitignore files from the repository root down, each to its own directory; .combineignore takes precedence")
+	cmd.Flags().StringArray("from-config", nil, "Merge settings from a YAML or TOML file (keys are argument field names, e.g. maxFileSizeKB) over the command line; may be repeated, later files take precedence")
+	cmd.Flags().String("error-output-file", "", "Write error level log messages to this file instead of the console")
+	cmd.Flags().BoolP("verbose", "v", false, "Enable verbose logging of skipped files")
+	cmd.Flags().Bool("watch-on-start", true, "In watch mode, run the combine process once before waiting for the first change")
+	cmd.Flags().String("format", combine.FormatText, "Output format for the combined file (text, json, yaml, zip)")
+	cmd.Flags().String("wrap-in-xml-tags", "", "Enclose the text output in <TAG> and </TAG>, the tree in <directory_tree>, and each file in <document path=\"...\"> instead of its header")
+	cmd.Flags().String("encoding-declaration-style", combine.EncodingDeclarationNone, "Start the text output with a UTF-8 encoding declaration (python for \"# -*- coding: utf-8 -*-\", xml for <?xml ...?>, or none)")
+	cmd.Flags().Bool("output-json-stream", false, "Write one JSON object per file and line (NDJSON) as soon as each file is processed, instead of a combined document")
+	cmd.Flags().String("tree-format", combine.TreeFormatText, "Format of the tree structure file (text, json, xml), independent of --format")
+	cmd.Flags().String("tree-relative-root", "", "Show tree root directories relative to this directory (e.g. ./project/ for /home/user with /home/user/project; . for the current directory) instead of as absolute paths")
+	cmd.Flags().Bool("tree-dirs-last", false, "List files before directories in the tree structure")
+	cmd.Flags().Bool("include-file-count", false, "Start text output with a comment giving the file count, total size, and generation time")
+	cmd.Flags().Bool("no-header", false, "Suppress the --include-file-count comment, e.g. when a config file enables it")
+	cmd.Flags().Bool("tree-show-ignored", false, "List ignored files and directories in the tree structure, marked with --tree-ignored-marker")
+	cmd.Flags().String("tree-ignored-marker", combine.DefaultTreeIgnoredMarker, "Marker for ignored entries shown with --tree-show-ignored; %s stands for the name (e.g. ~~%s~~), otherwise the marker precedes it")
+	cmd.Flags().Bool("tree-counts", false, "Annotate each directory in the tree with the number of included files beneath it")
+	cmd.Flags().Bool("tree-annotations", false, "Annotate each file in the tree with its modification time, e.g. main.go [2024-01-15]")
+	cmd.Flags().String("tree-annotation-format", combine.DefaultTreeAnnotationFormat, "Go time layout of the modification times shown with --tree-annotations (e.g. \"2006-01-02 15:04\")")
+	cmd.Flags().Bool("count-tokens-per-file", false, "Include per-file and total token estimates in JSON output")
+	cmd.Flags().Bool("token-count", false, "Print each file's estimated token count and the total to stderr after writing")
+	cmd.Flags().Int("max-tokens", 0, "Stop adding files once the estimated token total would exceed this limit (0 for no limit)")
+	cmd.Flags().String("output-template-dir", "", "Directory with per-format output templates (e.g. text.tmpl, json.tmpl)")
+	cmd.Flags().String("limit-to-imports", "", "Only include Go files in the transitive import graph of this package (e.g. ./cmd/app)")
+	cmd.Flags().Bool("write-if-changed", false, "Only overwrite the output file when its hash differs from the new content")
+	cmd.Flags().String("hash-algorithm", combine.HashSHA256, "Hash algorithm for comparing files (sha256, sha512, md5, xxhash)")
+	cmd.Flags().Int("preview", 0, "Print the first N lines of the combined output to stdout instead of writing files")
+	cmd.Flags().Bool("dry-run", false, "List the files that would be combined, the binary files skipped, and the estimated output size without writing files")
+	cmd.Flags().Bool("watch", false, "Re-run the combine process whenever source files change, until interrupted")
+	cmd.Flags().StringArray("watch-after", nil, "Shell command to run after each successful run in watch mode, with the output path in $AGENTEXEC_OUTPUT (repeatable)")
+	cmd.Flags().String("pre-combine-hook", "", "Shell command to run before combining, e.g. a code generator; the run fails if it exits nonzero")
+	cmd.Flags().String("post-combine-hook", "", "Shell command to run after the output is written, with the output path in $AGENTEXEC_OUTPUT; the run fails if it exits nonzero")
+	cmd.Flags().String("otlp-endpoint", "", "Export OpenTelemetry traces of each run over OTLP/gRPC to this endpoint URL, e.g. http://localhost:4317")
+	cmd.Flags().String("output-mode", combine.OutputModeOverwrite, "How to handle an existing output file (overwrite, append, fail-if-exists)")
+	cmd.Flags().String("on-error", combine.OnErrorSkip, "How to handle files that fail to process (skip: leave them out and exit with a partial success code, fail: stop at the first error)")
+	cmd.Flags().String("require-encoding", "", "Fail files whose content is not in this encoding (UTF-8), handled according to --on-error")
+	cmd.Flags().String("combine-into-archive", "", "Write the processed files to this .tar.gz archive instead of a combined text file")
+	cmd.Flags().Bool("output-per-extension", false, "Write one combined text file per file extension (e.g. combined.go.txt) into the --output directory")
+	cmd.Flags().String("split-on-pattern", "", "Comma-separated patterns; each matching file starts a new numbered output file (e.g. \"*/CHANGELOG*,*/README*\")")
+	cmd.Flags().Bool("output-split-tree", true, "Start each split output file with a tree of its own files; if false, only the first file has the full tree")
+	cmd.Flags().Int("split-bytes", 0, "Split the output into numbered files of at most this many bytes, each starting with the full tree (0 to disable)")
+	cmd.Flags().Bool("github-action", false, "Emit GitHub Actions annotations and a step summary (auto-detected via GITHUB_ACTIONS=true)")
+	cmd.Flags().Bool("profile-patterns", false, "Report per-pattern match counts, evaluation time, and dead patterns to stderr")
+	cmd.Flags().Bool("sanitize-paths", false, "Replace the home directory and the inputs' common root in absolute output paths with <HOME> and <ROOT>")
+	cmd.Flags().Bool("progress", false, "Print \"Processing file N/M: path\" to stderr as each file is processed and \"Writing file N/M\" as it is written, followed by a summary")
+	cmd.Flags().String("progress-file", "", "Write JSON progress updates, one per line ({\"processed\",\"total\",\"current\",\"elapsed_ms\"}) to this file for external monitoring")
+	cmd.Flags().Int("progress-interval", combine.DefaultProgressInterval, "Number of files processed between two updates of --progress-file")
+	cmd.Flags().Bool("profile-io", false, "Report stat, directory listing, read, and write latencies with P50/P95/P99 and a histogram of file read times to stderr")
+	cmd.Flags().Bool("report-skipped-patterns", false, "Report ignore patterns that matched no files to stderr after the run")
+	cmd.Flags().String("filter-by-regex", "", "Only include files whose content matches this Go regular expression")
+	cmd.Flags().Bool("negate-regex", false, "Exclude files matching --filter-by-regex instead of including them")
+	cmd.Flags().Bool("incremental", false, "Reuse the processed content of files unchanged since the previous run, tracked in --cache-file")
+	cmd.Flags().String("cache-file", combine.DefaultCacheFile, "Cache file used by --incremental")
+	cmd.Flags().String("checkpoint", "", "Record processed files in this file so that an interrupted run resumes where it stopped; removed once the run completes")
+	cmd.Flags().Bool("stats", false, "Write a JSON summary of files, bytes, lines, languages, and skipped binary files after combining")
+	cmd.Flags().String("stats-output", combine.DefaultStatsOutput, "File the --stats summary is written to (use - for stdout)")
+	cmd.Flags().Bool("summary-table", false, "Print a table of files included and skipped, total size, and processing time to stderr after combining")
+	cmd.Flags().Int("warn-on-large-combined", 0, "Print a warning with ways to reduce the size to stderr when the combined content exceeds this many MB (0 to disable)")
+	cmd.Flags().Bool("lock", false, "Hold OUTPUT.lock while writing, failing if another running agentexec process holds it")
+	cmd.Flags().Bool("open", false, "Open the combined output in the default editor or viewer when done (xdg-open, open, or start); skipped without a graphical session")
+	cmd.Flags().Bool("notify-done", false, "Send a desktop notification with the duration, file count, and output path when combining completes")
+	cmd.Flags().String("header-template", "", "Go text/template for the header before each file, given .Path, .AbsPath, .SizeBytes, .Extension, .Index, and .CommentPrefix; empty uses the built-in header")
+	cmd.Flags().Bool("combine-changelog", false, "Put CHANGELOG*, CHANGES*, HISTORY*, and NEWS* files first, merging several into one section with duplicate version sections removed")
+	cmd.Flags().String("diff-output", "", "Previous combined text output; write only the files added or changed since, and list deleted files as \"# Deleted: path\" after the tree")
+	cmd.Flags().Bool("deduplicate", false, "Drop files whose content is identical to an alphabetically earlier file")
+	cmd.Flags().Bool("content-hash-dedup", false, "Keep files whose content is identical to an alphabetically earlier file, with a \"Duplicate of: path\" note in place of their content")
+	cmd.Flags().String("grep", "", "Only include files whose content matches this Go regular expression, checked while files are read")
+	cmd.Flags().Bool("detect-secrets", false, "Warn about files containing AWS access keys, GitHub tokens, or private keys, naming the kind of secret but not its value")
+	cmd.Flags().Bool("exclude-secrets", false, "Leave files with detected secrets out of the output (implies --detect-secrets)")
+	cmd.Flags().Bool("base64-encode-binary", false, "Include binary files as base64-encoded content instead of skipping them")
+	cmd.Flags().Int("min-unique-lines", 0, "Skip files whose percentage of unique lines is below this value (0 disables)")
+	cmd.Flags().Bool("exclude-init-only", false, "Skip Python __init__.py files that only re-export names with relative imports (from . import *, from .module import name)")
+	cmd.Flags().Int("require-min-files", 0, "Fail if fewer than N files remain after filtering (0 disables)")
+	cmd.Flags().Int("require-max-files", 0, "Fail if more than N files remain after filtering (0 disables)")
+	cmd.Flags().String("exclude-older-than", "", "Skip files last modified longer ago than this duration (e.g. 24h, 7d, 1y)")
+	cmd.Flags().Int("max-depth", 0, "Maximum number of directory levels descended below each input directory, for both files and tree (0 for no limit, 1 for immediate children only)")
+	cmd.Flags().Int("max-symlink-depth", 10, "Maximum number of symlink redirects followed when resolving a path (0 for no limit)")
+	cmd.Flags().Int("read-retries", 3, "Number of retries for file reads failing with transient errors")
+	cmd.Flags().Duration("read-retry-delay", 100*time.Millisecond, "Delay between file read retries")
+	cmd.Flags().Int("read-chunk-size", combine.DefaultChunkSize, "Buffer size in bytes for streaming files larger than 1 MB")
+	cmd.Flags().Bool("trim-trailing-whitespace", false, "Strip trailing whitespace from every line of file content")
+	cmd.Flags().Bool("strip-doc-comments", false, "Remove doc comments, keeping inline ones: // comments directly above top-level Go declarations and Python docstrings of functions and classes")
+	cmd.Flags().Bool("line-numbers", false, "Prefix every line of file content with its line number, padded to the width of the file's line count")
+	cmd.Flags().StringArray("content-regex-replace", nil, `Replace matches of a Go regular expression in file content, given as "PATTERN:REPLACEMENT" split at the first colon not escaped as \: (repeatable, applied in order; $1 refers to submatches)`)
+	cmd.Flags().Bool("parallel-hash", false, "Compute the SHA-256 of each file in the worker pool while reading it (included in JSON output)")
+	cmd.Flags().String("path-normalization", combine.PathNormalizationSlash, "How source paths are written in the output (slash, os, none)")
+	cmd.Flags().String("sort", combine.SortPath, "Order of the files in the output (path, depth); depth puts the shallowest files first, e.g. root READMEs and configuration")
+	cmd.Flags().Bool("group-by-package", false, "Group Go files by the package they declare, main first and then alphabetically, followed by all other files")
+	cmd.Flags().String("virtual-root", "", "Write source paths under this name, relative to the common root of all input paths")
+	cmd.Flags().Float64("binary-threshold", combine.DefaultBinaryThreshold, "Ratio of non-printable bytes (greater than 0, at most 1) above which a file is treated as binary")
+	cmd.Flags().Int("binary-sample-bytes", combine.DefaultBinarySampleBytes, "Number of leading bytes of each file inspected for binary detection")
+	cmd.Flags().StringSlice("extra-binary-ext", nil, "Additional file extensions treated as binary (e.g. .dat,.bin2)")
+	cmd.Flags().StringSlice("not-binary-ext", nil, "File extensions removed from the built-in binary extension list (e.g. .svg)")
+	cmd.Flags().StringSlice("treat-as-text", nil, "File extensions always treated as text, skipping both the extension and the content binary checks (e.g. .proto,.ipynb)")
+	cmd.Flags().Int("section-padding-before", combine.DefaultSectionPaddingBefore, "Number of blank lines before the separator line of each file section")
+	cmd.Flags().Int("section-padding-after", combine.DefaultSectionPaddingAfter, "Number of blank lines between the Source line of each file section and its content")
+	cmd.Flags().String("file-comment-format", "", "JSON map from file extension to the comment prefix used in its header, e.g. '{\"go\":\"//\",\"sql\":\"--\"}' (default \"#\")")
+	cmd.Flags().Int("include-git-log", 0, "Prepend the last N git log entries of each file as a comment block")
+}
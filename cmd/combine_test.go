@@ -0,0 +1,225 @@
+// File: cmd/combine_test.go
+package cmd
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"agentexec/pkg/combine"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// parseCombineFlags parses flags with a fresh set of combine command flags.
+func parseCombineFlags(t *testing.T, flags []string) (combine.Arguments, error) {
+	t.Helper()
+	t.Setenv("GITHUB_ACTIONS", "")
+	cmd := &cobra.Command{}
+	addCombineFlags(cmd)
+	if err := cmd.Flags().Parse(flags); err != nil {
+		t.Fatalf("Parse(%q) = %v", flags, err)
+	}
+	return parseFlags(cmd, cmd.Flags().Args(), zap.NewNop())
+}
+
+// TestPathsFile checks that the files and directories listed in a paths file, and only those,
+// are combined along with positional paths.
+func TestPathsFile(t *testing.T) {
+	project := t.TempDir()
+	files := map[string]string{
+		"main.go":         "package main\n",
+		"lib/util.go":     "package lib\n",
+		"lib/sub/deep.go": "package sub\n",
+		"docs/guide.md":   "# Guide\n",
+		"unlisted.go":     "package unlisted\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(project, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := "# Generated file list\nmain.go\n\nlib\n  main.go  \n"
+	if err := os.WriteFile(filepath.Join(project, "paths.txt"), []byte(list), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(project); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	out := t.TempDir()
+	args, err := parseCombineFlags(t, []string{"docs", "--paths-file", "paths.txt", "-o", filepath.Join(out, "combined.txt"), "-t", filepath.Join(out, "tree.txt")})
+	if err != nil {
+		t.Fatalf("parseFlags() = %v", err)
+	}
+	if want := []string{"docs", "main.go", "lib"}; !slices.Equal(args.Paths, want) {
+		t.Errorf("Paths = %q, want %q", args.Paths, want)
+	}
+	if _, err := combine.ExecuteWithContext(context.Background(), args, zap.NewNop()); err != nil {
+		t.Fatalf("ExecuteWithContext() = %v", err)
+	}
+
+	f, err := os.Open(args.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	combined, err := combine.ParseCombinedFile(f)
+	if err != nil {
+		t.Fatalf("ParseCombinedFile() = %v", err)
+	}
+	want := []string{"docs/guide.md", "lib/sub/deep.go", "lib/util.go", "main.go"}
+	if got := slices.Sorted(maps.Keys(combined)); !slices.Equal(got, want) {
+		t.Errorf("combined files = %q, want %q", got, want)
+	}
+}
+
+func TestPathsFileListingNoPaths(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "paths.txt")
+	if err := os.WriteFile(path, []byte("# nothing\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseCombineFlags(t, []string{"--paths-file", path}); err == nil || !strings.Contains(err.Error(), "lists no paths") {
+		t.Errorf("parseFlags() = %v, want an error for a paths file listing no paths", err)
+	}
+}
+
+func intPtr(n int) *int    { return &n }
+func boolPtr(b bool) *bool { return &b }
+
+// TestToFlagsRoundTrip checks that parsing the flags returned by ToFlags reproduces the arguments.
+func TestToFlagsRoundTrip(t *testing.T) {
+	// Fields the command always sets, which ToFlags writes with their defaults if unset
+	base := combine.Arguments{
+		Paths:                []string{"src", "-dash"},
+		Output:               "out/combined.txt",
+		Tree:                 "out/tree.txt",
+		MaxFileSizeKB:        512,
+		MaxWorkers:           8,
+		IgnorePatterns:       []string{"*.log", "a,b"},
+		IgnoreSyntax:         combine.SyntaxGitignore,
+		OutputFormat:         combine.FormatText,
+		EncodingDeclaration:  combine.EncodingDeclarationNone,
+		TreeFormat:           combine.TreeFormatText,
+		TreeIgnoredMarker:    combine.DefaultTreeIgnoredMarker,
+		TreeAnnotationFormat: combine.DefaultTreeAnnotationFormat,
+		HashAlgorithm:        combine.HashSHA256,
+		OutputMode:           combine.OutputModeOverwrite,
+		OnError:              combine.OnErrorSkip,
+		ProgressInterval:     combine.DefaultProgressInterval,
+		CacheFile:            combine.DefaultCacheFile,
+		StatsOutput:          combine.DefaultStatsOutput,
+		ReadChunkSize:        combine.DefaultChunkSize,
+		PathNormalization:    combine.PathNormalizationSlash,
+		Sort:                 combine.SortPath,
+		SectionPaddingBefore: intPtr(combine.DefaultSectionPaddingBefore),
+		SectionPaddingAfter:  intPtr(combine.DefaultSectionPaddingAfter),
+		BinaryThreshold:      combine.DefaultBinaryThreshold,
+		BinarySampleBytes:    combine.DefaultBinarySampleBytes,
+		WatchOnStart:         boolPtr(true),
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*combine.Arguments)
+	}{
+		{name: "defaults", modify: func(*combine.Arguments) {}},
+		{name: "no ignore patterns", modify: func(a *combine.Arguments) {
+			a.IgnorePatterns = []string{}
+		}},
+		{name: "error on empty pattern", modify: func(a *combine.Arguments) {
+			a.ErrorOnEmptyPattern = true
+			a.IncludePatterns = []string{"*.go"}
+		}},
+		{name: "many settings", modify: func(a *combine.Arguments) {
+			a.GlobalIgnoreFiles = []string{"global.ignore"}
+			a.IgnoreSyntax = combine.SyntaxGlob
+			a.RespectGitignore = true
+			a.FollowDirSymlinksOnly = true
+			a.OutputFormat = combine.FormatJSON
+			a.TreeFormat = combine.TreeFormatXML
+			a.MaxWorkersPerDir = 2
+			a.MaxTokens = 1000
+			a.HashAlgorithm = combine.HashXXHash
+			a.OutputMode = combine.OutputModeFailIfExists
+			a.OnError = combine.OnErrorFail
+			a.RequireEncoding = combine.EncodingUTF8
+			a.ExcludeOlderThan = 36 * time.Hour
+			a.ReadRetries = 2
+			a.ReadRetryDelay = 250 * time.Millisecond
+			a.ContentReplacements = []string{`/home/\w+:~`}
+			a.CommentPrefixes = map[string]string{"go": "//", "sql": "--"}
+			a.VirtualRoot = "project"
+			a.Sort = combine.SortDepth
+			a.SectionPaddingBefore = intPtr(0)
+			a.ExtraBinaryExt = []string{".dat"}
+			a.Watch = true
+			a.WatchOnStart = boolPtr(false)
+			a.WatchAfter = []string{"make test"}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := base
+			tt.modify(&want)
+
+			got, err := parseCombineFlags(t, want.ToFlags())
+			if err != nil {
+				t.Fatalf("parseFlags(%q) = %v", want.ToFlags(), err)
+			}
+			if err := got.Validate(); err != nil {
+				t.Fatalf("Validate() = %v", err)
+			}
+			gotValue, wantValue := reflect.ValueOf(got), reflect.ValueOf(want)
+			for i := 0; i < wantValue.NumField(); i++ {
+				field := wantValue.Type().Field(i)
+				if !field.IsExported() {
+					continue
+				}
+				g, w := gotValue.Field(i), wantValue.Field(i)
+				if g.Kind() == reflect.Slice && g.Len() == 0 && w.Len() == 0 {
+					continue // Flags cannot tell a nil list from an empty one
+				}
+				if !reflect.DeepEqual(g.Interface(), w.Interface()) {
+					t.Errorf("%s = %#v after the round trip, want %#v", field.Name, g.Interface(), w.Interface())
+				}
+			}
+		})
+	}
+}
+
+// TestToFlagsEmptyIgnoreWithErrorOnEmptyPattern checks that ToFlags does not produce the
+// --ignore "" that parseFlags rejects with --error-on-empty-pattern.
+func TestToFlagsEmptyIgnoreWithErrorOnEmptyPattern(t *testing.T) {
+	args := combine.Arguments{Paths: []string{"."}, ErrorOnEmptyPattern: true}
+	flags := args.ToFlags()
+	for _, flag := range flags {
+		if flag == "--ignore" {
+			t.Fatalf("ToFlags() = %q, want no --ignore flag", flags)
+		}
+	}
+	if _, err := parseCombineFlags(t, flags); err != nil {
+		t.Fatalf("parseFlags(%q) = %v", flags, err)
+	}
+}
@@ -0,0 +1,116 @@
+// File: cmd/diff.go
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"agentexec/pkg/combine"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// diffCmd compares two combined output files and reports the files that changed between them.
+var diffCmd = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Report files added, removed, or modified between two combined outputs",
+	Long: `Report files added, removed, or modified between two combined outputs.
+
+Both files must be text output of the combine command. They are split into files at the
+"Source:" section headers, and each file's content in the new output is compared to the old.
+Modified files are listed with the change in their line count. Use --json for structured output.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+// runDiff parses both combined files and writes the differences between them.
+func runDiff(cmd *cobra.Command, args []string) error {
+	logger, err := getLogger(cmd)
+	if err != nil {
+		return err
+	}
+
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		logger.Error("Failed to parse 'json' flag", zap.Error(err))
+		return fmt.Errorf("invalid 'json' flag: %w", err)
+	}
+
+	oldFiles, err := parseCombinedPath(args[0])
+	if err != nil {
+		return err
+	}
+	newFiles, err := parseCombinedPath(args[1])
+	if err != nil {
+		return err
+	}
+
+	diff := combine.DiffCombined(oldFiles, newFiles)
+	if asJSON {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diff); err != nil {
+			return fmt.Errorf("failed to write diff: %w", err)
+		}
+		return nil
+	}
+	return writeDiffSummary(cmd.OutOrStdout(), diff)
+}
+
+// parseCombinedPath parses the combined output file at path.
+func parseCombinedPath(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open combined file: %w", err)
+	}
+	defer file.Close()
+
+	files, err := combine.ParseCombinedFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return files, nil
+}
+
+// writeDiffSummary writes a human-readable summary of diff to w.
+func writeDiffSummary(w io.Writer, diff combine.CombinedDiff) error {
+	var summary strings.Builder
+	if len(diff.Added)+len(diff.Removed)+len(diff.Modified) == 0 {
+		summary.WriteString("No differences\n")
+	}
+	if len(diff.Added) > 0 {
+		fmt.Fprintf(&summary, "Added (%d):\n", len(diff.Added))
+		for _, file := range diff.Added {
+			fmt.Fprintf(&summary, "  + %s (%d lines)\n", file.Path, file.NewLines)
+		}
+	}
+	if len(diff.Removed) > 0 {
+		fmt.Fprintf(&summary, "Removed (%d):\n", len(diff.Removed))
+		for _, file := range diff.Removed {
+			fmt.Fprintf(&summary, "  - %s (%d lines)\n", file.Path, file.OldLines)
+		}
+	}
+	if len(diff.Modified) > 0 {
+		fmt.Fprintf(&summary, "Modified (%d):\n", len(diff.Modified))
+		for _, file := range diff.Modified {
+			fmt.Fprintf(&summary, "  ~ %s (%+d lines)\n", file.Path, file.LineDelta)
+		}
+	}
+	if _, err := io.WriteString(w, summary.String()); err != nil {
+		return fmt.Errorf("failed to write diff: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	addDiffFlags(diffCmd)
+}
+
+// addDiffFlags defines the flags of the diff command on cmd.
+func addDiffFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("json", false, "Write the differences as JSON")
+}
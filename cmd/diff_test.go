@@ -0,0 +1,111 @@
+// File: cmd/diff_test.go
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"agentexec/pkg/combine"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// writeCombined writes text output combining files, given as path and content pairs in order,
+// to a temporary file and returns its path.
+func writeCombined(t *testing.T, files ...string) string {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("# Directory tree\n.\n")
+	for i := 0; i+1 < len(files); i += 2 {
+		b.WriteString("\n\n# " + strings.Repeat("-", 76) + "\n# Source: " + files[i] + " #\n\n" + files[i+1])
+	}
+	path := filepath.Join(t.TempDir(), "combined.txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// runDiffOn runs the diff command with flags on the combined files at oldPath and newPath, returning what
+// it printed.
+func runDiffOn(t *testing.T, oldPath, newPath string, flags ...string) string {
+	t.Helper()
+	cmd := &cobra.Command{}
+	addDiffFlags(cmd)
+	if err := cmd.Flags().Parse(flags); err != nil {
+		t.Fatalf("Parse(%q) = %v", flags, err)
+	}
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetContext(context.WithValue(context.Background(), loggerKey, zap.NewNop()))
+	if err := runDiff(cmd, []string{oldPath, newPath}); err != nil {
+		t.Fatalf("runDiff() = %v", err)
+	}
+	return stdout.String()
+}
+
+func TestDiff(t *testing.T) {
+	oldPath := writeCombined(t,
+		"main.go", "package main\n\nfunc main() {}\n",
+		"lib/util.go", "package lib\n",
+		"lib/removed.go", "package lib\n\nvar removed = 1\n",
+		"README.md", "# Project\n",
+	)
+	newPath := writeCombined(t,
+		"main.go", "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println()\n}\n",
+		"lib/util.go", "package lib\n",
+		"lib/added.go", "package lib\n\nvar added = 1\n\nvar more = 2\n",
+		"README.md", "# Project\n\nNow with a description.\n",
+		"docs/new.md", "# New\n",
+	)
+
+	t.Run("summary", func(t *testing.T) {
+		want := "Added (2):\n" +
+			"  + docs/new.md (1 lines)\n" +
+			"  + lib/added.go (5 lines)\n" +
+			"Removed (1):\n" +
+			"  - lib/removed.go (3 lines)\n" +
+			"Modified (2):\n" +
+			"  ~ README.md (+2 lines)\n" +
+			"  ~ main.go (+4 lines)\n"
+		if got := runDiffOn(t, oldPath, newPath); got != want {
+			t.Errorf("diff output = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var got combine.CombinedDiff
+		if err := json.Unmarshal([]byte(runDiffOn(t, oldPath, newPath, "--json")), &got); err != nil {
+			t.Fatalf("failed to decode diff output: %v", err)
+		}
+		want := combine.CombinedDiff{
+			Added: []combine.DiffFile{
+				{Path: "docs/new.md", NewLines: 1, LineDelta: 1},
+				{Path: "lib/added.go", NewLines: 5, LineDelta: 5},
+			},
+			Removed: []combine.DiffFile{
+				{Path: "lib/removed.go", OldLines: 3, LineDelta: -3},
+			},
+			Modified: []combine.DiffFile{
+				{Path: "README.md", OldLines: 1, NewLines: 3, LineDelta: 2},
+				{Path: "main.go", OldLines: 3, NewLines: 7, LineDelta: 4},
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("diff = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("identical", func(t *testing.T) {
+		if got, want := runDiffOn(t, oldPath, oldPath), "No differences\n"; got != want {
+			t.Errorf("diff output = %q, want %q", got, want)
+		}
+	})
+}
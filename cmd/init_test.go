@@ -0,0 +1,119 @@
+// File: cmd/init_test.go
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"agentexec/pkg/combine"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// runInitIn runs the init command with flags in dir, returning what it printed.
+func runInitIn(t *testing.T, dir string, flags ...string) (string, error) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	cmd := &cobra.Command{}
+	addInitFlags(cmd)
+	if err := cmd.Flags().Parse(flags); err != nil {
+		t.Fatalf("Parse(%q) = %v", flags, err)
+	}
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetContext(context.WithValue(context.Background(), loggerKey, zap.NewNop()))
+	err = runInit(cmd, nil)
+	return stdout.String(), err
+}
+
+func TestInitSelectsTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		markers []string
+		flags   []string
+		want    string
+	}{
+		{name: "go", markers: []string{"go.mod"}, want: combine.ProjectGo},
+		{name: "node", markers: []string{"package.json"}, want: combine.ProjectNode},
+		{name: "python", markers: []string{"pyproject.toml"}, want: combine.ProjectPython},
+		{name: "rust", markers: []string{"Cargo.toml"}, want: combine.ProjectRust},
+		{name: "java gradle", markers: []string{"build.gradle.kts"}, want: combine.ProjectJava},
+		{name: "generic", markers: []string{"README.md"}, want: combine.ProjectGeneric},
+		{name: "go before node", markers: []string{"package.json", "go.mod"}, want: combine.ProjectGo},
+		{name: "lang overrides detection", markers: []string{"go.mod"}, flags: []string{"--lang", "Python"}, want: combine.ProjectPython},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, marker := range tt.markers {
+				if err := os.WriteFile(filepath.Join(dir, marker), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			stdout, err := runInitIn(t, dir, tt.flags...)
+			if err != nil {
+				t.Fatalf("runInit() = %v", err)
+			}
+			if want := "for a " + tt.want + " project"; !strings.Contains(stdout, want) {
+				t.Errorf("output = %q, want it to contain %q", stdout, want)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, initIgnoreFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := string(data), combine.IgnoreTemplate(tt.want); got != want {
+				t.Errorf("%s = %q, want the %s template %q", initIgnoreFile, got, tt.want, want)
+			}
+		})
+	}
+}
+
+func TestInitRefusesToOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, initIgnoreFile)
+	if err := os.WriteFile(path, []byte("custom\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runInitIn(t, dir); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("runInit() = %v, want an error suggesting --force", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "custom\n" {
+		t.Fatalf("%s = %q after a refused init, want it unchanged", initIgnoreFile, data)
+	}
+
+	if _, err := runInitIn(t, dir, "--force"); err != nil {
+		t.Fatalf("runInit(--force) = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != combine.IgnoreTemplate(combine.ProjectGeneric) {
+		t.Errorf("%s = %q after init --force, want the generic template", initIgnoreFile, data)
+	}
+}
+
+func TestInitRejectsUnknownLanguage(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := runInitIn(t, dir, "--lang", "cobol"); err == nil {
+		t.Fatal("runInit(--lang cobol) = nil, want an error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, initIgnoreFile)); !os.IsNotExist(err) {
+		t.Errorf("Stat(%s) = %v, want it not to exist", initIgnoreFile, err)
+	}
+}
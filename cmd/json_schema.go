@@ -0,0 +1,43 @@
+// File: cmd/json_schema.go
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"agentexec/pkg/combine"
+
+	"github.com/spf13/cobra"
+)
+
+// jsonSchemaCmd prints the JSON Schema of the configuration files or of the combine result.
+var jsonSchemaCmd = &cobra.Command{
+	Use:   "json-schema <arguments|result>",
+	Short: "Print the JSON Schema of configuration files or of the combine result",
+	Long: `Print the JSON Schema of configuration files or of the combine result.
+
+"arguments" describes the files read with combine --from-config, e.g. .agentexec.yaml, for
+validation and autocompletion in editors:
+
+  agentexec json-schema arguments > agentexec.schema.json
+
+"result" describes the summary of a combine run returned by combine.ExecuteWithContext.`,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"arguments", "result"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var schema map[string]any
+		switch args[0] {
+		case "arguments":
+			schema = combine.ArgumentsSchema()
+		case "result":
+			schema = combine.CombineResultSchema()
+		}
+
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(schema); err != nil {
+			return fmt.Errorf("failed to write schema: %w", err)
+		}
+		return nil
+	},
+}
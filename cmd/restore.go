@@ -0,0 +1,76 @@
+// File: cmd/restore.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"agentexec/pkg/combine"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// restoreCmd recreates the files of a combined output file, the inverse of the combine command.
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Recreate the files of a combined output file in a directory",
+	Long: `Recreate the files of a combined output file in a directory.
+
+The input must be text output of the combine command. The tree and anything else before the
+first "Source:" section header is skipped, and each file is written below --output-dir at its
+source path. Binary files included with --base64-encode-binary are decoded. Source paths that
+are absolute or lead outside the output directory are rejected.`,
+	Args: cobra.NoArgs,
+	RunE: runRestore,
+}
+
+// runRestore restores the files of the combined file given by --input.
+func runRestore(cmd *cobra.Command, args []string) error {
+	logger, err := getLogger(cmd)
+	if err != nil {
+		return err
+	}
+
+	input, err := cmd.Flags().GetString("input")
+	if err != nil {
+		logger.Error("Failed to parse 'input' flag", zap.Error(err))
+		return fmt.Errorf("invalid 'input' flag: %w", err)
+	}
+	if input == "" {
+		return fmt.Errorf("invalid 'input' flag: a combined file is required")
+	}
+
+	outputDir, err := cmd.Flags().GetString("output-dir")
+	if err != nil {
+		logger.Error("Failed to parse 'output-dir' flag", zap.Error(err))
+		return fmt.Errorf("invalid 'output-dir' flag: %w", err)
+	}
+
+	overwrite, err := cmd.Flags().GetBool("overwrite")
+	if err != nil {
+		logger.Error("Failed to parse 'overwrite' flag", zap.Error(err))
+		return fmt.Errorf("invalid 'overwrite' flag: %w", err)
+	}
+
+	file, err := os.Open(input)
+	if err != nil {
+		return fmt.Errorf("failed to open combined file: %w", err)
+	}
+	defer file.Close()
+
+	restored, err := combine.RestoreCombinedFile(file, outputDir, overwrite, logger)
+	if err != nil {
+		logger.Error("Failed to restore combined file", zap.String("input", input), zap.Error(err))
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Restored %d files to %s\n", restored, outputDir)
+	return nil
+}
+
+func init() {
+	// Define flags specific to the restore command
+	restoreCmd.Flags().StringP("input", "i", "", "Combined text file to restore")
+	restoreCmd.Flags().StringP("output-dir", "o", "restored", "Directory the files are written to")
+	restoreCmd.Flags().Bool("overwrite", false, "Replace existing files instead of failing")
+}
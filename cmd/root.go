@@ -23,6 +23,15 @@ var RootCmd = &cobra.Command{
 	Long:  `AgentExec is a command-line interface tool designed to perform various tasks.`,
 }
 
+// exitCode is the process exit code reported by the last command that ran.
+var exitCode int
+
+// ExitCode returns the process exit code reported by the command run by Execute.
+// It is only meaningful when Execute returned no error.
+func ExitCode() int {
+	return exitCode
+}
+
 // Execute initializes the root command with the provided logger and executes it.
 // It sets up the context to include the logger for use in subcommands.
 func Execute(logger *zap.Logger) error {
@@ -38,7 +47,13 @@ func Execute(logger *zap.Logger) error {
 
 func init() {
 	// Initialize and add subcommands to the root command.
-	// Ensure that combineCmd and versionCmd are properly defined in their respective files.
+	// Ensure that combineCmd, versionCmd, and serveCmd are properly defined in their respective files.
 	RootCmd.AddCommand(combineCmd)
 	RootCmd.AddCommand(versionCmd)
+	RootCmd.AddCommand(serveCmd)
+	RootCmd.AddCommand(testIgnoreCmd)
+	RootCmd.AddCommand(initCmd)
+	RootCmd.AddCommand(diffCmd)
+	RootCmd.AddCommand(jsonSchemaCmd)
+	RootCmd.AddCommand(restoreCmd)
 }
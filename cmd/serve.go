@@ -0,0 +1,279 @@
+// File: cmd/serve.go
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"agentexec/pkg/combine"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// maxRequestBodyBytes limits the size of JSON bodies accepted by the HTTP API.
+const maxRequestBodyBytes = 1 << 20
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Expose the combine functionality over an HTTP API",
+	Long: `Expose the combine functionality over an HTTP API.
+
+Endpoints:
+  POST /combine   Accepts a JSON body (Content-Type: application/json) with the paths,
+                  ignore and include patterns, format, and size limit to combine with,
+                  and returns the combined content.
+  GET  /tree      Returns the tree structure for the comma-separated 'paths' query parameter.`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+// runServe registers the HTTP handlers and starts the server.
+func runServe(cmd *cobra.Command, args []string) error {
+	logger, err := getLogger(cmd)
+	if err != nil {
+		return err
+	}
+
+	host, err := cmd.Flags().GetString("host")
+	if err != nil {
+		logger.Error("Failed to parse 'host' flag", zap.Error(err))
+		return fmt.Errorf("invalid 'host' flag: %w", err)
+	}
+
+	port, err := cmd.Flags().GetInt("port")
+	if err != nil {
+		logger.Error("Failed to parse 'port' flag", zap.Error(err))
+		return fmt.Errorf("invalid 'port' flag: %w", err)
+	}
+	if port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid 'port' flag: %d is out of range", port)
+	}
+
+	http.HandleFunc("/combine", handleCombine(logger))
+	http.HandleFunc("/tree", handleTree(logger))
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", host, port),
+		Handler:           http.DefaultServeMux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	logger.Info("Starting HTTP API server", zap.String("address", server.Addr))
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("HTTP API server failed", zap.Error(err))
+		return fmt.Errorf("http server failed: %w", err)
+	}
+	return nil
+}
+
+// combineRequest is the JSON body accepted by the /combine endpoint. It is limited to settings
+// that only select and format what is read, so that a client cannot make the server write files,
+// run commands, or print to its own stdout; fields not listed here are rejected.
+type combineRequest struct {
+	Paths            []string `json:"paths"`
+	IgnorePatterns   []string `json:"ignorePatterns"`
+	IncludePatterns  []string `json:"includePatterns"`
+	IgnoreSyntax     string   `json:"ignoreSyntax"`
+	IgnoreVCS        bool     `json:"ignoreVCS"`
+	RespectGitignore bool     `json:"respectGitignore"`
+	OutputFormat     string   `json:"outputFormat"`
+	MaxFileSizeKB    int      `json:"maxFileSizeKB"`
+	MaxDepth         int      `json:"maxDepth"`
+	MaxTokens        int      `json:"maxTokens"`
+}
+
+// arguments returns the combine arguments for the request, writing to output and tree.
+func (req combineRequest) arguments(output, tree string) combine.Arguments {
+	maxFileSizeKB := req.MaxFileSizeKB
+	if maxFileSizeKB <= 0 {
+		maxFileSizeKB = combine.DefaultMaxFileSizeKB
+	}
+	return combine.Arguments{
+		Paths:            req.Paths,
+		Output:           output,
+		Tree:             tree,
+		MaxFileSizeKB:    maxFileSizeKB,
+		IgnorePatterns:   req.IgnorePatterns,
+		IncludePatterns:  req.IncludePatterns,
+		IgnoreSyntax:     strings.ToLower(req.IgnoreSyntax),
+		IgnoreVCS:        req.IgnoreVCS,
+		RespectGitignore: req.RespectGitignore,
+		OutputFormat:     req.OutputFormat,
+		MaxDepth:         req.MaxDepth,
+		MaxTokens:        req.MaxTokens,
+		NonInteractive:   true,
+	}
+}
+
+// handleCombine runs the combine process for a JSON-encoded combineRequest body and returns the combined content.
+// The output and tree locations are always chosen by the server.
+func handleCombine(logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		// Browsers send the Origin of cross-site requests, which must not reach the local files
+		if !sameOrigin(r) {
+			http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+			return
+		}
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var req combineRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if len(req.Paths) == 0 {
+			http.Error(w, "at least one path is required", http.StatusBadRequest)
+			return
+		}
+		for _, path := range req.Paths {
+			if _, err := os.Stat(path); err != nil {
+				http.Error(w, fmt.Sprintf("path %q cannot be accessed", path), http.StatusBadRequest)
+				return
+			}
+		}
+		req.OutputFormat = strings.ToLower(req.OutputFormat)
+		if req.OutputFormat == "" {
+			req.OutputFormat = combine.FormatText
+		}
+		switch req.OutputFormat {
+		case combine.FormatText, combine.FormatJSON, combine.FormatYAML:
+		default:
+			http.Error(w, fmt.Sprintf("unsupported format %q", req.OutputFormat), http.StatusBadRequest)
+			return
+		}
+
+		workDir, err := os.MkdirTemp("", "agentexec-serve-")
+		if err != nil {
+			logger.Error("Failed to create working directory", zap.Error(err))
+			http.Error(w, "failed to prepare combine", http.StatusInternalServerError)
+			return
+		}
+		defer os.RemoveAll(workDir)
+
+		combineArgs := req.arguments(filepath.Join(workDir, "combined"), filepath.Join(workDir, "tree.txt"))
+		if err := combineArgs.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if _, err := combine.ExecuteWithContext(r.Context(), combineArgs, logger); err != nil {
+			logger.Error("Combine request failed", zap.Error(err))
+			http.Error(w, fmt.Sprintf("combine failed: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		content, err := os.ReadFile(combineArgs.Output)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				http.Error(w, "no files to combine after filtering", http.StatusUnprocessableEntity)
+				return
+			}
+			logger.Error("Failed to read combined output", zap.Error(err))
+			http.Error(w, "failed to read combined output", http.StatusInternalServerError)
+			return
+		}
+
+		contentType := "text/plain; charset=utf-8"
+		switch combineArgs.OutputFormat {
+		case combine.FormatJSON:
+			contentType = "application/json"
+		case combine.FormatYAML:
+			contentType = "application/yaml"
+		}
+		w.Header().Set("Content-Type", contentType)
+		if _, err := w.Write(content); err != nil {
+			logger.Warn("Failed to write combine response", zap.Error(err))
+		}
+	}
+}
+
+// sameOrigin reports whether r has no Origin header or one naming the host it was sent to.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// handleTree returns the tree structure for the requested paths.
+func handleTree(logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		// Listing local paths for another site would expose the filesystem to any page
+		if !sameOrigin(r) {
+			http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+			return
+		}
+
+		var paths []string
+		for _, value := range r.URL.Query()["paths"] {
+			for _, path := range strings.Split(value, ",") {
+				if path = strings.TrimSpace(path); path != "" {
+					paths = append(paths, path)
+				}
+			}
+		}
+		if len(paths) == 0 {
+			http.Error(w, "query parameter 'paths' is required", http.StatusBadRequest)
+			return
+		}
+		for _, path := range paths {
+			if _, err := os.Stat(path); err != nil {
+				http.Error(w, fmt.Sprintf("path %q cannot be accessed", path), http.StatusBadRequest)
+				return
+			}
+		}
+
+		gi, err := combine.LoadIgnoreFiles([]string{os.Getenv("COMBINEIGNORE_GLOBAL")}, logger)
+		if err != nil {
+			logger.Error("Failed to load ignore patterns", zap.Error(err))
+			http.Error(w, "failed to load ignore patterns", http.StatusInternalServerError)
+			return
+		}
+
+		tree, err := combine.GenerateFullTree(paths, gi, combine.TreeOptions{}, combine.WithLogger(logger))
+		if err != nil {
+			logger.Error("Failed to generate tree structure", zap.Error(err))
+			http.Error(w, "failed to generate tree structure", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if _, err := w.Write([]byte(tree)); err != nil {
+			logger.Warn("Failed to write tree response", zap.Error(err))
+		}
+	}
+}
+
+func init() {
+	// Define flags specific to the serve command
+	serveCmd.Flags().String("host", "localhost", "Host interface to listen on")
+	serveCmd.Flags().IntP("port", "p", 8080, "Port to listen on")
+}
@@ -0,0 +1,120 @@
+// File: cmd/serve_test.go
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// postCombine sends body to the /combine handler with the given headers and returns the response.
+func postCombine(t *testing.T, body any, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/combine", strings.NewReader(string(data)))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	handleCombine(zap.NewNop())(rec, req)
+	return rec
+}
+
+// newServeSource returns a directory holding a single Go file to combine.
+func newServeSource(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+var jsonHeaders = map[string]string{"Content-Type": "application/json"}
+
+func TestHandleCombineRejectsHooks(t *testing.T) {
+	dir := newServeSource(t)
+	marker := filepath.Join(t.TempDir(), "hook-ran")
+
+	for _, field := range []string{"preCombineHook", "postCombineHook", "watchAfter"} {
+		t.Run(field, func(t *testing.T) {
+			body := map[string]any{"paths": []string{dir}, field: "touch " + marker}
+			rec := postCombine(t, body, jsonHeaders)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+			}
+			if _, err := os.Stat(marker); err == nil {
+				t.Fatal("hook command was run")
+			}
+		})
+	}
+}
+
+func TestHandleCombineRejectsServerSideFiles(t *testing.T) {
+	dir := newServeSource(t)
+	for _, field := range []string{"statsOutput", "cacheFile", "checkpoint", "progressFile", "combineIntoArchive", "diffOutput", "output", "dryRun", "preview"} {
+		t.Run(field, func(t *testing.T) {
+			body := map[string]any{"paths": []string{dir}, field: filepath.Join(t.TempDir(), "x")}
+			if rec := postCombine(t, body, jsonHeaders); rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+			}
+		})
+	}
+}
+
+func TestHandleCombineRequiresJSON(t *testing.T) {
+	dir := newServeSource(t)
+	body := map[string]any{"paths": []string{dir}}
+	for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded"} {
+		rec := postCombine(t, body, map[string]string{"Content-Type": contentType})
+		if rec.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("Content-Type %q: status = %d, want %d", contentType, rec.Code, http.StatusUnsupportedMediaType)
+		}
+	}
+}
+
+func TestHandleCombineRejectsCrossOrigin(t *testing.T) {
+	dir := newServeSource(t)
+	body := map[string]any{"paths": []string{dir}}
+	headers := map[string]string{"Content-Type": "application/json", "Origin": "https://attacker.example"}
+	if rec := postCombine(t, body, headers); rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestHandleTreeRejectsCrossOrigin(t *testing.T) {
+	dir := newServeSource(t)
+	req := httptest.NewRequest(http.MethodGet, "/tree?paths="+url.QueryEscape(dir), nil)
+	req.Header.Set("Origin", "https://attacker.example")
+	rec := httptest.NewRecorder()
+	handleTree(zap.NewNop())(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestHandleCombine(t *testing.T) {
+	dir := newServeSource(t)
+	body := map[string]any{"paths": []string{dir}, "outputFormat": "json", "maxDepth": 1}
+	headers := map[string]string{"Content-Type": "application/json; charset=utf-8", "Origin": "http://example.com"}
+	rec := postCombine(t, body, headers)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	if !strings.Contains(rec.Body.String(), "package main") {
+		t.Errorf("response does not contain the combined file: %s", rec.Body)
+	}
+}
@@ -0,0 +1,132 @@
+// File: cmd/test_ignore.go
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"agentexec/pkg/combine"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+// testIgnoreCmd reports which ignore pattern, if any, decides whether each path is ignored.
+var testIgnoreCmd = &cobra.Command{
+	Use:   "test-ignore [paths...]",
+	Short: "Show which ignore patterns match the given paths",
+	Long: `Show which ignore patterns match the given paths.
+
+Patterns are loaded the same way as for the combine command: from .combineignore files
+in the current and parent directories and the global ignore file in $COMBINEIGNORE_GLOBAL.
+Use --ignore-file to test a single ignore file instead, and --pattern to add patterns.
+For each path the last matching pattern, its source, and whether it was negated are printed.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runTestIgnore,
+}
+
+// runTestIgnore loads the ignore patterns and prints the verdict for each path as a table.
+func runTestIgnore(cmd *cobra.Command, args []string) error {
+	logger, err := getLogger(cmd)
+	if err != nil {
+		return err
+	}
+
+	ignoreFile, err := cmd.Flags().GetString("ignore-file")
+	if err != nil {
+		logger.Error("Failed to parse 'ignore-file' flag", zap.Error(err))
+		return fmt.Errorf("invalid 'ignore-file' flag: %w", err)
+	}
+
+	patterns, err := cmd.Flags().GetStringSlice("pattern")
+	if err != nil {
+		logger.Error("Failed to parse 'pattern' flag", zap.Error(err))
+		return fmt.Errorf("invalid 'pattern' flag: %w", err)
+	}
+
+	var gi *combine.CombineIgnore
+	if ignoreFile != "" {
+		if _, err := os.Stat(ignoreFile); err != nil {
+			return fmt.Errorf("invalid 'ignore-file' flag: %w", err)
+		}
+		gi = combine.NewCombineIgnore(logger)
+		if err := gi.CompileIgnoreFile(ignoreFile); err != nil {
+			return fmt.Errorf("failed to load ignore file: %w", err)
+		}
+	} else {
+		gi, err = combine.LoadIgnoreFiles([]string{os.Getenv("COMBINEIGNORE_GLOBAL")}, logger)
+		if err != nil {
+			logger.Error("Failed to load ignore patterns", zap.Error(err))
+			return fmt.Errorf("failed to load ignore patterns: %w", err)
+		}
+	}
+	if errs := gi.CompileIgnoreLines(patterns...); len(errs) > 0 {
+		joined := make([]error, len(errs))
+		for i, err := range errs {
+			joined[i] = err
+		}
+		return fmt.Errorf("invalid 'pattern' flag: %w", errors.Join(joined...))
+	}
+
+	return writeIgnoreVerdicts(cmd.OutOrStdout(), gi, args)
+}
+
+// writeIgnoreVerdicts writes one table row per path with the match result and the deciding pattern.
+func writeIgnoreVerdicts(w io.Writer, gi *combine.CombineIgnore, paths []string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tRESULT\tPATTERN\tSOURCE")
+	for _, path := range paths {
+		matched, pattern := gi.MatchesPathWithPattern(path)
+
+		result := "included"
+		switch {
+		case matched:
+			result = "ignored"
+		case pattern != nil && pattern.Negate:
+			result = "included (negated)"
+		}
+
+		// A file inside an ignored directory is never reached during traversal
+		if parent, parentPattern := ignoredParent(gi, path); parentPattern != nil && !matched {
+			result, pattern = fmt.Sprintf("ignored (parent %s)", parent), parentPattern
+		}
+
+		line, source := "-", "-"
+		if pattern != nil {
+			line = strings.TrimSpace(pattern.Line)
+			source = "--pattern"
+			if pattern.Source != "" {
+				source = fmt.Sprintf("%s:%d", pattern.Source, pattern.LineNo)
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", path, result, line, source)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	return nil
+}
+
+// ignoredParent returns the first ancestor directory of path that the ignore patterns exclude,
+// together with the pattern that excludes it.
+func ignoredParent(gi *combine.CombineIgnore, path string) (string, *combine.IgnorePattern) {
+	components := strings.Split(strings.Trim(filepath.ToSlash(path), "/"), "/")
+	for i := 1; i < len(components); i++ {
+		parent := strings.Join(components[:i], "/") + "/"
+		if matched, pattern := gi.MatchesPathWithPattern(parent); matched {
+			return parent, pattern
+		}
+	}
+	return "", nil
+}
+
+func init() {
+	// Define flags specific to the test-ignore command
+	testIgnoreCmd.Flags().String("ignore-file", "", "Load patterns from this file instead of discovering .combineignore files")
+	testIgnoreCmd.Flags().StringSliceP("pattern", "p", nil, "Additional gitignore-style patterns to test")
+}
@@ -2,6 +2,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"agentexec/pkg/version"
@@ -11,7 +12,8 @@ import (
 
 // versionCmd represents the version command.
 // It displays the current version of the AgentExec application.
-// The --short flag allows users to retrieve a concise version string.
+// The --short flag allows users to retrieve a concise version string, and the --json flag
+// prints the version information as a JSON object for scripts.
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Display the version of AgentExec",
@@ -22,11 +24,24 @@ var versionCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("error reading flags: %w", err)
 		}
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return fmt.Errorf("error reading flags: %w", err)
+		}
+		if short && asJSON {
+			return fmt.Errorf("invalid 'json' flag: cannot be combined with --short")
+		}
 
 		// Fetch version information
 		v := version.Get()
 
-		if short {
+		if asJSON {
+			data, err := json.MarshalIndent(v, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode version information: %w", err)
+			}
+			fmt.Println(string(data))
+		} else if short {
 			// If --short is provided, print only the version number
 			fmt.Println(v.Version)
 		} else {
@@ -41,6 +56,7 @@ var versionCmd = &cobra.Command{
 func init() {
 	// Define the --short flag for the version command
 	versionCmd.Flags().BoolP("short", "s", false, "Print the version number only")
+	versionCmd.Flags().Bool("json", false, "Print the version information as a JSON object")
 
 	// Add the version command to the root command
 	RootCmd.AddCommand(versionCmd)
@@ -29,8 +29,8 @@ func createLogger(verbose bool) (*zap.Logger, error) {
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
 
-	// Create stdout syncer
-	stdout := zapcore.AddSync(os.Stdout)
+	// Log to stderr so that stdout can carry the combined output
+	stderr := zapcore.AddSync(os.Stderr)
 
 	// Determine log level based on verbose flag
 	level := zap.InfoLevel
@@ -40,7 +40,7 @@ func createLogger(verbose bool) (*zap.Logger, error) {
 
 	// Create console encoder and core
 	consoleEncoder := zapcore.NewConsoleEncoder(encoderConfig)
-	core := zapcore.NewCore(consoleEncoder, stdout, level)
+	core := zapcore.NewCore(consoleEncoder, stderr, level)
 
 	// Get build info for startup logging only
 	buildInfo, _ := debug.ReadBuildInfo()
@@ -90,4 +90,6 @@ func main() {
 		)
 		os.Exit(1)
 	}
+	_ = logger.Sync()
+	os.Exit(cmd.ExitCode())
 }
@@ -0,0 +1,77 @@
+// File: pkg/combine/archive.go
+package combine
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// archiveTreeName is the name of the tree structure entry at the root of the archive.
+const archiveTreeName = "TREE.txt"
+
+// WriteArchive writes the tree and every processed file to a gzip-compressed tar archive.
+// Each file becomes an entry named after its relative path; the tree is stored as TREE.txt.
+func WriteArchive(archivePath string, treeContent string, combinedContents []FileContent, logger *zap.Logger) (err error) {
+	logger.Debug("Writing combined content to archive", zap.String("archive", archivePath))
+
+	outFile, err := os.Create(archivePath)
+	if err != nil {
+		logger.Error("Failed to create archive", zap.String("file", archivePath), zap.Error(err))
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer func() {
+		if closeErr := outFile.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close archive: %w", closeErr)
+		}
+	}()
+
+	gzipWriter := gzip.NewWriter(outFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+	modTime := time.Now()
+
+	if err := writeArchiveEntry(tarWriter, archiveTreeName, treeContent, modTime); err != nil {
+		logger.Error("Failed to write tree to archive", zap.String("file", archivePath), zap.Error(err))
+		return err
+	}
+	for _, content := range combinedContents {
+		if err := writeArchiveEntry(tarWriter, content.Path, content.Content, modTime); err != nil {
+			logger.Error("Failed to write file to archive",
+				zap.String("file", archivePath),
+				zap.String("contentPath", content.Path),
+				zap.Error(err))
+			return err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finalize tar archive: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finalize gzip stream: %w", err)
+	}
+
+	logger.Debug("Wrote archive", zap.String("archive", archivePath), zap.Int("entries", len(combinedContents)+1))
+	return nil
+}
+
+// writeArchiveEntry writes a single regular file entry to the tar archive.
+func writeArchiveEntry(tw *tar.Writer, name, content string, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(content)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write archive header for %s: %w", name, err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		return fmt.Errorf("failed to write archive entry %s: %w", name, err)
+	}
+	return nil
+}
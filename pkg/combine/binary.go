@@ -3,29 +3,115 @@ package combine
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
-	"os"
+	"io/fs"
+	"mime"
+	"net/http"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"go.uber.org/zap"
 )
 
-// isBinaryFile checks if a file is likely to be binary by reading its first few bytes
-// and checking for null bytes or a high ratio of non-printable characters
-func isBinaryFile(filePath string) (bool, error) {
-	file, err := os.Open(filePath)
+// base64LineLength is the line width used when embedding base64-encoded binary content.
+const base64LineLength = 76
+
+// magicBytesLen is the number of leading bytes compared against binarySignatures.
+const magicBytesLen = 8
+
+// binarySignatures are the leading bytes of common binary formats, at most magicBytesLen long.
+// Formats whose signature could plausibly start a text file, such as "MZ" or "BM", are left to
+// the content heuristic.
+var binarySignatures = [][]byte{
+	[]byte("%PDF-"),                    // PDF
+	[]byte("\x89PNG\r\n\x1a\n"),        // PNG
+	{0xFF, 0xD8, 0xFF},                 // JPEG
+	[]byte("GIF87a"),                   // GIF
+	[]byte("GIF89a"),                   // GIF
+	{'P', 'K', 0x03, 0x04},             // ZIP, JAR, and Office documents
+	{'P', 'K', 0x05, 0x06},             // Empty ZIP
+	{0x1F, 0x8B},                       // gzip
+	{0xFD, '7', 'z', 'X', 'Z', 0x00},   // xz
+	{0x28, 0xB5, 0x2F, 0xFD},           // Zstandard
+	{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}, // 7-Zip
+	{'R', 'a', 'r', '!', 0x1A, 0x07},   // RAR
+	{0x7F, 'E', 'L', 'F'},              // ELF
+	{0xFE, 0xED, 0xFA, 0xCE},           // Mach-O, 32-bit
+	{0xFE, 0xED, 0xFA, 0xCF},           // Mach-O, 64-bit
+	{0xCE, 0xFA, 0xED, 0xFE},           // Mach-O, 32-bit little-endian
+	{0xCF, 0xFA, 0xED, 0xFE},           // Mach-O, 64-bit little-endian
+	{0xCA, 0xFE, 0xBA, 0xBE},           // Java class and Mach-O universal binary
+	{0x00, 'a', 's', 'm'},              // WebAssembly
+	[]byte("SQLite f"),                 // SQLite database
+}
+
+// hasBinarySignature reports whether header starts with one of binarySignatures.
+func hasBinarySignature(header []byte) bool {
+	for _, signature := range binarySignatures {
+		if bytes.HasPrefix(header, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// BinaryDetector decides whether files are binary from their extension or a sample of their content.
+// The zero value uses the default threshold, sample size, and BinaryExtensions.
+type BinaryDetector struct {
+	Threshold       float64  // Ratio of non-printable bytes above which content is binary; zero selects DefaultBinaryThreshold.
+	SampleBytes     int      // Number of leading bytes inspected; non-positive selects DefaultBinarySampleBytes.
+	ExtraExtensions []string // Extensions treated as binary in addition to BinaryExtensions, e.g. ".dat".
+	NotExtensions   []string // Extensions from BinaryExtensions that are not treated as binary.
+	TextExtensions  []string // Extensions always treated as text, bypassing both the extension and content checks.
+}
+
+// IsBinaryFile checks if a file is likely to be binary by reading its first few bytes
+// and checking for the signature of a known binary format, null bytes, or a high ratio of
+// non-printable characters
+func (d BinaryDetector) IsBinaryFile(filePath string) (bool, error) {
+	return d.isBinaryFile(nil, filePath)
+}
+
+// isBinaryFile is IsBinaryFile reading the file from fsys, or from the host filesystem when
+// fsys is nil.
+func (d BinaryDetector) isBinaryFile(fsys fs.FS, filePath string) (bool, error) {
+	file, err := openFS(fsys, filePath)
 	if err != nil {
 		return false, err
 	}
 	defer file.Close()
 
-	// Read first 512 bytes to check content type
-	buffer := make([]byte, 512)
-	n, err := file.Read(buffer)
-	if err != nil && !errors.Is(err, io.EOF) {
+	sampleBytes := d.SampleBytes
+	if sampleBytes <= 0 {
+		sampleBytes = DefaultBinarySampleBytes
+	}
+	threshold := d.Threshold
+	if threshold == 0 {
+		threshold = DefaultBinaryThreshold
+	}
+
+	// Read the first bytes to check content type, starting with those holding a format signature
+	buffer := make([]byte, max(sampleBytes, magicBytesLen))
+	n, err := io.ReadFull(file, buffer[:magicBytesLen])
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
 		return false, err
 	}
-	buffer = buffer[:n]
+	if hasBinarySignature(buffer[:n]) {
+		return true, nil
+	}
+	if n == magicBytesLen && sampleBytes > n {
+		rest, err := io.ReadFull(file, buffer[n:sampleBytes])
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, err
+		}
+		n += rest
+	}
+	buffer = buffer[:min(n, sampleBytes)]
 
 	// Check for null bytes (common in binary files)
 	if bytes.Contains(buffer, []byte{0}) {
@@ -40,11 +126,11 @@ func isBinaryFile(filePath string) (bool, error) {
 		}
 	}
 
-	// If more than 30% non-printable characters, consider it binary
+	// If the share of non-printable characters exceeds the threshold, consider it binary
 	if len(buffer) == 0 {
 		return false, nil // Empty files are considered text
 	}
-	return float64(nonPrintable)/float64(len(buffer)) > 0.3, nil
+	return float64(nonPrintable)/float64(len(buffer)) > threshold, nil
 }
 
 // isPrintable checks if a byte represents a printable ASCII character
@@ -52,8 +138,110 @@ func isPrintable(b byte) bool {
 	return (b >= 32 && b <= 126) || b == '\n' || b == '\r' || b == '\t'
 }
 
-// isCommonBinaryExtension checks if the file has a known binary extension
-func isCommonBinaryExtension(path string) bool {
+// HasBinaryExtension checks if the file has a known binary extension, taking the detector's
+// extra and excluded extensions into account.
+func (d BinaryDetector) HasBinaryExtension(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return false
+	}
+	for _, notBinary := range d.NotExtensions {
+		if normalizeExtension(notBinary) == ext {
+			return false
+		}
+	}
+	for _, extra := range d.ExtraExtensions {
+		if normalizeExtension(extra) == ext {
+			return true
+		}
+	}
 	return BinaryExtensions[ext]
 }
+
+// HasTextExtension reports whether the file has one of the detector's text extensions, so that
+// neither HasBinaryExtension nor IsBinaryFile should be consulted for it.
+func (d BinaryDetector) HasTextExtension(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return false
+	}
+	for _, text := range d.TextExtensions {
+		if normalizeExtension(text) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeExtension returns ext in lower case with a leading dot, e.g. ".png" for "PNG".
+func normalizeExtension(ext string) string {
+	return "." + strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+}
+
+// EncodeBinaryFile reads a binary file and formats it as base64-encoded content,
+// preceded by a comment line describing its MIME type.
+func EncodeBinaryFile(ctx context.Context, filePath, parentDir string, opts ProcessOptions, logger *zap.Logger) (FileContent, error) {
+	relativePath := opts.sourcePath(filePath, parentDir, logger)
+
+	readStart := time.Now()
+	data, err := readFileWithRetry(ctx, filePath, opts.ReadRetries, opts.ReadRetryDelay, opts.ChunkSize, logger)
+	opts.ioProfile.observe(ioRead, readStart)
+	if err != nil {
+		logger.Error("Failed to read binary file", zap.String("filePath", filePath), zap.Error(err))
+		return FileContent{}, fmt.Errorf("error reading file %s: %w", filePath, err)
+	}
+
+	mimeType := detectMIMEType(filePath, data)
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	var content strings.Builder
+	content.Grow(len(encoded) + len(encoded)/base64LineLength + 64)
+	content.WriteString(base64BinaryMarker + mimeType + "\n")
+	for len(encoded) > base64LineLength {
+		content.WriteString(encoded[:base64LineLength] + "\n")
+		encoded = encoded[base64LineLength:]
+	}
+	if encoded != "" {
+		content.WriteString(encoded + "\n")
+	}
+
+	logger.Debug("Encoded binary file",
+		zap.String("filePath", filePath),
+		zap.String("mimeType", mimeType),
+		zap.Int("sizeBytes", len(data)))
+
+	fc := FileContent{
+		Path:    relativePath,
+		AbsPath: filePath,
+		Size:    int64(len(data)),
+		Header:  opts.sectionHeader(relativePath, opts.commentPrefix(filePath)),
+		Content: content.String(),
+	}
+	if opts.CountTokens {
+		fc.Tokens = CountTokens(fc.Content)
+	}
+	return fc, nil
+}
+
+// ReadBinaryFile reads a binary file unmodified for storage in an archive output format.
+func ReadBinaryFile(ctx context.Context, filePath, parentDir string, opts ProcessOptions, logger *zap.Logger) (BinaryFile, error) {
+	readStart := time.Now()
+	data, err := readFileWithRetry(ctx, filePath, opts.ReadRetries, opts.ReadRetryDelay, opts.ChunkSize, logger)
+	opts.ioProfile.observe(ioRead, readStart)
+	if err != nil {
+		logger.Error("Failed to read binary file", zap.String("filePath", filePath), zap.Error(err))
+		return BinaryFile{}, fmt.Errorf("error reading file %s: %w", filePath, err)
+	}
+	return BinaryFile{
+		Path:    opts.sourcePath(filePath, parentDir, logger),
+		Content: data,
+	}, nil
+}
+
+// detectMIMEType determines the MIME type of a file from its extension, falling back to content sniffing.
+func detectMIMEType(filePath string, data []byte) string {
+	if mimeType := mime.TypeByExtension(filepath.Ext(filePath)); mimeType != "" {
+		return mimeType
+	}
+	return http.DetectContentType(data)
+}
@@ -0,0 +1,90 @@
+// File: pkg/combine/binary_test.go
+package combine_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"agentexec/pkg/combine"
+)
+
+// TestBinaryDetectorThreshold checks that content with 25% non-printable bytes is binary only
+// for thresholds below that ratio, and that only the sampled bytes count.
+func TestBinaryDetectorThreshold(t *testing.T) {
+	// Every fourth byte is a non-printable control character, without null bytes
+	quarter := bytes.Repeat([]byte("abc\x01"), 128)
+	// Printable text followed by as many control characters
+	textFirst := append(bytes.Repeat([]byte("a"), 256), bytes.Repeat([]byte{0x01}, 256)...)
+
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	quarterPath := write("quarter.txt", quarter)
+	textFirstPath := write("text-first.txt", textFirst)
+
+	tests := []struct {
+		name     string
+		path     string
+		detector combine.BinaryDetector
+		want     bool
+	}{
+		{name: "default threshold", path: quarterPath, detector: combine.BinaryDetector{}, want: false},
+		{name: "threshold above ratio", path: quarterPath, detector: combine.BinaryDetector{Threshold: 0.3}, want: false},
+		{name: "threshold at ratio", path: quarterPath, detector: combine.BinaryDetector{Threshold: 0.25}, want: false},
+		{name: "threshold below ratio", path: quarterPath, detector: combine.BinaryDetector{Threshold: 0.2}, want: true},
+		{name: "sample of text only", path: textFirstPath, detector: combine.BinaryDetector{SampleBytes: 256}, want: false},
+		{name: "default sample", path: textFirstPath, detector: combine.BinaryDetector{}, want: true},
+		{name: "larger sample", path: textFirstPath, detector: combine.BinaryDetector{SampleBytes: 4096, Threshold: 0.6}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.detector.IsBinaryFile(tt.path)
+			if err != nil {
+				t.Fatalf("IsBinaryFile() = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsBinaryFile(%s) with %+v = %t, want %t", filepath.Base(tt.path), tt.detector, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBinaryDetectorExtensions checks that extra and excluded extensions adjust the built-in set,
+// in any case and with or without a leading dot.
+func TestBinaryDetectorExtensions(t *testing.T) {
+	detector := combine.BinaryDetector{
+		ExtraExtensions: []string{"DAT", ".bundle"},
+		NotExtensions:   []string{"svg", ".PNG"},
+		TextExtensions:  []string{".lock"},
+	}
+	tests := []struct {
+		path       string
+		wantBinary bool
+		wantText   bool
+	}{
+		{path: "image.jpg", wantBinary: true},
+		{path: "image.png"},
+		{path: "IMAGE.PNG"},
+		{path: "data.dat", wantBinary: true},
+		{path: "app.Bundle", wantBinary: true},
+		{path: "main.go"},
+		{path: "Makefile"},
+		{path: "yarn.lock", wantText: true},
+	}
+	for _, tt := range tests {
+		if got := detector.HasBinaryExtension(tt.path); got != tt.wantBinary {
+			t.Errorf("HasBinaryExtension(%q) = %t, want %t", tt.path, got, tt.wantBinary)
+		}
+		if got := detector.HasTextExtension(tt.path); got != tt.wantText {
+			t.Errorf("HasTextExtension(%q) = %t, want %t", tt.path, got, tt.wantText)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// File: pkg/combine/cache.go
+package combine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultCacheFile is the sidecar file used by --incremental when no cache file is given.
+const DefaultCacheFile = ".combinecache"
+
+// contentCache maps absolute file paths to their processed content from a previous run,
+// together with the modification time and size the file had when it was processed.
+type contentCache struct {
+	mu       sync.Mutex
+	previous map[string]cacheEntry
+	current  map[string]cacheEntry
+	settings string
+}
+
+// cacheEntry is the cached result of processing one file.
+type cacheEntry struct {
+	ModTime time.Time
+	Size    int64
+	Content FileContent
+}
+
+// cacheFile is the on-disk representation of a contentCache. Settings fingerprints the
+// options that shape processed content; a cache written under other settings is discarded.
+type cacheFile struct {
+	Settings string
+	Entries  map[string]cacheEntry
+}
+
+// cacheSettings returns a fingerprint of everything besides a file's own content that
+// affects its processed form.
+func cacheSettings(sourceRoot string, opts ProcessOptions) string {
+	grep := ""
+	if opts.Grep != nil {
+		grep = opts.Grep.String()
+	}
+	data, _ := json.Marshal(struct {
+		SourceRoot             string
+		TrimTrailingWhitespace bool
+		LineNumbers            bool
+		IncludeGitLog          int
+		ComputeHash            bool
+		CountTokens            bool
+		PathNormalization      string
+		CommentPrefixes        map[string]string
+		SectionPaddingBefore   int
+		SectionPaddingAfter    int
+		Sanitizer              *PathSanitizer
+		Grep                   string
+		RequireUTF8            bool
+		StripDocComments       bool
+		DetectSecrets          bool
+		ExcludeSecrets         bool
+		Replacements           []ContentReplacement
+	}{sourceRoot, opts.TrimTrailingWhitespace, opts.LineNumbers, opts.IncludeGitLog, opts.ComputeHash, opts.CountTokens,
+		opts.PathNormalization, opts.CommentPrefixes, opts.SectionPaddingBefore, opts.SectionPaddingAfter, opts.Sanitizer, grep, opts.RequireUTF8,
+		opts.StripDocComments, opts.DetectSecrets, opts.ExcludeSecrets, opts.Replacements})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadContentCache reads the cache at path. A missing, unreadable, or outdated cache yields
+// an empty cache, so that every file is processed afresh.
+func loadContentCache(path, settings string, logger *zap.Logger) *contentCache {
+	cache := &contentCache{
+		previous: make(map[string]cacheEntry),
+		current:  make(map[string]cacheEntry),
+		settings: settings,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			logger.Warn("Failed to read cache file, processing all files", zap.String("cacheFile", path), zap.Error(err))
+		}
+		return cache
+	}
+
+	var stored cacheFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		logger.Warn("Failed to parse cache file, processing all files", zap.String("cacheFile", path), zap.Error(err))
+		return cache
+	}
+	if stored.Settings != settings {
+		logger.Debug("Cache file was written with different settings, processing all files", zap.String("cacheFile", path))
+		return cache
+	}
+	if stored.Entries != nil {
+		cache.previous = stored.Entries
+	}
+	logger.Debug("Loaded cache file", zap.String("cacheFile", path), zap.Int("entries", len(cache.previous)))
+	return cache
+}
+
+// lookup returns the cached content of the file at path if its modification time and size
+// match info.
+func (c *contentCache) lookup(path string, info fs.FileInfo) (FileContent, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.previous[path]
+	if !ok || !entry.ModTime.Equal(info.ModTime()) || entry.Size != info.Size() {
+		return FileContent{}, false
+	}
+	c.current[path] = entry
+	return entry.Content, true
+}
+
+// store records the processed content of the file at path for the next run.
+func (c *contentCache) store(path string, info fs.FileInfo, content FileContent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current[path] = cacheEntry{ModTime: info.ModTime(), Size: info.Size(), Content: content}
+}
+
+// save atomically replaces the cache file at path with the entries recorded during this run.
+func (c *contentCache) save(path string, logger *zap.Logger) error {
+	c.mu.Lock()
+	data, err := json.Marshal(cacheFile{Settings: c.settings, Entries: c.current})
+	entries := len(c.current)
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary cache file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name()) // No-op once the file has been renamed
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write temporary cache file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary cache file: %w", err)
+	}
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace cache file: %w", err)
+	}
+
+	logger.Debug("Saved cache file", zap.String("cacheFile", path), zap.Int("entries", entries))
+	return nil
+}
@@ -0,0 +1,188 @@
+// File: pkg/combine/changelog.go
+package combine
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// changelogPrefixes are the upper-cased base name prefixes of the files treated as changelogs
+// by --combine-changelog, e.g. CHANGELOG.md, Changes.txt, HISTORY.rst, or NEWS.
+var changelogPrefixes = []string{"CHANGELOG", "CHANGES", "HISTORY", "NEWS"}
+
+// changelogSectionPattern matches the Markdown heading starting a version section, e.g.
+// "## [1.2.0] - 2024-05-01", "## v1.2.0", or "# 1.2".
+var changelogSectionPattern = regexp.MustCompile(`^#{1,3}\s+\S`)
+
+// changelogVersionPattern matches the version number in a section heading.
+var changelogVersionPattern = regexp.MustCompile(`\d+(?:\.\d+)*`)
+
+// isChangelogFile reports whether the base name of path starts with one of changelogPrefixes,
+// ignoring case.
+func isChangelogFile(path string) bool {
+	base := strings.ToUpper(filepath.Base(path))
+	for _, prefix := range changelogPrefixes {
+		if strings.HasPrefix(base, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// changelogSection is a version section of a changelog: its heading line and the lines after it,
+// up to the next heading.
+type changelogSection struct {
+	heading string
+	body    string
+}
+
+// splitChangelog splits content into the text before the first version section and the sections.
+// The first heading naming a version or "Unreleased" sets the section level; only headings of
+// that level count as section boundaries, so that "### Added" inside "## 1.2.0" stays part of it.
+func splitChangelog(content string) (string, []changelogSection) {
+	lines := strings.SplitAfter(content, "\n")
+	level := 0
+	var preamble strings.Builder
+	var sections []changelogSection
+	for _, line := range lines {
+		trimmed := strings.TrimRight(line, "\r\n")
+		if changelogSectionPattern.MatchString(trimmed) {
+			headingLevel := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			if level == 0 && isVersionHeading(trimmed) || headingLevel == level {
+				level = headingLevel
+				sections = append(sections, changelogSection{heading: line})
+				continue
+			}
+		}
+		if len(sections) == 0 {
+			preamble.WriteString(line)
+		} else {
+			sections[len(sections)-1].body += line
+		}
+	}
+	return preamble.String(), sections
+}
+
+// isVersionHeading reports whether a heading line names a version or the unreleased changes.
+func isVersionHeading(heading string) bool {
+	return changelogVersionPattern.MatchString(heading) || strings.Contains(strings.ToLower(heading), "unreleased")
+}
+
+// key returns the normalized heading identifying the section's version across changelogs,
+// e.g. "1.2.0" for both "## [1.2.0] - 2024-05-01" and "## v1.2.0".
+func (s changelogSection) key() string {
+	heading := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(s.heading), "#"))
+	if version := changelogVersionPattern.FindString(heading); version != "" {
+		return version
+	}
+	return strings.ToLower(heading)
+}
+
+// compareVersions compares two dotted version numbers numerically, component by component.
+func compareVersions(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aNum, bNum int
+		if i < len(aParts) {
+			aNum, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bNum, _ = strconv.Atoi(bParts[i])
+		}
+		if aNum != bNum {
+			return aNum - bNum
+		}
+	}
+	return 0
+}
+
+// mergeChangelogs merges the version sections of several changelogs, keeping the first of several
+// sections for the same version, and orders them newest first. Sections without a version number,
+// such as "## Unreleased", come before all others. The preamble of the first changelog is kept.
+func mergeChangelogs(contents []string) string {
+	var preamble string
+	var merged []changelogSection
+	seen := make(map[string]struct{})
+	for i, content := range contents {
+		intro, sections := splitChangelog(content)
+		if i == 0 {
+			preamble = intro
+		}
+		for _, section := range sections {
+			key := section.key()
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			merged = append(merged, section)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		iVersion := changelogVersionPattern.MatchString(merged[i].key())
+		jVersion := changelogVersionPattern.MatchString(merged[j].key())
+		if iVersion != jVersion {
+			return !iVersion
+		}
+		return iVersion && compareVersions(merged[i].key(), merged[j].key()) > 0
+	})
+
+	var b strings.Builder
+	b.WriteString(preamble)
+	for _, section := range merged {
+		b.WriteString(section.heading)
+		b.WriteString(section.body)
+		// The last section of a changelog may lack a final newline
+		if !strings.HasSuffix(section.heading+section.body, "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// combineChangelogs moves the changelog files among contents to the front, merged into a single
+// section whose header lists the files it was built from. It returns the new contents and the
+// number of changelog files merged.
+func combineChangelogs(contents []FileContent, opts ProcessOptions, logger *zap.Logger) ([]FileContent, int) {
+	var changelogs, others []FileContent
+	for _, content := range contents {
+		if isChangelogFile(content.Path) {
+			changelogs = append(changelogs, content)
+		} else {
+			others = append(others, content)
+		}
+	}
+	if len(changelogs) == 0 {
+		return contents, 0
+	}
+
+	paths := make([]string, len(changelogs))
+	texts := make([]string, len(changelogs))
+	var size int64
+	for i, changelog := range changelogs {
+		paths[i] = changelog.Path
+		texts[i] = changelog.Content
+		size += changelog.Size
+	}
+
+	merged := changelogs[0]
+	if len(changelogs) > 1 {
+		merged.Content = mergeChangelogs(texts)
+		merged.AbsPath = ""
+		merged.Size = size
+		merged.SHA256 = ""
+		if opts.CountTokens {
+			merged.Tokens = CountTokens(merged.Content)
+		}
+	}
+	label := "Changelog (" + strings.Join(paths, ", ") + ")"
+	merged.Header = opts.sectionHeader(label, opts.commentPrefix(merged.Path))
+
+	logger.Debug("Combined changelog files", zap.Strings("changelogs", paths))
+	return append([]FileContent{merged}, others...), len(changelogs)
+}
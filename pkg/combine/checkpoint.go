@@ -0,0 +1,140 @@
+// File: pkg/combine/checkpoint.go
+package combine
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// checkpoint records each successfully processed file in a JSON lines file as soon as it is
+// processed, so that a run that is interrupted can skip those files when it is started again.
+// The first line holds the settings fingerprint; every further line is one checkpointRecord.
+type checkpoint struct {
+	mu       sync.Mutex
+	file     *os.File
+	encoder  *json.Encoder
+	previous map[string]cacheEntry
+}
+
+// checkpointHeader is the first line of a checkpoint file.
+type checkpointHeader struct {
+	Settings string
+}
+
+// checkpointRecord is a file recorded in a checkpoint, keyed by its absolute path.
+type checkpointRecord struct {
+	Path string
+	cacheEntry
+}
+
+// openCheckpoint loads the files recorded at path by an earlier run with the same settings and
+// starts a new checkpoint there that carries them over. A missing or outdated checkpoint, or a
+// record cut short by the interruption, is ignored.
+func openCheckpoint(path, settings string, logger *zap.Logger) (*checkpoint, error) {
+	c := &checkpoint{previous: loadCheckpoint(path, settings, logger)}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint file: %w", err)
+	}
+	c.file = file
+	c.encoder = json.NewEncoder(file)
+	if err := c.encoder.Encode(checkpointHeader{Settings: settings}); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write checkpoint file: %w", err)
+	}
+	for recordPath, entry := range c.previous {
+		if err := c.encoder.Encode(checkpointRecord{Path: recordPath, cacheEntry: entry}); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to write checkpoint file: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// loadCheckpoint returns the records of the checkpoint at path if it was written with settings.
+func loadCheckpoint(path, settings string, logger *zap.Logger) map[string]cacheEntry {
+	entries := make(map[string]cacheEntry)
+	file, err := os.Open(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			logger.Warn("Failed to read checkpoint file, processing all files", zap.String("checkpoint", path), zap.Error(err))
+		}
+		return entries
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<30) // Records hold whole file contents
+	var header checkpointHeader
+	if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &header) != nil || header.Settings != settings {
+		logger.Debug("Checkpoint file was written with different settings, processing all files", zap.String("checkpoint", path))
+		return entries
+	}
+	for scanner.Scan() {
+		var record checkpointRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			logger.Debug("Ignoring incomplete checkpoint record", zap.String("checkpoint", path), zap.Error(err))
+			break
+		}
+		entries[record.Path] = record.cacheEntry
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Warn("Failed to read checkpoint file, keeping the records read so far", zap.String("checkpoint", path), zap.Error(err))
+	}
+	logger.Info("Resuming from checkpoint", zap.String("checkpoint", path), zap.Int("files", len(entries)))
+	return entries
+}
+
+// lookup returns the recorded content of the file at path if its modification time and size
+// match info. It is safe to call on a nil checkpoint.
+func (c *checkpoint) lookup(path string, info fs.FileInfo) (FileContent, bool) {
+	if c == nil {
+		return FileContent{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.previous[path]
+	if !ok || !entry.ModTime.Equal(info.ModTime()) || entry.Size != info.Size() {
+		return FileContent{}, false
+	}
+	return entry.Content, true
+}
+
+// record appends the processed content of the file at path to the checkpoint. It is safe to
+// call on a nil checkpoint.
+func (c *checkpoint) record(path string, info fs.FileInfo, content FileContent) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := cacheEntry{ModTime: info.ModTime(), Size: info.Size(), Content: content}
+	if err := c.encoder.Encode(checkpointRecord{Path: path, cacheEntry: entry}); err != nil {
+		return fmt.Errorf("failed to write checkpoint record: %w", err)
+	}
+	return nil
+}
+
+// close closes the checkpoint file and, once the run has completed, removes it so that the
+// next run starts afresh.
+func (c *checkpoint) close(completed bool, logger *zap.Logger) {
+	path := c.file.Name()
+	if err := c.file.Close(); err != nil {
+		logger.Warn("Failed to close checkpoint file", zap.String("checkpoint", path), zap.Error(err))
+	}
+	if !completed {
+		logger.Info("Kept checkpoint for resuming", zap.String("checkpoint", path))
+		return
+	}
+	if err := os.Remove(path); err != nil {
+		logger.Warn("Failed to remove checkpoint file", zap.String("checkpoint", path), zap.Error(err))
+	}
+}
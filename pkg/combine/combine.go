@@ -2,10 +2,39 @@
 package combine
 
 import (
+	"context"
+	"time"
+
 	"go.uber.org/zap"
 )
 
+// Exit codes reported in CombineResult.ExitCode.
+const (
+	ExitSuccess          = 0 // All collected files were combined.
+	ExitFatal            = 1 // The run failed, e.g. the output could not be written.
+	ExitPartialSuccess   = 2 // Output was written, but some files could not be read or encoded.
+	ExitNothingToCombine = 3 // No files remained after ignore patterns and filters.
+	ExitUserAbort        = 4 // The user declined to continue at the binary file prompt.
+)
+
+// CombineResult summarizes a completed combine run.
+type CombineResult struct {
+	FilesIncluded int           // Number of files written to the output.
+	FilesSkipped  int           // Number of collected files left out by filters, binary detection, or read errors.
+	BinaryFiles   []string      // Paths of the binary files that were detected.
+	TotalBytes    int64         // Total size of the included file contents in bytes.
+	Duration      time.Duration // Wall-clock duration of the run.
+	OutputPath    string        // Path of the written output (combined file or archive); empty if nothing was written.
+	TreePath      string        // Path of the written tree structure file; empty if it was not written.
+	ExitCode      int           // Process exit code describing the outcome (ExitSuccess, ExitPartialSuccess, ...).
+}
+
 // ExecuteWithArgs initiates the combine process with the provided arguments and logger.
-func ExecuteWithArgs(args Arguments, logger *zap.Logger) error {
-	return executeProcess(args, logger)
+func ExecuteWithArgs(args Arguments, logger *zap.Logger) (CombineResult, error) {
+	return ExecuteWithContext(context.Background(), args, logger)
+}
+
+// ExecuteWithContext initiates the combine process and stops waiting on retries when ctx is cancelled.
+func ExecuteWithContext(ctx context.Context, args Arguments, logger *zap.Logger) (CombineResult, error) {
+	return executeProcess(ctx, args, logger)
 }
@@ -0,0 +1,382 @@
+// File: pkg/combine/combine_test.go
+package combine_test
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"agentexec/pkg/combine"
+
+	"go.uber.org/zap"
+)
+
+// writeTree creates the files and directories of fsys in a new temporary directory and returns
+// its path. Entries with fs.ModeSymlink become symlinks to the target in their Data. The combine
+// process reads the host filesystem, so its test trees are declared as a MapFS and materialized.
+func writeTree(t *testing.T, fsys fstest.MapFS) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, file := range fsys {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case file.Mode.IsDir():
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+		case file.Mode&fs.ModeSymlink != 0:
+			if err := os.Symlink(filepath.FromSlash(string(file.Data)), path); err != nil {
+				t.Skipf("cannot create symlinks: %v", err)
+			}
+		default:
+			if err := os.WriteFile(path, file.Data, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return root
+}
+
+// symlink returns a MapFS entry that writeTree creates as a symlink to target.
+func symlink(target string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(target), Mode: fs.ModeSymlink}
+}
+
+// testArguments returns arguments combining the src directory of fsys, written to a temporary
+// directory, into files in another temporary directory, without prompting. The test runs in a
+// temporary working directory, so that the ignore files of the repository are not loaded.
+func testArguments(t *testing.T, fsys fstest.MapFS) combine.Arguments {
+	t.Helper()
+	chdir(t, t.TempDir())
+	root := writeTree(t, fsys)
+	out := t.TempDir()
+	return combine.Arguments{
+		Paths:          []string{filepath.Join(root, "src")},
+		Output:         filepath.Join(out, "combined.txt"),
+		Tree:           filepath.Join(out, "tree.txt"),
+		MaxFileSizeKB:  combine.DefaultMaxFileSizeKB,
+		NonInteractive: true,
+		CacheFile:      filepath.Join(out, combine.DefaultCacheFile),
+		StatsOutput:    filepath.Join(out, "stats.json"),
+	}
+}
+
+// chdir changes the working directory to dir until the test ends.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// runCombine runs the combine process with args and fails the test if it returns an error.
+func runCombine(t *testing.T, args combine.Arguments) combine.CombineResult {
+	t.Helper()
+	result, err := combine.ExecuteWithContext(context.Background(), args, zap.NewNop())
+	if err != nil {
+		t.Fatalf("ExecuteWithContext() = %v", err)
+	}
+	return result
+}
+
+// captureStdout returns what run writes to os.Stdout, which it replaces with a temporary file.
+func captureStdout(t *testing.T, run func()) string {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	stdout := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = stdout }()
+	run()
+	return readFile(t, f.Name())
+}
+
+// readFile returns the content of the file at path.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// TestStdoutOutput checks that output to "-" goes to standard output, with the file sections in
+// order, and that no output file is created.
+func TestStdoutOutput(t *testing.T) {
+	args := testArguments(t, fstest.MapFS{
+		"src/b.go":       text("package b\n"),
+		"src/a.go":       text("package a\n"),
+		"src/lib/c.go":   text("package lib\n"),
+		"src/lib/d/e.go": text("package d\n"),
+	})
+	outDir := filepath.Dir(args.Output)
+	args.Output = combine.StdoutPath
+
+	var result combine.CombineResult
+	stdout := captureStdout(t, func() { result = runCombine(t, args) })
+
+	last := -1
+	for _, path := range []string{"src/a.go", "src/b.go", "src/lib/c.go", "src/lib/d/e.go"} {
+		header := "# Source: " + path + " #\n"
+		i := strings.Index(stdout, header)
+		if i < 0 {
+			t.Fatalf("standard output = %q, want a header %q", stdout, header)
+		}
+		if i < last {
+			t.Errorf("header %q appears before the previous file's header", header)
+		}
+		last = i
+	}
+	if result.FilesIncluded != 4 {
+		t.Errorf("FilesIncluded = %d, want 4", result.FilesIncluded)
+	}
+
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "tree.txt" {
+			t.Errorf("output directory contains %s, want only the tree file", entry.Name())
+		}
+	}
+}
+
+// TestDryRun checks that a dry run writes nothing and lists the files a real run combines.
+func TestDryRun(t *testing.T) {
+	args := testArguments(t, fstest.MapFS{
+		"src/main.go":      text("package main\n"),
+		"src/lib/util.go":  text("package lib\n"),
+		"src/README.md":    text("# readme\n"),
+		"src/ignored.go":   text("package ignored\n"),
+		"src/data/raw.bin": &fstest.MapFile{Data: []byte{0, 1, 2, 3}},
+	})
+	outDir := filepath.Join(filepath.Dir(args.Output), "nested")
+	args.Output = filepath.Join(outDir, "combined.txt")
+	args.Tree = filepath.Join(outDir, "tree.txt")
+	args.IgnorePatterns = []string{"ignored.go"}
+
+	dryRun := args
+	dryRun.DryRun = true
+	stdout := captureStdout(t, func() { runCombine(t, dryRun) })
+	if _, err := os.Stat(outDir); !os.IsNotExist(err) {
+		t.Fatalf("Stat(%s) = %v after a dry run, want it not to exist", outDir, err)
+	}
+
+	runCombine(t, args)
+	output := readFile(t, args.Output)
+	files, err := combine.ParseCombinedFile(strings.NewReader(output))
+	if err != nil {
+		t.Fatalf("ParseCombinedFile() = %v", err)
+	}
+	paths := slices.Sorted(maps.Keys(files))
+
+	want := fmt.Sprintf("Files to combine (%d):\n%s\nBinary files skipped (1):\n", len(paths), strings.Join(paths, "\n"))
+	if !strings.HasPrefix(stdout, want) {
+		t.Errorf("dry run output = %q, want it to start with %q", stdout, want)
+	}
+	if wantSize := fmt.Sprintf("Estimated output size: %d bytes\n", len(output)); !strings.HasSuffix(stdout, wantSize) {
+		t.Errorf("dry run output = %q, want it to end with %q", stdout, wantSize)
+	}
+}
+
+// TestGrep checks that only files whose content matches the grep pattern are combined.
+func TestGrep(t *testing.T) {
+	args := testArguments(t, fstest.MapFS{
+		"src/reader.go":   text("package io\n\ntype Reader interface {\n\tRead(p []byte) (int, error)\n}\n"),
+		"src/impl.go":     text("package io\n\nvar _ Reader = (*file)(nil)\n"),
+		"src/writer.go":   text("package io\n\ntype Writer interface{}\n"),
+		"src/notes.md":    text("Readers are documented elsewhere.\n"),
+		"src/empty.go":    text(""),
+		"src/sub/deep.go": text("package sub\n\n// Uses io.Reader\n"),
+	})
+	args.GrepPattern = `\bReader\b`
+
+	result := runCombine(t, args)
+	files, err := combine.ParseCombinedFile(strings.NewReader(readFile(t, args.Output)))
+	if err != nil {
+		t.Fatalf("ParseCombinedFile() = %v", err)
+	}
+	want := []string{"src/impl.go", "src/reader.go", "src/sub/deep.go"}
+	if got := slices.Sorted(maps.Keys(files)); !slices.Equal(got, want) {
+		t.Errorf("combined files = %q, want %q", got, want)
+	}
+	if result.FilesIncluded != len(want) {
+		t.Errorf("FilesIncluded = %d, want %d", result.FilesIncluded, len(want))
+	}
+}
+
+// TestIncremental checks that files whose modification time and size match the cache are served
+// from it without being read. Rewriting a file with content of the same size and restoring its
+// modification time leaves the old content in the output.
+func TestIncremental(t *testing.T) {
+	args := testArguments(t, fstest.MapFS{
+		"src/a.go": text("package a // v1\n"),
+		"src/b.go": text("package b // v1\n"),
+	})
+	args.Incremental = true
+	src := args.Paths[0]
+
+	// Fake modification times, so that rewrites within the clock's resolution are told apart
+	setModTime := func(name string, modTime time.Time) {
+		t.Helper()
+		if err := os.Chtimes(filepath.Join(src, name), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rewrite := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	combined := func() map[string]string {
+		t.Helper()
+		runCombine(t, args)
+		files, err := combine.ParseCombinedFile(strings.NewReader(readFile(t, args.Output)))
+		if err != nil {
+			t.Fatalf("ParseCombinedFile() = %v", err)
+		}
+		return files
+	}
+
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	setModTime("a.go", first)
+	setModTime("b.go", first)
+	combined()
+	if _, err := os.Stat(args.CacheFile); err != nil {
+		t.Fatalf("cache file was not written: %v", err)
+	}
+
+	// a.go keeps its modification time and size, so its cached content is used; b.go is re-read
+	rewrite("a.go", "package a // v2\n")
+	rewrite("b.go", "package b // v2\n")
+	setModTime("a.go", first)
+	setModTime("b.go", first.Add(time.Hour))
+	files := combined()
+	if got, want := files["src/a.go"], "package a // v1\n"; got != want {
+		t.Errorf("unchanged a.go = %q, want the cached %q", got, want)
+	}
+	if got, want := files["src/b.go"], "package b // v2\n"; got != want {
+		t.Errorf("modified b.go = %q, want %q", got, want)
+	}
+
+	// A new modification time invalidates the cached content
+	setModTime("a.go", first.Add(time.Hour))
+	if got, want := combined()["src/a.go"], "package a // v2\n"; got != want {
+		t.Errorf("modified a.go = %q, want %q", got, want)
+	}
+}
+
+// TestFollowSymlinksCycle checks that a run terminates on directories linking to each other,
+// combining each file once, and that links are annotated in the tree whether followed or not.
+func TestFollowSymlinksCycle(t *testing.T) {
+	for _, follow := range []bool{false, true} {
+		t.Run(fmt.Sprintf("follow=%t", follow), func(t *testing.T) {
+			args := testArguments(t, fstest.MapFS{
+				"src/a/a.go": text("package a\n"),
+				"src/a/toB":  symlink("../b"),
+				"src/b/b.go": text("package b\n"),
+				"src/b/toA":  symlink("../a"),
+			})
+			args.FollowSymlinks = follow
+
+			done := make(chan error, 1)
+			go func() {
+				_, err := combine.ExecuteWithContext(context.Background(), args, zap.NewNop())
+				done <- err
+			}()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatalf("ExecuteWithContext() = %v", err)
+				}
+			case <-time.After(30 * time.Second):
+				t.Fatal("combine did not terminate on a symlink cycle")
+			}
+
+			files, err := combine.ParseCombinedFile(strings.NewReader(readFile(t, args.Output)))
+			if err != nil {
+				t.Fatalf("ParseCombinedFile() = %v", err)
+			}
+			contents := slices.Sorted(maps.Values(files))
+			if want := []string{"package a\n", "package b\n"}; !slices.Equal(contents, want) {
+				t.Errorf("combined contents = %q, want each file once: %q", contents, want)
+			}
+			tree := readFile(t, args.Tree)
+			for _, link := range []string{"toB/ -> " + filepath.FromSlash("../b"), "toA/ -> " + filepath.FromSlash("../a")} {
+				if !strings.Contains(tree, link) {
+					t.Errorf("tree = %q, want it to contain %q", tree, link)
+				}
+			}
+		})
+	}
+}
+
+// TestMaxDepth checks that files and directories beyond the depth limit are absent from both the
+// tree and the combined content.
+func TestMaxDepth(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/top.go":                text("package top\n"),
+		"src/one/one.go":            text("package one\n"),
+		"src/one/two/two.go":        text("package two\n"),
+		"src/one/two/three/deep.go": text("package three\n"),
+	}
+	tests := []struct {
+		maxDepth int
+		want     []string
+	}{
+		{maxDepth: 0, want: []string{"src/one/one.go", "src/one/two/three/deep.go", "src/one/two/two.go", "src/top.go"}},
+		{maxDepth: 1, want: []string{"src/top.go"}},
+		{maxDepth: 2, want: []string{"src/one/one.go", "src/top.go"}},
+		{maxDepth: 3, want: []string{"src/one/one.go", "src/one/two/two.go", "src/top.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("max depth %d", tt.maxDepth), func(t *testing.T) {
+			args := testArguments(t, fsys)
+			args.MaxDepth = tt.maxDepth
+			runCombine(t, args)
+
+			files, err := combine.ParseCombinedFile(strings.NewReader(readFile(t, args.Output)))
+			if err != nil {
+				t.Fatalf("ParseCombinedFile() = %v", err)
+			}
+			if got := slices.Sorted(maps.Keys(files)); !slices.Equal(got, tt.want) {
+				t.Errorf("combined files = %q, want %q", got, tt.want)
+			}
+
+			tree := readFile(t, args.Tree)
+			for path := range fsys {
+				name := filepath.Base(path)
+				if listed, want := strings.Contains(tree, name), slices.Contains(tt.want, path); listed != want {
+					t.Errorf("tree lists %s: %t, want %t; tree:\n%s", name, listed, want, tree)
+				}
+			}
+		})
+	}
+}
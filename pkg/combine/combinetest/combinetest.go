@@ -0,0 +1,44 @@
+// File: pkg/combine/combinetest/combinetest.go
+
+// Package combinetest provides helpers for tests of ignore pattern matching, kept out of package
+// combine so that programs using it do not link the testing package.
+package combinetest
+
+import (
+	"testing"
+
+	"agentexec/pkg/combine"
+
+	"go.uber.org/zap"
+)
+
+// NewTestCombineIgnore returns a CombineIgnore with patterns compiled in gitignore syntax, as if
+// they had been passed with --ignore, and logging disabled.
+func NewTestCombineIgnore(patterns []string) *combine.CombineIgnore {
+	gi := combine.NewCombineIgnore(zap.NewNop())
+	// Blank lines are skipped since empty patterns are not rejected
+	_ = gi.CompileIgnoreLines(patterns...)
+	return gi
+}
+
+// AssertIgnored reports a test error for each of paths, relative and slash-separated, that gi
+// does not ignore.
+func AssertIgnored(t testing.TB, gi *combine.CombineIgnore, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		if !gi.MatchesPath(path) {
+			t.Errorf("expected %q to be ignored", path)
+		}
+	}
+}
+
+// AssertNotIgnored reports a test error for each of paths, relative and slash-separated, that gi
+// ignores, naming the pattern that matched it.
+func AssertNotIgnored(t testing.TB, gi *combine.CombineIgnore, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		if matched, pattern := gi.MatchesPathWithPattern(path); matched {
+			t.Errorf("expected %q not to be ignored, but it matches %q", path, pattern.Line)
+		}
+	}
+}
@@ -1,26 +1,578 @@
 // File: pkg/combine/config.go
 package combine
 
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Arguments holds the configuration options for the file combining process.
 type Arguments struct {
-	Paths            []string // List of file or directory paths to be processed.
-	Output           string   // Destination path for the combined output file.
-	Tree             string   // Destination path for the tree structure output file.
-	GlobalIgnoreFile string   // Optional path to a global .combineignore file for ignore patterns.
-	MaxFileSizeKB    int      // Maximum size (in KB) of files to process; larger files are skipped.
-	MaxWorkers       int      // Number of concurrent workers for processing files.
-	IgnorePatterns   []string // Additional ignore patterns provided via command-line arguments.
-	Verbose          bool     // If true, enables detailed logging, including skipped file information.
+	Paths             []string // List of file or directory paths to be processed.
+	PathsFile         string   // Optional file, or "-" for stdin, whose lines were added to Paths.
+	StdinExtension    string   // If set, content piped to stdin is added as the virtual file "stdin" plus this extension.
+	Prioritize        string   // If PrioritizeStdin, the virtual stdin file comes first instead of last in the output.
+	Output            string   // Destination path for the combined output file.
+	Tree              string   // Destination path for the tree structure output file.
+	GlobalIgnoreFiles []string // Optional global .combineignore files, loaded in order; $COMBINEIGNORE_GLOBAL is used if empty.
+	MaxFileSizeKB     int      // Maximum size (in KB) of files to process; larger files are skipped.
+	MaxWorkers        int      // Number of concurrent workers for processing files.
+	MaxMemoryMB       int      // If positive, files are handed to the workers only while the heap stays below this many MB.
+	MaxWorkersPerDir  int      // If positive, the most files of one directory processed at once.
+	IgnorePatterns    []string // Additional ignore patterns provided via command-line arguments.
+	IncludePatterns   []string // If non-empty, only files matching at least one of these gitignore-style patterns are collected.
+	IgnoreSyntax      string   // Syntax of IgnorePatterns ("gitignore", "glob", or "regex"); defaults to gitignore.
+	IgnoreVCS         bool     // If true, metadata directories of common version control systems are ignored.
+	RespectGitignore  bool     // If true, `.gitignore` files are applied to their directories during traversal.
+	FollowSymlinks    bool     // If true, symlinks to directories are traversed; otherwise they are only listed in the tree.
+	DockerOutput      bool     // If true, a warning is logged when running in a container and an output path is not on a mounted volume.
+	DockerVolumeCheck bool     // If true, the run fails when running in a container and an output path is not on a mounted volume.
+	Verbose           bool     // If true, enables detailed logging, including skipped file information.
+	WatchOnStart      *bool    // If nil or true, watch mode runs the combine process once before waiting for the first change.
+	WatchAfter        []string // Shell commands run in order after each successful run in watch mode, given $AGENTEXEC_OUTPUT.
+	PreCombineHook    string   // Optional shell command run before combining; the run fails if it exits nonzero.
+	PostCombineHook   string   // Optional shell command run after the output is written, given $AGENTEXEC_OUTPUT.
+	OTLPEndpoint      string   // Optional OTLP/gRPC endpoint URL, e.g. "http://localhost:4317", to which the spans of each run are exported.
+
+	OutputFormat          string // Format of the combined output file ("text", "json", "yaml", or "zip").
+	OutputJSONStream      bool   // If true, each file is written to Output as one JSON line as soon as it is processed.
+	WrapInXMLTags         string // Optional tag enclosing the text output, with the tree and each file in their own tags; see WriteXMLTaggedFile.
+	EncodingDeclaration   string // Style of the encoding declaration starting text output ("none", "python", or "xml"); defaults to none.
+	TreeFormat            string // Format of the tree structure output file ("text", "json", or "xml").
+	TreeRelativeRoot      string // If set, tree root paths are shown relative to this directory ("." for the current one).
+	TreeDirsLast          bool   // If true, the tree lists files before directories.
+	IncludeFileCount      bool   // If true, text output starts with a comment giving the file count, size, and generation time.
+	NoHeader              bool   // If true, the IncludeFileCount comment is suppressed, e.g. when a config file enables it.
+	TreeShowIgnored       bool   // If true, ignored files and directories are listed in the tree with TreeIgnoredMarker.
+	TreeIgnoredMarker     string // Marker for ignored tree entries; "%s" stands for the name, otherwise the marker precedes it.
+	TreeCounts            bool   // If true, each directory in the text tree shows the number of included files beneath it.
+	TreeAnnotations       bool   // If true, each file in the text tree shows its modification time in TreeAnnotationFormat.
+	TreeAnnotationFormat  string // time.Format layout of the modification times; defaults to DefaultTreeAnnotationFormat.
+	CountTokensPerFile    bool   // If true, JSON output includes per-file and total token estimates.
+	TokenCount            bool   // If true, a table of per-file and total token estimates is printed to stderr after writing.
+	MaxTokens             int    // If positive, files are dropped in output order once the running token total would exceed it.
+	OutputTemplateDir     string // Optional directory containing per-format output templates (<format>.tmpl).
+	NonInteractive        bool   // If true, binary files are excluded without prompting the user.
+	LimitToImports        string // Optional Go package whose transitive import graph limits the collected files.
+	WriteIfChanged        bool   // If true, the output file is only replaced when its content changes.
+	HashAlgorithm         string // Algorithm used to compare and verify files ("sha256", "sha512", "md5", or "xxhash").
+	CombineIntoArchive    string // Optional .tar.gz path; if set, files are archived there instead of combined into Output.
+	OutputPerExtension    bool   // If true, Output is a directory receiving one combined text file per file extension.
+	SplitOnPattern        string // Optional comma-separated patterns; each matching file starts a new numbered Output shard.
+	OutputSplitTree       bool   // If true, each shard starts with a tree of its own files; otherwise only the first shard has the full tree.
+	SplitBytes            int    // If positive, Output is split into numbered chunks of at most this many bytes, each with the full tree.
+	Preview               int    // If positive, the first Preview lines of the output are printed to stdout and no files are written.
+	DryRun                bool   // If true, the files that would be combined are listed on stdout and no files are written.
+	Watch                 bool   // If true, the combine process is re-run whenever files under Paths change (see WatchWithContext).
+	OutputMode            string // How an existing output file is handled ("overwrite", "append", or "fail-if-exists").
+	OnError               string // How files failing to process are handled ("skip" or "fail"); defaults to skip.
+	RequireEncoding       string // If EncodingUTF8, files that are not valid UTF-8 fail to process; empty accepts any content.
+	GitHubAction          bool   // If true, a markdown summary is written to $GITHUB_STEP_SUMMARY.
+	ProfilePatterns       bool   // If true, per-pattern evaluation statistics are reported to stderr.
+	ProfileIO             bool   // If true, the latency of stat, directory listing, read, and write operations is reported to stderr.
+	Progress              bool   // If true, each file is reported on stderr as it is processed and written, followed by a summary.
+	ProgressFile          string // Optional file JSON progress updates are written to, one line every ProgressInterval files.
+	ProgressInterval      int    // Number of files processed between two updates of ProgressFile; zero selects DefaultProgressInterval.
+	SanitizePaths         bool   // If true, absolute paths in the output have their home directory or common root replaced by a placeholder.
+	ReportSkippedPatterns bool   // If true, ignore patterns that matched no files are reported to stderr.
+	ExcludeJSArtifacts    bool   // If true, the JavaScript build outputs and caches in JSArtifactPatterns are ignored.
+	ErrorOnEmptyPattern   bool   // If true, a blank entry in IgnorePatterns fails the run instead of being skipped.
+	FollowDirSymlinksOnly bool   // If true, symlinks to directories are traversed as with FollowSymlinks, but symlinks to files found there are skipped.
+	CombineChangelog      bool   // If true, CHANGELOG*, CHANGES*, HISTORY*, and NEWS* files are merged into one section placed first.
+	DiffOutput            string // Optional previous text output; only files added or changed since are written, and deleted ones are noted.
+	FilterByRegex         string // Optional Go regular expression that file content must match to be included.
+	NegateRegex           bool   // If true, FilterByRegex excludes matching files instead of including them.
+	GrepPattern           string // Optional Go regular expression that file content must match, checked by the worker pool.
+	DetectSecrets         bool   // If true, a warning naming the file and the kind of secret is logged for content matching secretPatterns.
+	ExcludeSecrets        bool   // If true, files with detected secrets are left out of the output; implies DetectSecrets.
+	Deduplicate           bool   // If true, files whose content is identical to an alphabetically earlier file are dropped.
+	ContentHashDedup      bool   // If true, such files are kept with a "Duplicate of: path" note in place of their content.
+	HeaderTemplate        string // Optional text/template for the section header before each file; empty uses the built-in header.
+	Incremental           bool   // If true, files unchanged since the previous run are taken from CacheFile instead of being re-read.
+	CacheFile             string // Sidecar file caching processed content between incremental runs; defaults to DefaultCacheFile.
+	Checkpoint            string // Optional file recording processed files so that an interrupted run resumes where it stopped.
+	NotifyDone            bool   // If true, a desktop notification is sent when the run completes.
+	Open                  bool   // If true, the written output file is opened in the default editor or viewer.
+	Lock                  bool   // If true, Output is guarded by an Output.lock file so that concurrent runs fail instead of clobbering it.
+	Stats                 bool   // If true, a JSON summary of the run is written to StatsOutput after the output.
+	StatsOutput           string // File the JSON summary is written to; defaults to DefaultStatsOutput.
+	SummaryTable          bool   // If true, a table of the run's metrics is printed to stderr when it completes.
+	WarnOnLargeCombinedMB int    // If positive, a warning with ways to reduce the size is printed to stderr when the included content exceeds this many MB.
+	Base64EncodeBinary    bool   // If true, binary files are included as base64-encoded content instead of being skipped.
+	MinUniqueLines        int    // Minimum percentage of unique lines a file must have to be included; 0 disables the check.
+	ExcludeInitOnly       bool   // If true, Python __init__.py files consisting only of relative imports are skipped.
+	RequireMinFiles       int    // If positive, the run fails when fewer files remain after filtering.
+	RequireMaxFiles       int    // If positive, the run fails when more files remain after filtering.
+	MaxSymlinkDepth       int    // Maximum number of symlink redirects followed when resolving a path; non-positive means unlimited.
+	MaxDepth              int    // If positive, directories are descended at most this many levels below each input; 1 keeps only a root's immediate children.
+
+	ExcludeOlderThan time.Duration // If positive, files last modified longer ago than this are skipped.
+
+	ReadRetries    int           // Number of times a file read failing with a transient error is retried.
+	ReadRetryDelay time.Duration // Delay between read retries.
+	ReadChunkSize  int           // Buffer size in bytes for streaming files larger than 1 MB; defaults to DefaultChunkSize.
+
+	TrimTrailingWhitespace bool // If true, trailing whitespace is stripped from every line of file content.
+	StripDocComments       bool // If true, doc comments are removed from Go and Python files; see StripDocComments.
+	LineNumbers            bool // If true, every line of file content is prefixed with its 1-based line number.
+	IncludeGitLog          int  // Number of recent git log entries prepended to each file; 0 disables.
+	ParallelHash           bool // If true, file hashes are computed by the worker pool while reading content.
+
+	ContentReplacements []string // "PATTERN:REPLACEMENT" substitutions applied in order to file content; see ParseContentReplacement.
+
+	PathNormalization string            // How source paths are written in the output ("slash", "os", or "none"); defaults to slash.
+	VirtualRoot       string            // Optional name under which source paths are written relative to the common root of all Paths.
+	CommentPrefixes   map[string]string // Comment prefix used in file headers per extension (without the dot); "#" for unlisted extensions.
+	Sort              string            // Order of the files in the output ("path" or "depth"); defaults to path.
+	GroupByPackage    bool              // If true, files are grouped by Go package after sorting: main first, then by name, then non-Go files.
+
+	SectionPaddingBefore *int // Blank lines before each file's separator line; nil selects DefaultSectionPaddingBefore.
+	SectionPaddingAfter  *int // Blank lines between each file's Source line and its content; nil selects DefaultSectionPaddingAfter.
+
+	BinaryThreshold       float64  // Ratio of non-printable bytes above which a file is binary; zero selects DefaultBinaryThreshold.
+	BinarySampleBytes     int      // Number of leading bytes inspected for binary detection; zero selects DefaultBinarySampleBytes.
+	ExtraBinaryExt        []string // Extensions treated as binary in addition to BinaryExtensions.
+	NotBinaryExt          []string // Extensions from BinaryExtensions that are not treated as binary.
+	TreatAsTextExtensions []string // Extensions always treated as text, bypassing both binary checks.
+
+	ioProfile *ioProfile // Records I/O latencies when ProfileIO is set; created by executeProcess.
+}
+
+// includesBinary reports whether detected binary files are included in the output rather than excluded.
+func (a Arguments) includesBinary() bool {
+	return a.Base64EncodeBinary || a.OutputFormat == FormatZip
+}
+
+// followsDirSymlinks reports whether symlinks to directories are traversed.
+func (a Arguments) followsDirSymlinks() bool {
+	return a.FollowSymlinks || a.FollowDirSymlinksOnly
+}
+
+// collectOptions derives the file collection options from the arguments.
+func (a Arguments) collectOptions() CollectOptions {
+	opts := CollectOptions{
+		MaxSymlinkDepth:  a.MaxSymlinkDepth,
+		MaxDepth:         a.MaxDepth,
+		ExcludeOlderThan: a.ExcludeOlderThan,
+		RespectGitignore: a.RespectGitignore,
+		FollowSymlinks:   a.followsDirSymlinks(),
+		SkipFileSymlinks: a.FollowDirSymlinksOnly,
+		Binary: BinaryDetector{
+			Threshold:       a.BinaryThreshold,
+			SampleBytes:     a.BinarySampleBytes,
+			ExtraExtensions: a.ExtraBinaryExt,
+			NotExtensions:   a.NotBinaryExt,
+			TextExtensions:  a.TreatAsTextExtensions,
+		},
+		ioProfile: a.ioProfile,
+	}
+	if len(a.IncludePatterns) > 0 {
+		opts.Include = NewCombineIgnore(nil)
+		opts.Include.CompileIgnoreLines(a.IncludePatterns...)
+	}
+	return opts
+}
+
+// CollectOptions holds the options that control which files are collected during traversal.
+type CollectOptions struct {
+	FS               fs.FS          // If non-nil, files are collected from FS by slash-separated name instead of from the host filesystem.
+	MaxSymlinkDepth  int            // Maximum number of symlink redirects followed when resolving a path; non-positive means unlimited.
+	MaxDepth         int            // If positive, entries more than this many levels below a root directory are not collected.
+	ExcludeOlderThan time.Duration  // If positive, files last modified longer ago than this are skipped.
+	Include          *CombineIgnore // If non-nil, only files matching one of its patterns are collected.
+	RespectGitignore bool           // If true, `.gitignore` files are applied to the directories containing them.
+	FollowSymlinks   bool           // If true, symlinks to directories are traversed, each real directory at most once.
+	SkipFileSymlinks bool           // If true, symlinks to files found during traversal are not collected.
+	Binary           BinaryDetector // Decides which files are binary.
+
+	visited   *visitedFiles // Files collected so far, shared across all input paths; created on demand.
+	ioProfile *ioProfile    // If non-nil, the latency of filesystem operations is recorded.
+}
+
+// isTooOld reports whether a file with the given info falls outside the ExcludeOlderThan window.
+func (o CollectOptions) isTooOld(info fs.FileInfo) bool {
+	return o.ExcludeOlderThan > 0 && time.Since(info.ModTime()) > o.ExcludeOlderThan
+}
+
+// treeOptions derives the tree rendering options from the arguments.
+func (a Arguments) treeOptions() TreeOptions {
+	return TreeOptions{
+		DirsLast:         a.TreeDirsLast,
+		ShowIgnored:      a.TreeShowIgnored,
+		IgnoredMarker:    a.TreeIgnoredMarker,
+		MaxDepth:         a.MaxDepth,
+		RespectGitignore: a.RespectGitignore,
+		FollowSymlinks:   a.followsDirSymlinks(),
+		Sanitizer:        a.pathSanitizer(),
+		RelativeRoot:     a.TreeRelativeRoot,
+		ModTimeFormat:    a.treeAnnotationFormat(),
+		ioProfile:        a.ioProfile,
+	}
+}
+
+// treeAnnotationFormat returns the layout of the modification times in the tree, or "" if
+// TreeAnnotations is not set.
+func (a Arguments) treeAnnotationFormat() string {
+	if !a.TreeAnnotations {
+		return ""
+	}
+	if a.TreeAnnotationFormat == "" {
+		return DefaultTreeAnnotationFormat
+	}
+	return a.TreeAnnotationFormat
+}
+
+// watchesOnStart reports whether watch mode runs the combine process before the first change.
+func (a Arguments) watchesOnStart() bool {
+	return a.WatchOnStart == nil || *a.WatchOnStart
+}
+
+// processOptions derives the per-file processing options from the arguments.
+func (a Arguments) processOptions() ProcessOptions {
+	return ProcessOptions{
+		ReadRetries:    a.ReadRetries,
+		ReadRetryDelay: a.ReadRetryDelay,
+		ChunkSize:      a.ReadChunkSize,
+
+		TrimTrailingWhitespace: a.TrimTrailingWhitespace,
+		StripDocComments:       a.StripDocComments,
+		LineNumbers:            a.LineNumbers,
+		IncludeGitLog:          a.IncludeGitLog,
+		ComputeHash:            a.ParallelHash || a.OutputJSONStream,
+		CountTokens:            a.TokenCount || a.MaxTokens > 0,
+		RequireUTF8:            a.RequireEncoding == EncodingUTF8,
+		DetectSecrets:          a.DetectSecrets || a.ExcludeSecrets,
+		ExcludeSecrets:         a.ExcludeSecrets,
+
+		PathNormalization: a.PathNormalization,
+		CommentPrefixes:   a.CommentPrefixes,
+
+		SectionPaddingBefore: intOrDefault(a.SectionPaddingBefore, DefaultSectionPaddingBefore),
+		SectionPaddingAfter:  intOrDefault(a.SectionPaddingAfter, DefaultSectionPaddingAfter),
+
+		Sanitizer: a.pathSanitizer(),
+
+		ioProfile: a.ioProfile,
+	}
+}
+
+// pathSanitizer returns the sanitizer for paths written to the output, rooted at the common
+// root of all Paths, or nil if SanitizePaths is not set.
+func (a Arguments) pathSanitizer() *PathSanitizer {
+	if !a.SanitizePaths {
+		return nil
+	}
+	return NewPathSanitizer(commonSourceRoot(a.Paths))
+}
+
+// progressReporter returns the Progress notified while processing total files, along with a
+// function closing the progress file, if any.
+func (a Arguments) progressReporter(total int) (Progress, func() error, error) {
+	var reporters multiProgress
+	if a.Progress {
+		reporters = append(reporters, NewTextProgress(os.Stderr, total))
+	}
+	closeFile := func() error { return nil }
+	if a.ProgressFile != "" {
+		file, err := os.Create(a.ProgressFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create progress file: %w", err)
+		}
+		reporters = append(reporters, NewJSONProgress(file, total, a.ProgressInterval))
+		closeFile = file.Close
+	}
+
+	switch len(reporters) {
+	case 0:
+		return NoopProgress{}, closeFile, nil
+	case 1:
+		return reporters[0], closeFile, nil
+	default:
+		return reporters, closeFile, nil
+	}
+}
+
+// intOrDefault returns *value, or def if value is nil.
+func intOrDefault(value *int, def int) int {
+	if value == nil {
+		return def
+	}
+	return *value
+}
+
+// ToFlags serializes the arguments to the command line of the combine command reproducing them,
+// e.g. ["--output", "combined.txt", "--workers", "8", ..., "--", "src"]. Every flag is given
+// explicitly, so the result does not depend on the command's defaults, and Paths follow "--" as
+// positional arguments. PathsFile is left out, its paths being part of Paths already, and so is
+// NonInteractive, which the command derives from GitHubAction and PathsFile. Empty lists are left
+// out too, except IgnorePatterns, whose empty value replaces the command's default patterns; with
+// ErrorOnEmptyPattern, which rejects that value, the default patterns apply instead.
+func (a Arguments) ToFlags() []string {
+	var flags []string
+	str := func(name, value string) {
+		flags = append(flags, "--"+name, value)
+	}
+	num := func(name string, value int) {
+		str(name, strconv.Itoa(value))
+	}
+	flag := func(name string, value bool) {
+		flags = append(flags, "--"+name+"="+strconv.FormatBool(value))
+	}
+	slice := func(name string, values []string) {
+		if len(values) > 0 {
+			str(name, csvField(values))
+		}
+	}
+	array := func(name string, values []string) {
+		for _, value := range values {
+			str(name, value)
+		}
+	}
+
+	str("output", a.Output)
+	str("stdin-extension", a.StdinExtension)
+	str("prioritize", a.Prioritize)
+	str("tree", a.Tree)
+	array("global-ignore", a.GlobalIgnoreFiles)
+	num("max-size", a.MaxFileSizeKB)
+	num("workers", a.MaxWorkers)
+	num("max-memory-mb", a.MaxMemoryMB)
+	num("max-workers-per-dir", a.MaxWorkersPerDir)
+	if len(a.IgnorePatterns) > 0 || !a.ErrorOnEmptyPattern {
+		str("ignore", csvField(a.IgnorePatterns))
+	}
+	slice("include-pattern", a.IncludePatterns)
+	str("ignore-syntax", stringOrDefault(a.IgnoreSyntax, SyntaxGitignore))
+	flag("ignore-vcs", a.IgnoreVCS)
+	flag("respect-gitignore", a.RespectGitignore)
+	flag("follow-symlinks", a.FollowSymlinks)
+	flag("follow-symlinks-to-dirs-only", a.FollowDirSymlinksOnly)
+	flag("docker-output", a.DockerOutput)
+	flag("docker-volume-check", a.DockerVolumeCheck)
+	flag("verbose", a.Verbose)
+	array("watch-after", a.WatchAfter)
+	str("pre-combine-hook", a.PreCombineHook)
+	str("post-combine-hook", a.PostCombineHook)
+	str("otlp-endpoint", a.OTLPEndpoint)
+
+	str("format", stringOrDefault(a.OutputFormat, FormatText))
+	flag("output-json-stream", a.OutputJSONStream)
+	str("wrap-in-xml-tags", a.WrapInXMLTags)
+	str("encoding-declaration-style", stringOrDefault(a.EncodingDeclaration, EncodingDeclarationNone))
+	str("tree-format", stringOrDefault(a.TreeFormat, TreeFormatText))
+	str("tree-relative-root", a.TreeRelativeRoot)
+	flag("tree-dirs-last", a.TreeDirsLast)
+	flag("include-file-count", a.IncludeFileCount)
+	flag("no-header", a.NoHeader)
+	flag("tree-show-ignored", a.TreeShowIgnored)
+	str("tree-ignored-marker", stringOrDefault(a.TreeIgnoredMarker, DefaultTreeIgnoredMarker))
+	flag("tree-counts", a.TreeCounts)
+	flag("tree-annotations", a.TreeAnnotations)
+	str("tree-annotation-format", stringOrDefault(a.TreeAnnotationFormat, DefaultTreeAnnotationFormat))
+	flag("count-tokens-per-file", a.CountTokensPerFile)
+	flag("token-count", a.TokenCount)
+	num("max-tokens", a.MaxTokens)
+	str("output-template-dir", a.OutputTemplateDir)
+	str("limit-to-imports", a.LimitToImports)
+	flag("write-if-changed", a.WriteIfChanged)
+	str("hash-algorithm", stringOrDefault(a.HashAlgorithm, HashSHA256))
+	str("combine-into-archive", a.CombineIntoArchive)
+	flag("output-per-extension", a.OutputPerExtension)
+	str("split-on-pattern", a.SplitOnPattern)
+	flag("output-split-tree", a.OutputSplitTree)
+	num("split-bytes", a.SplitBytes)
+	num("preview", a.Preview)
+	flag("dry-run", a.DryRun)
+	flag("watch", a.Watch)
+	flag("watch-on-start", a.watchesOnStart())
+	str("output-mode", stringOrDefault(a.OutputMode, OutputModeOverwrite))
+	str("on-error", stringOrDefault(a.OnError, OnErrorSkip))
+	str("require-encoding", a.RequireEncoding)
+	flag("github-action", a.GitHubAction)
+	flag("profile-patterns", a.ProfilePatterns)
+	flag("profile-io", a.ProfileIO)
+	flag("progress", a.Progress)
+	str("progress-file", a.ProgressFile)
+	num("progress-interval", intOrDefault(nonZero(a.ProgressInterval), DefaultProgressInterval))
+	flag("sanitize-paths", a.SanitizePaths)
+	flag("report-skipped-patterns", a.ReportSkippedPatterns)
+	flag("exclude-js-artifacts", a.ExcludeJSArtifacts)
+	flag("error-on-empty-pattern", a.ErrorOnEmptyPattern)
+	flag("combine-changelog", a.CombineChangelog)
+	str("diff-output", a.DiffOutput)
+	str("filter-by-regex", a.FilterByRegex)
+	flag("negate-regex", a.NegateRegex)
+	str("grep", a.GrepPattern)
+	flag("detect-secrets", a.DetectSecrets)
+	flag("exclude-secrets", a.ExcludeSecrets)
+	flag("deduplicate", a.Deduplicate)
+	flag("content-hash-dedup", a.ContentHashDedup)
+	str("header-template", a.HeaderTemplate)
+	flag("incremental", a.Incremental)
+	str("cache-file", stringOrDefault(a.CacheFile, DefaultCacheFile))
+	str("checkpoint", a.Checkpoint)
+	flag("notify-done", a.NotifyDone)
+	flag("open", a.Open)
+	flag("lock", a.Lock)
+	flag("stats", a.Stats)
+	str("stats-output", stringOrDefault(a.StatsOutput, DefaultStatsOutput))
+	flag("summary-table", a.SummaryTable)
+	num("warn-on-large-combined", a.WarnOnLargeCombinedMB)
+	flag("base64-encode-binary", a.Base64EncodeBinary)
+	num("min-unique-lines", a.MinUniqueLines)
+	flag("exclude-init-only", a.ExcludeInitOnly)
+	num("require-min-files", a.RequireMinFiles)
+	num("require-max-files", a.RequireMaxFiles)
+	num("max-symlink-depth", a.MaxSymlinkDepth)
+	num("max-depth", a.MaxDepth)
+
+	if a.ExcludeOlderThan > 0 {
+		str("exclude-older-than", a.ExcludeOlderThan.String())
+	} else {
+		str("exclude-older-than", "")
+	}
+
+	num("read-retries", a.ReadRetries)
+	str("read-retry-delay", a.ReadRetryDelay.String())
+	num("read-chunk-size", intOrDefault(nonZero(a.ReadChunkSize), DefaultChunkSize))
+
+	flag("trim-trailing-whitespace", a.TrimTrailingWhitespace)
+	flag("strip-doc-comments", a.StripDocComments)
+	flag("line-numbers", a.LineNumbers)
+	num("include-git-log", a.IncludeGitLog)
+	flag("parallel-hash", a.ParallelHash)
+	array("content-regex-replace", a.ContentReplacements)
+
+	str("path-normalization", stringOrDefault(a.PathNormalization, PathNormalizationSlash))
+	str("virtual-root", a.VirtualRoot)
+	if len(a.CommentPrefixes) > 0 {
+		// Marshaling a map of strings cannot fail; the keys are written in sorted order
+		prefixes, _ := json.Marshal(a.CommentPrefixes)
+		str("file-comment-format", string(prefixes))
+	} else {
+		str("file-comment-format", "")
+	}
+	str("sort", stringOrDefault(a.Sort, SortPath))
+	flag("group-by-package", a.GroupByPackage)
+
+	num("section-padding-before", intOrDefault(a.SectionPaddingBefore, DefaultSectionPaddingBefore))
+	num("section-padding-after", intOrDefault(a.SectionPaddingAfter, DefaultSectionPaddingAfter))
+
+	threshold := a.BinaryThreshold
+	if threshold == 0 {
+		threshold = DefaultBinaryThreshold
+	}
+	str("binary-threshold", strconv.FormatFloat(threshold, 'g', -1, 64))
+	num("binary-sample-bytes", intOrDefault(nonZero(a.BinarySampleBytes), DefaultBinarySampleBytes))
+	slice("extra-binary-ext", a.ExtraBinaryExt)
+	slice("not-binary-ext", a.NotBinaryExt)
+	slice("treat-as-text", a.TreatAsTextExtensions)
+
+	flags = append(flags, "--")
+	return append(flags, a.Paths...)
+}
+
+// stringOrDefault returns value, or def if value is empty.
+func stringOrDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// nonZero returns a pointer to value, or nil if value is zero, for use with intOrDefault.
+func nonZero(value int) *int {
+	if value == 0 {
+		return nil
+	}
+	return &value
+}
+
+// csvField joins values into the comma-separated form parsed by string slice flags, quoting
+// values that contain commas or quotes themselves.
+func csvField(values []string) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	// Writing to a strings.Builder cannot fail
+	_ = w.Write(values)
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// ProcessOptions holds the options that control how individual files are read and formatted.
+type ProcessOptions struct {
+	ReadRetries    int           // Number of times a file read failing with a transient error is retried.
+	ReadRetryDelay time.Duration // Delay between read retries.
+	ChunkSize      int           // Buffer size in bytes for streaming files larger than 1 MB; defaults to DefaultChunkSize.
+
+	TrimTrailingWhitespace bool // If true, trailing whitespace is stripped from every line of file content.
+	StripDocComments       bool // If true, doc comments are removed from Go and Python files; see StripDocComments.
+	LineNumbers            bool // If true, every line of file content is prefixed with its 1-based line number.
+	IncludeGitLog          int  // Number of recent git log entries prepended to each file; 0 disables.
+	ComputeHash            bool // If true, the SHA-256 of each file is computed from the bytes read.
+	CountTokens            bool // If true, the estimated token count of each file's content is computed.
+
+	Replacements []ContentReplacement // Substitutions applied in order to file content after doc comments are stripped.
+
+	PathNormalization string            // How source paths are written ("slash", "os", or "none"); defaults to slash.
+	CommentPrefixes   map[string]string // Comment prefix used in file headers per extension (without the dot); "#" for unlisted extensions.
+
+	SectionPaddingBefore int // Blank lines before each file's separator line.
+	SectionPaddingAfter  int // Blank lines between each file's Source line and its content.
+
+	Grep        *regexp.Regexp // If non-nil, files whose content does not match are dropped after reading.
+	RequireUTF8 bool           // If true, files whose content is not valid UTF-8 fail with ErrInvalidEncoding.
+
+	DetectSecrets  bool // If true, files containing secrets such as AWS keys or private keys are reported.
+	ExcludeSecrets bool // If true, files with detected secrets are dropped after reading.
+
+	Sanitizer *PathSanitizer // If non-nil, absolute source paths are sanitized before they are written.
+
+	cache      *contentCache // If non-nil, unchanged files are served from the cache of the previous run.
+	checkpoint *checkpoint   // If non-nil, processed files are recorded for resuming, and files recorded earlier are reused.
+	ioProfile  *ioProfile    // If non-nil, the latency of filesystem operations is recorded.
+}
+
+// commentPrefix returns the comment prefix for the header of the file at path.
+func (o ProcessOptions) commentPrefix(path string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if prefix, ok := o.CommentPrefixes[ext]; ok {
+		return prefix
+	}
+	return DefaultCommentPrefix
 }
 
 // FileContent represents the structured content of a single file.
 type FileContent struct {
 	Path    string // Relative file path to the file being processed.
-	Content string // The formatted content of the file.
+	AbsPath string // Absolute path the file was read from.
+	Size    int64  // Size of the file in bytes as read from disk.
+	Header  string // Section header written before the content in text output.
+	Content string // The content of the file.
+	SHA256  string // Hex-encoded SHA-256 of the file as read from disk; empty unless hashing is enabled.
+	Tokens  int    // Estimated token count of Content; zero unless token counting is enabled.
 }
 
 // CollectedFiles contains categorized lists of files discovered during processing.
 type CollectedFiles struct {
-	Regular []string // List of paths to regular (non-binary) files.
-	Binary  []string // List of paths to binary files.
+	Regular  []string           // List of paths to regular (non-binary) files.
+	Binary   []string           // List of paths to binary files.
+	Symlinks []string           // List of paths to symlinked directories encountered, whether followed or not.
+	Skipped  map[SkipReason]int // Number of files skipped during collection, by reason.
+
+	PermissionDenied []string // Paths skipped during traversal because they could not be read.
+}
+
+// BinaryFile holds the raw content of a binary file stored verbatim in archive output formats.
+type BinaryFile struct {
+	Path    string // Source path of the file, relative like FileContent.Path.
+	Content []byte // Unmodified file content.
 }
@@ -0,0 +1,63 @@
+// File: pkg/combine/config_file.go
+package combine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadConfigFile reads Arguments from a YAML file, or a TOML file if path ends in ".toml".
+// Keys name Arguments fields and are matched case-insensitively, as in the JSON accepted by
+// the serve command, e.g. "maxFileSizeKB: 512". Unknown keys are rejected.
+func LoadConfigFile(path string) (Arguments, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Arguments{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := make(map[string]any)
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		err = toml.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return Arguments{}, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	// Decode through JSON to reuse its case-insensitive field matching
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return Arguments{}, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	var args Arguments
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&args); err != nil {
+		return Arguments{}, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return args, nil
+}
+
+// MergeConfig returns base with every non-zero field of override applied on top, so that
+// configuration can be layered. Since false and 0 are zero values, an override cannot reset
+// a field that base has set.
+func MergeConfig(base, override Arguments) Arguments {
+	merged := base
+	mergedValue := reflect.ValueOf(&merged).Elem()
+	overrideValue := reflect.ValueOf(override)
+	for i := 0; i < overrideValue.NumField(); i++ {
+		if field := overrideValue.Field(i); !field.IsZero() {
+			mergedValue.Field(i).Set(field)
+		}
+	}
+	return merged
+}
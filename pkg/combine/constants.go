@@ -1,6 +1,87 @@
 // File: pkg/combine/constants.go
 package combine
 
+// Supported output formats for the combined file.
+const (
+	FormatText = "text" // Flat text with a separator header before each file.
+	FormatJSON = "json" // JSON document with the tree and a list of files.
+	FormatYAML = "yaml" // YAML document with the same structure as the JSON output.
+	FormatZip  = "zip"  // ZIP archive with one entry per file, binary files included verbatim.
+)
+
+// Supported formats for the tree structure output file.
+const (
+	TreeFormatText = "text" // Indented text tree with box-drawing characters.
+	TreeFormatJSON = "json" // JSON array of nested nodes.
+	TreeFormatXML  = "xml"  // XML document of nested directory and file elements.
+)
+
+// Supported modes for handling an existing combined output file.
+const (
+	OutputModeOverwrite    = "overwrite"      // Replace the existing file.
+	OutputModeAppend       = "append"         // Append the new content to the existing file.
+	OutputModeFailIfExists = "fail-if-exists" // Abort if the output file already exists.
+)
+
+// Supported ways of handling files that fail to process.
+const (
+	OnErrorSkip = "skip" // Leave the file out, exit with ExitPartialSuccess, and continue (default).
+	OnErrorFail = "fail" // Abandon the remaining files and fail the run with the first error.
+)
+
+// EncodingUTF8 is the RequireEncoding value that rejects files whose content is not valid UTF-8.
+const EncodingUTF8 = "utf-8"
+
+// Supported styles of the encoding declaration at the start of text output, which is always UTF-8.
+const (
+	EncodingDeclarationNone   = "none"   // No declaration.
+	EncodingDeclarationPython = "python" // A "# -*- coding: utf-8 -*-" comment, as read by Python and Emacs.
+	EncodingDeclarationXML    = "xml"    // An XML declaration, e.g. for output wrapped with WrapInXMLTags.
+)
+
+// StdoutPath is the output or tree path that selects standard output instead of a file.
+const StdoutPath = "-"
+
+// StdinPath is the paths file that selects standard input instead of a file.
+const StdinPath = "-"
+
+// Supported normalizations for source paths in the combined output.
+const (
+	PathNormalizationSlash = "slash" // Forward slashes on every platform (default).
+	PathNormalizationOS    = "os"    // The native path separator of the current OS.
+	PathNormalizationNone  = "none"  // Paths exactly as computed, without normalization.
+)
+
+// Supported orders of the files in the combined output.
+const (
+	SortPath  = "path"  // Alphabetically by source path (default).
+	SortDepth = "depth" // By the number of directories in the source path, shallowest first, then by path.
+)
+
+// DefaultCommentPrefix is the comment prefix of file headers for extensions without a configured prefix.
+const DefaultCommentPrefix = "#"
+
+// Default number of blank lines around the header of each file section in text output.
+const (
+	DefaultSectionPaddingBefore = 2 // Blank lines between the previous section and the separator line.
+	DefaultSectionPaddingAfter  = 1 // Blank lines between the Source line and the file's content.
+)
+
+// DefaultChunkSize is the default buffer size, in bytes, used when streaming large files.
+const DefaultChunkSize = 64 * 1024
+
+// streamingReadThreshold is the file size, in bytes, above which files are streamed in chunks.
+const streamingReadThreshold = 1024 * 1024
+
+// DefaultBinaryThreshold is the ratio of non-printable bytes above which file content is binary.
+const DefaultBinaryThreshold = 0.3
+
+// DefaultBinarySampleBytes is the number of leading bytes inspected to detect binary content.
+const DefaultBinarySampleBytes = 512
+
+// DefaultProgressInterval is the number of files processed between two updates of the progress file.
+const DefaultProgressInterval = 10
+
 // BinaryExtensions maps common binary file extensions to a boolean flag.
 // It is used to quickly determine if a file should be treated as binary and potentially ignored.
 var BinaryExtensions = map[string]bool{
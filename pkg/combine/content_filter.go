@@ -0,0 +1,129 @@
+// File: pkg/combine/content_filter.go
+package combine
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// FilterByContent returns the files whose content matches re, preserving order.
+// When negate is true, only files whose content does not match re are returned.
+// Files that cannot be read are dropped with a warning.
+func FilterByContent(files []string, re *regexp.Regexp, negate bool, logger *zap.Logger) []string {
+	var filtered []string
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			logger.Warn("Failed to read file for content filtering", zap.String("filePath", file), zap.Error(err))
+			continue
+		}
+
+		if re.Match(content) != negate {
+			filtered = append(filtered, file)
+		} else {
+			logger.Debug("Skipping file not matching content filter",
+				zap.String("filePath", file),
+				zap.String("pattern", re.String()),
+				zap.Bool("negate", negate))
+		}
+	}
+	return filtered
+}
+
+// FilterByUniqueLines returns the files in which at least minPercent percent of the lines
+// are unique, preserving order. Files that cannot be read are dropped with a warning.
+func FilterByUniqueLines(files []string, minPercent int, logger *zap.Logger) []string {
+	var filtered []string
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			logger.Warn("Failed to read file for unique line filtering", zap.String("filePath", file), zap.Error(err))
+			continue
+		}
+
+		if ratio := uniqueLineRatio(content); ratio*100 >= float64(minPercent) {
+			filtered = append(filtered, file)
+		} else {
+			logger.Debug("Skipping file with too little unique content",
+				zap.String("filePath", file),
+				zap.Float64("uniqueLinePercent", ratio*100),
+				zap.Int("minUniqueLinePercent", minPercent))
+		}
+	}
+	return filtered
+}
+
+// pythonInitFile is the base name of the file marking a directory as a Python package.
+const pythonInitFile = "__init__.py"
+
+// relativeImportPattern matches a Python relative import statement such as "from . import *" or
+// "from .module import name", capturing the imported names.
+var relativeImportPattern = regexp.MustCompile(`^from\s+\.[\w.]*\s+import\s+(.+)$`)
+
+// FilterInitOnly returns the files that are not Python __init__.py files consisting only of
+// relative imports, preserving order. Such files merely re-export names from their submodules.
+// Files that cannot be read are kept.
+func FilterInitOnly(files []string, logger *zap.Logger) []string {
+	var filtered []string
+	for _, file := range files {
+		if filepath.Base(file) == pythonInitFile {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				logger.Warn("Failed to read file for __init__.py filtering", zap.String("filePath", file), zap.Error(err))
+			} else if isImportOnlyInit(content) {
+				logger.Debug("Skipping __init__.py consisting only of imports", zap.String("filePath", file))
+				continue
+			}
+		}
+		filtered = append(filtered, file)
+	}
+	return filtered
+}
+
+// isImportOnlyInit reports whether every line of content that is not blank or a comment belongs to
+// a relative import, including parenthesized imports spanning several lines, and there is at
+// least one such import.
+func isImportOnlyInit(content []byte) bool {
+	imports := 0
+	inParens := false
+	for _, line := range strings.Split(string(content), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if inParens {
+			inParens = !strings.Contains(line, ")")
+			continue
+		}
+		match := relativeImportPattern.FindStringSubmatch(line)
+		if match == nil {
+			return false
+		}
+		imports++
+		inParens = strings.HasPrefix(match[1], "(") && !strings.Contains(match[1], ")")
+	}
+	return imports > 0 && !inParens
+}
+
+// uniqueLineRatio returns the ratio of distinct lines to total lines in content.
+// Empty content is considered fully unique.
+func uniqueLineRatio(content []byte) float64 {
+	lines := bytes.Split(bytes.TrimSuffix(content, []byte("\n")), []byte("\n"))
+	if len(content) == 0 || len(lines) == 0 {
+		return 1
+	}
+
+	unique := make(map[string]struct{}, len(lines))
+	for _, line := range lines {
+		unique[string(bytes.TrimRight(line, "\r"))] = struct{}{}
+	}
+	return float64(len(unique)) / float64(len(lines))
+}
@@ -0,0 +1,61 @@
+// File: pkg/combine/content_replace.go
+package combine
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// ContentReplacement is a regular expression substitution applied to the content of every file.
+type ContentReplacement struct {
+	Pattern     *regexp.Regexp // Matches the text to replace.
+	Replacement string         // Replacement text; $1 or ${name} refer to submatches as in Regexp.ReplaceAllString.
+}
+
+// ParseContentReplacement parses a "PATTERN:REPLACEMENT" spec such as `/home/\w+:~`. The spec is
+// split at the first colon not escaped by a backslash, so that `\:` matches a literal colon in
+// the pattern, and the replacement may contain colons.
+func ParseContentReplacement(spec string) (ContentReplacement, error) {
+	sep := -1
+	for i := 0; i < len(spec) && sep < 0; i++ {
+		switch spec[i] {
+		case '\\':
+			i++ // Skip the escaped character
+		case ':':
+			sep = i
+		}
+	}
+	if sep < 0 {
+		return ContentReplacement{}, fmt.Errorf("replacement %q: expected PATTERN:REPLACEMENT", spec)
+	}
+	if sep == 0 {
+		return ContentReplacement{}, fmt.Errorf("replacement %q: empty pattern", spec)
+	}
+
+	pattern, err := regexp.Compile(spec[:sep])
+	if err != nil {
+		return ContentReplacement{}, fmt.Errorf("replacement %q: %w", spec, err)
+	}
+	return ContentReplacement{Pattern: pattern, Replacement: spec[sep+1:]}, nil
+}
+
+// ParseContentReplacements parses specs with ParseContentReplacement, keeping their order.
+func ParseContentReplacements(specs []string) ([]ContentReplacement, error) {
+	var replacements []ContentReplacement
+	for _, spec := range specs {
+		replacement, err := ParseContentReplacement(spec)
+		if err != nil {
+			return nil, err
+		}
+		replacements = append(replacements, replacement)
+	}
+	return replacements, nil
+}
+
+// ReplaceContent applies replacements to content in order, each to the result of the previous one.
+func ReplaceContent(content string, replacements []ContentReplacement) string {
+	for _, r := range replacements {
+		content = r.Pattern.ReplaceAllString(content, r.Replacement)
+	}
+	return content
+}
@@ -0,0 +1,52 @@
+// File: pkg/combine/dedup.go
+package combine
+
+import (
+	"crypto/sha256"
+
+	"go.uber.org/zap"
+)
+
+// deduplicateContents drops every file whose content is identical to that of an earlier file
+// in contents, so that with sorted contents the alphabetically first path wins.
+func deduplicateContents(contents []FileContent, logger *zap.Logger) []FileContent {
+	firstPaths := make(map[[sha256.Size]byte]string, len(contents))
+	unique := contents[:0]
+	for _, content := range contents {
+		digest := sha256.Sum256([]byte(content.Content))
+		if first, seen := firstPaths[digest]; seen {
+			logger.Debug("skipping duplicate content", zap.String("file", content.Path), zap.String("duplicateOf", first))
+			continue
+		}
+		firstPaths[digest] = content.Path
+		unique = append(unique, content)
+	}
+	return unique
+}
+
+// markDuplicateContents replaces the content of every file identical to an earlier file in
+// contents with a "Duplicate of: path" comment naming that file, so that with sorted contents the
+// alphabetically first path keeps its content. It returns the number of duplicates and the size of
+// the duplicate content they no longer repeat.
+func markDuplicateContents(contents []FileContent, opts ProcessOptions, logger *zap.Logger) (int, int64) {
+	firstPaths := make(map[[sha256.Size]byte]string, len(contents))
+	duplicates := 0
+	var saved int64
+	for i, content := range contents {
+		digest := sha256.Sum256([]byte(content.Content))
+		first, seen := firstPaths[digest]
+		if !seen {
+			firstPaths[digest] = content.Path
+			continue
+		}
+		note := opts.commentPrefix(content.Path) + " Duplicate of: " + first + "\n"
+		duplicates++
+		saved += int64(len(content.Content))
+		contents[i].Content = note
+		if opts.CountTokens {
+			contents[i].Tokens = CountTokens(note)
+		}
+		logger.Debug("Replaced duplicate content", zap.String("file", content.Path), zap.String("duplicateOf", first))
+	}
+	return duplicates, saved
+}
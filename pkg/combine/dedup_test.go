@@ -0,0 +1,40 @@
+// File: pkg/combine/dedup_test.go
+package combine_test
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"agentexec/pkg/combine"
+)
+
+// TestDeduplicate checks that files with identical content are combined once, under the first
+// path in sorted order, and that files with distinct content are all kept.
+func TestDeduplicate(t *testing.T) {
+	args := testArguments(t, fstest.MapFS{
+		"src/vendor/lib/util.go": text("package util\n\nfunc Util() {}\n"),
+		"src/internal/util.go":   text("package util\n\nfunc Util() {}\n"),
+		"src/copy/util.go":       text("package util\n\nfunc Util() {}\n"),
+		"src/near/util.go":       text("package util\n\nfunc Util() {} \n"),
+		"src/main.go":            text("package main\n"),
+		"src/empty1.txt":         text(""),
+		"src/empty2.txt":         text(""),
+	})
+	args.Deduplicate = true
+	result := runCombine(t, args)
+
+	files, err := combine.ParseCombinedFile(strings.NewReader(readFile(t, args.Output)))
+	if err != nil {
+		t.Fatalf("ParseCombinedFile() = %v", err)
+	}
+	want := []string{"src/copy/util.go", "src/empty1.txt", "src/main.go", "src/near/util.go"}
+	if got := slices.Sorted(maps.Keys(files)); !slices.Equal(got, want) {
+		t.Errorf("combined files = %q, want %q", got, want)
+	}
+	if result.FilesIncluded != len(want) {
+		t.Errorf("FilesIncluded = %d, want %d", result.FilesIncluded, len(want))
+	}
+}
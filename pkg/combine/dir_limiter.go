@@ -0,0 +1,48 @@
+// File: pkg/combine/dir_limiter.go
+package combine
+
+import (
+	"context"
+	"sync"
+)
+
+// dirLimiter limits the number of files of the same directory processed at once, so that
+// workers reading from a spinning disk do not make its head seek back and forth between them.
+// Each directory gets a semaphore of the configured size when a file of it is first processed.
+type dirLimiter struct {
+	mu   sync.Mutex
+	size int
+	sems map[string]chan struct{}
+}
+
+// newDirLimiter returns a limiter allowing n files per directory at once, or nil, which imposes
+// no limit, if n is not positive.
+func newDirLimiter(n int) *dirLimiter {
+	if n <= 0 {
+		return nil
+	}
+	return &dirLimiter{size: n, sems: make(map[string]chan struct{})}
+}
+
+// acquire waits until another file of dir may be processed and returns the function releasing
+// its slot. It reports false if ctx is cancelled first.
+func (l *dirLimiter) acquire(ctx context.Context, dir string) (func(), bool) {
+	if l == nil {
+		return func() {}, true
+	}
+
+	l.mu.Lock()
+	sem, ok := l.sems[dir]
+	if !ok {
+		sem = make(chan struct{}, l.size)
+		l.sems[dir] = sem
+	}
+	l.mu.Unlock()
+
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
@@ -0,0 +1,60 @@
+// File: pkg/combine/diskspace.go
+package combine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// ErrInsufficientDiskSpace is returned by CheckDiskSpace if the filesystem lacks the space needed.
+var ErrInsufficientDiskSpace = errors.New("insufficient disk space")
+
+// errDiskSpaceUnsupported is returned by availableDiskSpace on platforms where free space cannot
+// be determined.
+var errDiskSpaceUnsupported = errors.New("free disk space cannot be determined on this platform")
+
+// CheckDiskSpace returns an error wrapping ErrInsufficientDiskSpace if fewer than neededBytes are
+// available to the current user on the filesystem holding path, which must exist. Other errors
+// mean that the free space could not be determined.
+func CheckDiskSpace(path string, neededBytes int64) error {
+	available, err := availableDiskSpace(path)
+	if err != nil {
+		return fmt.Errorf("failed to determine free disk space for %s: %w", path, err)
+	}
+	if neededBytes > 0 && uint64(neededBytes) > available {
+		return fmt.Errorf("%w for %s: about %s needed, %s available", ErrInsufficientDiskSpace, path,
+			formatByteSize(neededBytes), formatByteSize(int64(min(available, math.MaxInt64))))
+	}
+	return nil
+}
+
+// inputSize returns the total size of files, used to estimate the size of the output; files
+// that cannot be inspected are not counted.
+func inputSize(files []string) int64 {
+	var total int64
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// checkOutputDiskSpace fails if the output directory cannot hold an output of about the size of
+// files. Free space that cannot be determined is only logged, so that the run goes ahead.
+func checkOutputDiskSpace(dir string, files []string, logger *zap.Logger) error {
+	needed := inputSize(files)
+	err := CheckDiskSpace(dir, needed)
+	if errors.Is(err, ErrInsufficientDiskSpace) {
+		logger.Error("Not enough disk space for the output", zap.String("dir", dir), zap.Int64("neededBytes", needed), zap.Error(err))
+		return err
+	}
+	if err != nil {
+		logger.Debug("Skipping disk space check", zap.String("dir", dir), zap.Error(err))
+	}
+	return nil
+}
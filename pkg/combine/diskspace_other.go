@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+// File: pkg/combine/diskspace_other.go
+package combine
+
+// availableDiskSpace reports that free disk space cannot be determined on this platform.
+func availableDiskSpace(path string) (uint64, error) {
+	return 0, errDiskSpaceUnsupported
+}
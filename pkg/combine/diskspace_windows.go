@@ -0,0 +1,20 @@
+//go:build windows
+
+// File: pkg/combine/diskspace_windows.go
+package combine
+
+import "golang.org/x/sys/windows"
+
+// availableDiskSpace returns the number of bytes available to the current user, honoring disk
+// quotas, on the volume holding path.
+func availableDiskSpace(path string) (uint64, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var available, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(pathPtr, &available, &total, &free); err != nil {
+		return 0, err
+	}
+	return available, nil
+}
@@ -0,0 +1,162 @@
+// File: pkg/combine/doc_comments.go
+package combine
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// docCommentStrippers maps file extensions (without the dot) to the function removing the doc
+// comments of their language. Files with other extensions are left unchanged by --strip-doc-comments.
+var docCommentStrippers = map[string]func(content string) string{
+	"go":  StripGoDocComments,
+	"py":  StripPythonDocComments,
+	"pyi": StripPythonDocComments,
+}
+
+// goDocKeywords are the keywords starting the top-level Go declarations whose doc comments are stripped.
+var goDocKeywords = []string{"package", "func", "type", "var", "const"}
+
+// StripDocComments removes the doc comments from content, the file at path, using the stripper
+// registered for its extension in docCommentStrippers.
+func StripDocComments(path, content string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if strip, ok := docCommentStrippers[ext]; ok {
+		return strip(content)
+	}
+	return content
+}
+
+// StripGoDocComments removes the block of "//" comment lines directly preceding each top-level
+// package, func, type, var, and const declaration. Comments separated from a declaration by a
+// blank line, indented comments, and compiler directives such as //go:embed are kept.
+func StripGoDocComments(content string) string {
+	lines := strings.SplitAfter(content, "\n")
+	keep := make([]bool, len(lines))
+	for i := range lines {
+		keep[i] = true
+	}
+
+	for i, line := range lines {
+		if !isGoDeclaration(line) {
+			continue
+		}
+		for j := i - 1; j >= 0 && strings.HasPrefix(lines[j], "//"); j-- {
+			if !isGoDirective(lines[j]) {
+				keep[j] = false
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.Grow(len(content))
+	for i, line := range lines {
+		if keep[i] {
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// isGoDeclaration reports whether line starts a top-level declaration with one of goDocKeywords.
+func isGoDeclaration(line string) bool {
+	for _, keyword := range goDocKeywords {
+		if rest, ok := strings.CutPrefix(line, keyword); ok && rest != "" && strings.ContainsRune(" \t(", rune(rest[0])) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGoDirective reports whether the comment line is a directive for the Go toolchain, such as
+// "//go:generate" or "//export", rather than documentation.
+func isGoDirective(line string) bool {
+	return strings.HasPrefix(line, "//go:") || strings.HasPrefix(line, "//export ") || strings.HasPrefix(line, "//line ")
+}
+
+// StripPythonDocComments removes the docstring, the triple-quoted string forming the first
+// statement of a body, of every def and class. Module docstrings are kept.
+func StripPythonDocComments(content string) string {
+	lines := strings.SplitAfter(content, "\n")
+	var b strings.Builder
+	b.Grow(len(content))
+	for i := 0; i < len(lines); i++ {
+		b.WriteString(lines[i])
+		if !isPythonDefinition(lines[i]) {
+			continue
+		}
+
+		// The signature may span several lines until its brackets are closed
+		end := i
+		for depth := bracketDepth(lines[end]); depth > 0 && end+1 < len(lines); depth += bracketDepth(lines[end]) {
+			end++
+			b.WriteString(lines[end])
+		}
+		i = end
+		if !strings.HasSuffix(strings.TrimSpace(lines[end]), ":") {
+			continue // A body on the same line has no docstring
+		}
+
+		// The docstring is the first statement after any blank lines
+		start := i + 1
+		for start < len(lines) && strings.TrimSpace(lines[start]) == "" {
+			start++
+		}
+		if start == len(lines) {
+			continue
+		}
+		if last, ok := docstringEnd(lines, start); ok {
+			for _, blank := range lines[i+1 : start] {
+				b.WriteString(blank)
+			}
+			i = last
+		}
+	}
+	return b.String()
+}
+
+// isPythonDefinition reports whether line starts a function or class definition.
+func isPythonDefinition(line string) bool {
+	trimmed := strings.TrimLeft(line, " \t")
+	return strings.HasPrefix(trimmed, "def ") || strings.HasPrefix(trimmed, "async def ") || strings.HasPrefix(trimmed, "class ")
+}
+
+// bracketDepth returns the number of brackets line opens minus the number it closes.
+func bracketDepth(line string) int {
+	depth := 0
+	for _, r := range line {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// docstringEnd reports whether lines[start] begins a triple-quoted string, optionally with a
+// string prefix such as r or u, and returns the index of the line closing it.
+func docstringEnd(lines []string, start int) (int, bool) {
+	text := strings.TrimLeft(lines[start], " \t")
+	text = strings.TrimLeft(text, "rRuU")
+	var quote string
+	switch {
+	case strings.HasPrefix(text, `"""`):
+		quote = `"""`
+	case strings.HasPrefix(text, `'''`):
+		quote = `'''`
+	default:
+		return 0, false
+	}
+
+	if strings.Contains(text[len(quote):], quote) {
+		return start, true
+	}
+	for i := start + 1; i < len(lines); i++ {
+		if strings.Contains(lines[i], quote) {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,125 @@
+// File: pkg/combine/docker.go
+package combine
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Files inspected to detect a container and its mounts.
+const (
+	dockerEnvFile  = "/.dockerenv"
+	procCgroupFile = "/proc/1/cgroup"
+	mountInfoFile  = "/proc/self/mountinfo"
+)
+
+// InContainer reports whether the process appears to run inside a Docker (or similar) container,
+// based on the presence of /.dockerenv or container runtime names in the cgroup of PID 1.
+func InContainer() bool {
+	if _, err := os.Stat(dockerEnvFile); err == nil {
+		return true
+	}
+	data, err := os.ReadFile(procCgroupFile)
+	if err != nil {
+		return false
+	}
+	cgroup := string(data)
+	for _, marker := range []string{"docker", "containerd", "kubepods", "libpod"} {
+		if strings.Contains(cgroup, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// mountPointOf returns the deepest mount point containing path, according to /proc/self/mountinfo.
+func mountPointOf(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.Open(mountInfoFile)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	mountPoint := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Fields: mount ID, parent ID, major:minor, root, mount point, ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mp := unescapeMountInfo(fields[4])
+		if isWithinDir(absPath, mp) && len(mp) > len(mountPoint) {
+			mountPoint = mp
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if mountPoint == "" {
+		return "", fmt.Errorf("no mount point found for %s", absPath)
+	}
+	return mountPoint, nil
+}
+
+// unescapeMountInfo decodes the octal escapes (such as \040 for a space) used in mountinfo paths.
+func unescapeMountInfo(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// checkDockerOutputs verifies that every file the run writes lies on a mount other than the
+// container's root filesystem, since anything else is lost when the container exits. Outside a
+// container nothing is checked. Unmounted paths are reported as warnings, or as an error if strict.
+func checkDockerOutputs(args Arguments, strict bool, logger *zap.Logger) error {
+	if !InContainer() {
+		logger.Debug("Not running inside a container, skipping output mount check")
+		return nil
+	}
+	for _, path := range []string{args.Output, args.Tree, args.CombineIntoArchive} {
+		if path == "" || path == StdoutPath {
+			continue
+		}
+		mountPoint, err := mountPointOf(path)
+		if err != nil {
+			logger.Warn("Unable to determine mount point of output path", zap.String("path", path), zap.Error(err))
+			if strict {
+				return fmt.Errorf("unable to verify that %s is on a mounted volume: %w", path, err)
+			}
+			continue
+		}
+		if mountPoint != "/" {
+			logger.Debug("Output path is on a mounted volume", zap.String("path", path), zap.String("mountPoint", mountPoint))
+			continue
+		}
+		if strict {
+			logger.Error("Output path is not on a mounted volume", zap.String("path", path))
+			return fmt.Errorf("output path %s is not on a volume mounted from the host", path)
+		}
+		logger.Warn("Output path is not on a mounted volume and will be lost when the container exits", zap.String("path", path))
+	}
+	return nil
+}
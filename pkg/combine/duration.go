@@ -0,0 +1,72 @@
+// File: pkg/combine/duration.go
+package combine
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Units accepted by ParseDuration in addition to those of time.ParseDuration.
+const (
+	day  = 24 * time.Hour
+	year = 365 * day
+)
+
+// ParseDuration parses a duration string like time.ParseDuration, additionally accepting
+// "d" (24 hours) and "y" (365 days) units, e.g. "7d", "1y", or "1d12h".
+func ParseDuration(s string) (time.Duration, error) {
+	orig := s
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	if s == "0" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", orig)
+	}
+
+	var total time.Duration
+	for s != "" {
+		i := 0
+		for i < len(s) && (s[i] == '.' || ('0' <= s[i] && s[i] <= '9')) {
+			i++
+		}
+		j := i
+		for j < len(s) && s[j] != '.' && (s[j] < '0' || s[j] > '9') {
+			j++
+		}
+		if i == 0 || j == i {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+
+		var d time.Duration
+		switch unit := s[i:j]; unit {
+		case "d", "y":
+			value, err := strconv.ParseFloat(s[:i], 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", orig)
+			}
+			d = day
+			if unit == "y" {
+				d = year
+			}
+			d = time.Duration(value * float64(d))
+		default:
+			var err error
+			if d, err = time.ParseDuration(s[:j]); err != nil {
+				return 0, fmt.Errorf("invalid duration %q", orig)
+			}
+		}
+		total += d
+		s = s[j:]
+	}
+
+	if neg {
+		total = -total
+	}
+	return total, nil
+}
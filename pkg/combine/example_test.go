@@ -0,0 +1,86 @@
+// File: pkg/combine/example_test.go
+package combine_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"agentexec/pkg/combine"
+)
+
+// exampleProject writes a small project to a temporary directory and returns its path.
+func exampleProject() string {
+	dir, err := os.MkdirTemp("", "combine-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	files := map[string]string{
+		"main.go":          "package main\n",
+		"lib/util.go":      "package lib\n",
+		"lib/util_test.go": "package lib\n",
+		"README.md":        "# Example\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func ExampleCollectFiles() {
+	dir := exampleProject()
+	defer os.RemoveAll(dir)
+
+	gi := combine.NewCombineIgnore(nil)
+	gi.CompileIgnoreLines("*_test.go")
+
+	collected, err := combine.CollectFiles([]string{dir}, gi, combine.CollectOptions{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	var paths []string
+	for _, path := range collected.Regular {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+	// Output:
+	// README.md
+	// lib/util.go
+	// main.go
+}
+
+func ExampleProcessFilesConcurrently() {
+	dir := exampleProject()
+	defer os.RemoveAll(dir)
+
+	files := []string{filepath.Join(dir, "main.go"), filepath.Join(dir, "lib", "util.go")}
+	contents, failed, err := combine.ProcessFilesConcurrently(context.Background(), files, dir,
+		combine.DefaultProcessOptions(), combine.WithMaxWorkers(2), combine.WithOrderedResults(true))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("failed:", failed)
+	for _, content := range contents {
+		fmt.Printf("%s: %q\n", content.Path, content.Content)
+	}
+	// Output:
+	// failed: 0
+	// lib/util.go: "package lib\n"
+	// main.go: "package main\n"
+}
@@ -2,115 +2,858 @@
 package combine
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
+	"text/template"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
 // executeProcess encapsulates the main logic for combining files.
-func executeProcess(args Arguments, logger *zap.Logger) error {
+func executeProcess(ctx context.Context, args Arguments, logger *zap.Logger) (result CombineResult, err error) {
 	logger.Debug("Starting combine process", zap.Strings("paths", args.Paths))
 
-	// Ensure output and tree directories exist
-	if err := ensureDirectory(filepath.Dir(args.Output), logger); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+		if err != nil {
+			result.ExitCode = ExitFatal
+		}
+	}()
+
+	// Trace the run with the arguments it was given; spans are no-ops unless tracing was started
+	ctx, span := tracer.Start(ctx, "agentexec.combine")
+	if span.IsRecording() {
+		span.SetAttributes(argumentAttributes(args)...)
+	}
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	// Time filesystem operations and report them once everything has been written
+	if args.ProfileIO {
+		args.ioProfile = newIOProfile()
+		defer func() {
+			fmt.Fprintln(os.Stderr, "I/O profile:")
+			if reportErr := args.ioProfile.writeReport(os.Stderr); reportErr != nil {
+				logger.Warn("Failed to write I/O profile", zap.Error(reportErr))
+			}
+		}()
 	}
-	if err := ensureDirectory(filepath.Dir(args.Tree), logger); err != nil {
-		return fmt.Errorf("failed to create tree output directory: %w", err)
+
+	// Outputs written inside a container are lost unless they land on a volume mounted from the host
+	if args.DockerOutput || args.DockerVolumeCheck {
+		if err := checkDockerOutputs(args, args.DockerVolumeCheck, logger); err != nil {
+			return result, err
+		}
 	}
 
-	// Load ignore patterns from `.combineignore` files (local and global)
-	var globalIgnorePath string
-	if args.GlobalIgnoreFile != "" {
-		globalIgnorePath = args.GlobalIgnoreFile
-	} else {
-		globalIgnorePath = os.Getenv("COMBINEIGNORE_GLOBAL") // Optional environment variable for global ignore file
+	// Ensure output and tree directories exist; a dry run creates nothing on disk
+	if args.Output != StdoutPath && !args.DryRun {
+		if err := ensureDirectory(filepath.Dir(args.Output), logger); err != nil {
+			return result, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	if args.Tree != StdoutPath && !args.DryRun {
+		if err := ensureDirectory(filepath.Dir(args.Tree), logger); err != nil {
+			return result, fmt.Errorf("failed to create tree output directory: %w", err)
+		}
+	}
+
+	// Keep concurrent runs from writing the same output
+	if args.Lock && args.Output != StdoutPath && !args.DryRun {
+		release, err := acquireOutputLock(args.Output, logger)
+		if err != nil {
+			logger.Error("Failed to lock output file", zap.String("outputFile", args.Output), zap.Error(err))
+			return result, err
+		}
+		defer release()
 	}
 
-	gi, err := LoadIgnoreFiles(globalIgnorePath, logger)
+	// Refuse to clobber an existing output file before doing any work
+	if args.OutputMode == OutputModeFailIfExists && args.Output != StdoutPath {
+		if _, err := os.Stat(args.Output); err == nil {
+			logger.Error("Output file already exists", zap.String("outputFile", args.Output))
+			return result, fmt.Errorf("output file %s already exists", args.Output)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return result, fmt.Errorf("failed to check output file: %w", err)
+		}
+	}
+
+	// Run the hooks around the combine, unless nothing is to be written
+	if !args.DryRun && args.Preview == 0 {
+		if args.PreCombineHook != "" {
+			logger.Debug("Running pre-combine hook", zap.String("command", args.PreCombineHook))
+			if err := RunHook(ctx, args.PreCombineHook, nil); err != nil {
+				logger.Error("Pre-combine hook failed", zap.String("command", args.PreCombineHook), zap.Error(err))
+				return result, err
+			}
+		}
+		if args.PostCombineHook != "" {
+			defer func() {
+				if err != nil || result.OutputPath == "" {
+					return
+				}
+				logger.Debug("Running post-combine hook", zap.String("command", args.PostCombineHook))
+				if err = RunHook(ctx, args.PostCombineHook, []string{"AGENTEXEC_OUTPUT=" + result.OutputPath}); err != nil {
+					logger.Error("Post-combine hook failed", zap.String("command", args.PostCombineHook), zap.Error(err))
+				}
+			}()
+		}
+	}
+
+	// Read piped standard input as a virtual file; stdin can then no longer answer prompts
+	var stdinData []byte
+	if args.StdinExtension != "" {
+		data, ok, err := readStdin(os.Stdin, logger)
+		if err != nil {
+			logger.Error("Failed to read standard input", zap.Error(err))
+			return result, err
+		}
+		if ok {
+			stdinData = data
+			args.NonInteractive = true
+		}
+	}
+
+	// Load a custom output template for the selected format, if one is provided
+	outputTemplate, err := LoadOutputTemplate(args.OutputTemplateDir, args.OutputFormat, logger)
 	if err != nil {
-		logger.Error("Failed to load ignore patterns", zap.Error(err))
-		return fmt.Errorf("failed to load ignore patterns: %w", err)
+		return result, fmt.Errorf("failed to load output template: %w", err)
 	}
-	logger.Debug("Loaded ignore patterns", zap.Int("totalPatterns", len(gi.patterns)))
 
-	// Add command-line ignore patterns to the ignore parser
-	if len(args.IgnorePatterns) > 0 {
-		gi.CompileIgnoreLines(args.IgnorePatterns...)
-		logger.Debug("Added command-line ignore patterns", zap.Int("count", len(args.IgnorePatterns)))
+	// Load ignore patterns from `.combineignore` files and the command line
+	_, ignoreSpan := tracer.Start(ctx, "agentexec.load_ignore_patterns")
+	gi, err := loadIgnorePatterns(args, logger)
+	endSpan(ignoreSpan, err)
+	if err != nil {
+		return result, err
+	}
+
+	if args.ProfilePatterns {
+		gi.EnableProfiling()
+	}
+
+	// Index large pattern sets so that each path is only tested against relevant patterns
+	var parser IgnoreParser = gi
+	if len(gi.patterns) >= trieIgnoreMinPatterns {
+		parser = NewTrieIgnore(gi)
 	}
 
 	// Collect files and binaries
-	collected, err := CollectFiles(args.Paths, gi, args.MaxFileSizeKB, logger, args.Verbose)
+	_, collectSpan := tracer.Start(ctx, "agentexec.collect_files")
+	collected, err := CollectFiles(args.Paths, parser, args.collectOptions(), WithLogger(logger), WithMaxFileSizeKB(args.MaxFileSizeKB), WithVerbose(args.Verbose))
+	collectSpan.SetAttributes(attribute.Int("agentexec.files.regular", len(collected.Regular)), attribute.Int("agentexec.files.binary", len(collected.Binary)))
+	endSpan(collectSpan, err)
 	if err != nil {
 		logger.Error("Failed to collect files", zap.Error(err))
-		return fmt.Errorf("failed to collect files: %w", err)
+		return result, fmt.Errorf("failed to collect files: %w", err)
+	}
+	if len(collected.Skipped) > 0 {
+		logger.Debug("Skipped files during collection", zap.Any("byReason", collected.Skipped))
+	}
+
+	collectedCount := len(collected.Regular) + len(collected.Binary)
+
+	// Restrict collection to the Go import graph of the requested package
+	if args.LimitToImports != "" {
+		graphFiles, err := GoImportGraphFiles(args.LimitToImports, logger)
+		if err != nil {
+			return result, fmt.Errorf("failed to resolve imports of %s: %w", args.LimitToImports, err)
+		}
+		collected.Regular = filterToFileSet(collected.Regular, graphFiles)
+		collected.Binary = nil // Binary files are never part of a Go import graph
+		logger.Debug("Limited files to Go import graph",
+			zap.String("package", args.LimitToImports),
+			zap.Int("remainingFiles", len(collected.Regular)))
+	}
+
+	// Keep only files whose content matches the requested regular expression
+	if args.FilterByRegex != "" {
+		re, err := regexp.Compile(args.FilterByRegex)
+		if err != nil {
+			logger.Error("Invalid content filter regex", zap.String("pattern", args.FilterByRegex), zap.Error(err))
+			return result, fmt.Errorf("invalid content filter regex: %w", err)
+		}
+		collected.Regular = FilterByContent(collected.Regular, re, args.NegateRegex, logger)
+		logger.Debug("Filtered files by content",
+			zap.String("pattern", args.FilterByRegex),
+			zap.Bool("negate", args.NegateRegex),
+			zap.Int("remainingFiles", len(collected.Regular)))
+	}
+
+	// Drop files consisting mostly of repeated lines, such as generated boilerplate
+	if args.MinUniqueLines > 0 {
+		collected.Regular = FilterByUniqueLines(collected.Regular, args.MinUniqueLines, logger)
+		logger.Debug("Filtered files by unique line ratio",
+			zap.Int("minUniqueLinePercent", args.MinUniqueLines),
+			zap.Int("remainingFiles", len(collected.Regular)))
+	}
+
+	// Drop Python __init__.py files that only re-export names from their submodules
+	if args.ExcludeInitOnly {
+		collected.Regular = FilterInitOnly(collected.Regular, logger)
+		logger.Debug("Filtered import-only __init__.py files", zap.Int("remainingFiles", len(collected.Regular)))
 	}
 
 	// Warn about binary files
-	if len(collected.Binary) > 0 {
+	if len(collected.Binary) > 0 && !args.includesBinary() {
 		logger.Warn("Detected binary files. These files are not included in the combined output.",
 			zap.Int("binaryFileCount", len(collected.Binary)),
 			zap.Strings("binaryFiles", collected.Binary))
 
-		shouldContinue, err := promptUser(fmt.Sprintf(
-			"Detected %d binary files. Do you want to continue and exclude these files? (y/n): ", len(collected.Binary)))
-		if err != nil {
-			logger.Error("Failed to read user input", zap.Error(err))
-			return fmt.Errorf("failed to read user input: %w", err)
-		}
+		if !args.NonInteractive && !args.DryRun {
+			shouldContinue, err := promptUser(fmt.Sprintf(
+				"Detected %d binary files. Do you want to continue and exclude these files? (y/n): ", len(collected.Binary)))
+			if err != nil {
+				logger.Error("Failed to read user input", zap.Error(err))
+				return result, fmt.Errorf("failed to read user input: %w", err)
+			}
 
-		if !shouldContinue {
-			logger.Info("User chose to abort the combine process due to detected binary files.")
-			return nil
+			if !shouldContinue {
+				logger.Info("User chose to abort the combine process due to detected binary files.")
+				result.ExitCode = ExitUserAbort
+				return result, nil
+			}
 		}
 	}
 
+	result.BinaryFiles = collected.Binary
+	remaining := len(collected.Regular)
+	if args.includesBinary() {
+		remaining += len(collected.Binary)
+	}
+	result.FilesSkipped = collectedCount - remaining
+
+	// Guard against ignore patterns or filters that are too aggressive or too lax
+	if args.RequireMinFiles > 0 && remaining < args.RequireMinFiles {
+		logger.Error("Too few files collected", zap.Int("files", remaining), zap.Int("requireMinFiles", args.RequireMinFiles))
+		return result, fmt.Errorf("collected %d files, fewer than the required minimum of %d", remaining, args.RequireMinFiles)
+	}
+	if args.RequireMaxFiles > 0 && remaining > args.RequireMaxFiles {
+		logger.Error("Too many files collected", zap.Int("files", remaining), zap.Int("requireMaxFiles", args.RequireMaxFiles))
+		return result, fmt.Errorf("collected %d files, more than the allowed maximum of %d", remaining, args.RequireMaxFiles)
+	}
+
 	// Warn if no files remain after filtering
-	if len(collected.Regular) == 0 {
+	if len(collected.Regular) == 0 && (!args.includesBinary() || len(collected.Binary) == 0) && stdinData == nil {
 		logger.Warn("No files to process after filtering.")
-		return nil
+		result.ExitCode = ExitNothingToCombine
+		return result, nil
 	}
 
-	// Process files concurrently
-	combinedContents, err := ProcessFilesConcurrently(collected.Regular, args.MaxWorkers, filepath.Dir(args.Paths[0]), logger)
+	// Fail before the expensive processing if the output would not fit on its filesystem
+	if args.Output != StdoutPath && !args.DryRun && args.Preview <= 0 {
+		inputs := collected.Regular
+		if args.includesBinary() {
+			inputs = append(slices.Clip(inputs), collected.Binary...)
+		}
+		if err := checkOutputDiskSpace(filepath.Dir(args.Output), inputs, logger); err != nil {
+			return result, err
+		}
+	}
+
+	// Source paths are relative to the first input's parent, or to the common root of all inputs
+	// when they are remapped under a virtual root
+	sourceRoot := filepath.Dir(args.Paths[0])
+	if args.VirtualRoot != "" {
+		if root := commonSourceRoot(args.Paths); root != "" {
+			sourceRoot = root
+		} else {
+			logger.Warn("Input paths share no common directory, virtual root paths are relative to the first input", zap.String("virtualRoot", args.VirtualRoot))
+		}
+		logger.Debug("Remapping source paths under virtual root", zap.String("virtualRoot", args.VirtualRoot), zap.String("sourceRoot", sourceRoot))
+	}
+
+	// Process files concurrently, dropping files not matching the grep pattern as they are read
+	processOpts := args.processOptions()
+	if args.GrepPattern != "" {
+		if processOpts.Grep, err = regexp.Compile(args.GrepPattern); err != nil {
+			logger.Error("Invalid grep pattern", zap.String("pattern", args.GrepPattern), zap.Error(err))
+			return result, fmt.Errorf("invalid grep pattern: %w", err)
+		}
+	}
+	if processOpts.Replacements, err = ParseContentReplacements(args.ContentReplacements); err != nil {
+		logger.Error("Invalid content replacement", zap.Strings("replacements", args.ContentReplacements), zap.Error(err))
+		return result, fmt.Errorf("invalid content replacement: %w", err)
+	}
+	if args.Incremental {
+		cacheFile := args.CacheFile
+		if cacheFile == "" {
+			cacheFile = DefaultCacheFile
+		}
+		processOpts.cache = loadContentCache(cacheFile, cacheSettings(sourceRoot, processOpts), logger)
+		if !args.DryRun {
+			// Only a successful run replaces the cache
+			defer func() {
+				if err != nil {
+					return
+				}
+				if saveErr := processOpts.cache.save(cacheFile, logger); saveErr != nil {
+					logger.Warn("Failed to save cache file", zap.String("cacheFile", cacheFile), zap.Error(saveErr))
+				}
+			}()
+		}
+	}
+
+	// Report each file as it is processed and summarize the run once it has succeeded
+	progress, closeProgress, err := args.progressReporter(len(collected.Regular))
+	if err != nil {
+		logger.Error("Failed to set up progress reporting", zap.String("progressFile", args.ProgressFile), zap.Error(err))
+		return result, err
+	}
+	defer func() {
+		if err == nil {
+			progress.OnSummary(collectedCount, result.FilesSkipped, len(collected.Binary))
+		}
+		if closeErr := closeProgress(); closeErr != nil {
+			logger.Warn("Failed to close progress file", zap.String("progressFile", args.ProgressFile), zap.Error(closeErr))
+		}
+	}()
+
+	// Write each file as a JSON line as soon as it is processed instead of buffering the output
+	if args.OutputJSONStream {
+		return executeJSONStream(ctx, args, parser, collected, sourceRoot, processOpts, stdinData, progress, result, logger)
+	}
+
+	// Resume an interrupted run and record progress until the output has been written
+	if args.Checkpoint != "" && !args.DryRun {
+		progress, openErr := openCheckpoint(args.Checkpoint, cacheSettings(sourceRoot, processOpts), logger)
+		if openErr != nil {
+			logger.Error("Failed to open checkpoint", zap.String("checkpoint", args.Checkpoint), zap.Error(openErr))
+			return result, fmt.Errorf("failed to open checkpoint: %w", openErr)
+		}
+		processOpts.checkpoint = progress
+		defer func() {
+			progress.close(err == nil, logger)
+		}()
+	}
+
+	processCtx, processSpan := tracer.Start(ctx, "agentexec.process_files")
+	combinedContents, failedFiles, err := ProcessFilesConcurrently(processCtx, collected.Regular, sourceRoot, processOpts, WithLogger(logger), WithMaxWorkers(args.MaxWorkers), WithMaxMemoryMB(args.MaxMemoryMB), WithMaxWorkersPerDir(args.MaxWorkersPerDir), WithFailFast(args.OnError == OnErrorFail), WithProgress(progress))
+	processSpan.SetAttributes(attribute.Int("agentexec.files.processed", len(combinedContents)), attribute.Int("agentexec.files.failed", failedFiles))
+	endSpan(processSpan, err)
 	if err != nil {
 		logger.Error("Failed to process files", zap.Error(err))
-		return fmt.Errorf("failed to process files: %w", err)
+		return result, fmt.Errorf("failed to process files: %w", err)
+	}
+
+	// Include binary files verbatim in ZIP output, or as base64-encoded content otherwise
+	excludedBinary := collected.Binary
+	var binaryContents []BinaryFile
+	if args.OutputFormat == FormatZip {
+		for _, binaryFile := range collected.Binary {
+			binary, err := ReadBinaryFile(ctx, binaryFile, sourceRoot, args.processOptions(), logger)
+			if err != nil && args.OnError == OnErrorFail {
+				return result, fmt.Errorf("failed to read binary file %s: %w", binaryFile, err)
+			}
+			if err != nil {
+				logger.Warn("Skipping binary file that could not be read", zap.String("filePath", binaryFile), zap.Error(err))
+				failedFiles++
+				continue
+			}
+			binaryContents = append(binaryContents, binary)
+		}
+		excludedBinary = nil
+	} else if args.Base64EncodeBinary {
+		for _, binaryFile := range collected.Binary {
+			encoded, err := EncodeBinaryFile(ctx, binaryFile, sourceRoot, args.processOptions(), logger)
+			if err != nil && args.OnError == OnErrorFail {
+				return result, fmt.Errorf("failed to encode binary file %s: %w", binaryFile, err)
+			}
+			if err != nil {
+				logger.Warn("Skipping binary file that could not be encoded", zap.String("filePath", binaryFile), zap.Error(err))
+				failedFiles++
+				continue
+			}
+			combinedContents = append(combinedContents, encoded)
+		}
+		excludedBinary = nil
+	}
+
+	if failedFiles > 0 {
+		logger.Warn("Some files could not be processed and are missing from the output", zap.Int("failedFiles", failedFiles))
+		result.ExitCode = ExitPartialSuccess
+	}
+
+	if args.VirtualRoot != "" {
+		applyVirtualRoot(combinedContents, args.VirtualRoot, args.processOptions())
+		for i := range binaryContents {
+			binaryContents[i].Path = normalizeSourcePath(filepath.Join(args.VirtualRoot, binaryContents[i].Path), args.PathNormalization)
+		}
 	}
 
 	// Sort files for consistent output
 	sort.Slice(combinedContents, func(i, j int) bool {
 		return combinedContents[i].Path < combinedContents[j].Path
 	})
+	sort.Slice(binaryContents, func(i, j int) bool {
+		return binaryContents[i].Path < binaryContents[j].Path
+	})
 	logger.Debug("Sorted processed files")
 
+	// Keep only the first of several files with identical content
+	if args.Deduplicate {
+		before := len(combinedContents)
+		combinedContents = deduplicateContents(combinedContents, logger)
+		logger.Debug("Removed files with duplicate content", zap.Int("duplicates", before-len(combinedContents)))
+	}
+
+	// Point copies of a file to the first one instead of repeating its content
+	if args.ContentHashDedup {
+		duplicates, saved := markDuplicateContents(combinedContents, processOpts, logger)
+		logger.Info("Replaced duplicate file contents with references", zap.Int("duplicates", duplicates), zap.Int64("bytesSaved", saved))
+	}
+
+	// Put shallow files such as READMEs and configuration first, keeping the path order within a depth
+	if args.Sort == SortDepth {
+		sort.SliceStable(combinedContents, func(i, j int) bool {
+			return pathDepth(combinedContents[i].Path) < pathDepth(combinedContents[j].Path)
+		})
+		sort.SliceStable(binaryContents, func(i, j int) bool {
+			return pathDepth(binaryContents[i].Path) < pathDepth(binaryContents[j].Path)
+		})
+		logger.Debug("Sorted processed files by depth")
+	}
+
+	// Group Go files by package, keeping the order above within a package
+	if args.GroupByPackage {
+		groupByGoPackage(combinedContents)
+		logger.Debug("Grouped processed files by Go package")
+	}
+
+	// Put the changelogs first, merged into one section
+	changelogFiles := 0
+	if args.CombineChangelog {
+		combinedContents, changelogFiles = combineChangelogs(combinedContents, processOpts, logger)
+	}
+
+	// Add standard input after the sorted files, unless it is prioritized
+	stdinFiles := 0
+	if stdinData != nil {
+		if stdinFile, ok := stdinContent(stdinData, args.StdinExtension, processOpts, logger); ok {
+			combinedContents = addStdinContent(combinedContents, stdinFile, args.Prioritize == PrioritizeStdin)
+			stdinFiles = 1
+		}
+	}
+
+	// Keep only the files changed since the previous combined output, noting the deleted ones
+	var deletedFiles []string
+	if args.DiffOutput != "" {
+		previous, err := loadPreviousCombined(args.DiffOutput)
+		if err != nil {
+			logger.Error("Failed to load previous combined file", zap.String("diffOutput", args.DiffOutput), zap.Error(err))
+			return result, err
+		}
+		combinedContents, deletedFiles = changedContents(combinedContents, previous)
+		logger.Debug("Compared files with previous combined output",
+			zap.String("diffOutput", args.DiffOutput),
+			zap.Int("changed", len(combinedContents)),
+			zap.Int("deleted", len(deletedFiles)))
+	}
+
+	// Stop adding files once the token budget would be exceeded
+	if args.MaxTokens > 0 {
+		combinedContents, _ = limitTokens(combinedContents, args.MaxTokens, logger)
+	}
+
+	// Render custom headers now that each file's position in the output is known
+	if args.HeaderTemplate != "" {
+		headerTemplate, err := ParseHeaderTemplate(args.HeaderTemplate)
+		if err != nil {
+			logger.Error("Invalid header template", zap.Error(err))
+			return result, fmt.Errorf("invalid header template: %w", err)
+		}
+		if err := applyHeaderTemplate(combinedContents, headerTemplate, args.processOptions()); err != nil {
+			logger.Error("Failed to render header template", zap.Error(err))
+			return result, err
+		}
+	}
+
+	// Summarize the run once every output has been written successfully
+	if args.Stats && !args.DryRun && args.Preview <= 0 {
+		defer func() {
+			if err != nil {
+				return
+			}
+			statsOutput := args.StatsOutput
+			if statsOutput == "" {
+				statsOutput = DefaultStatsOutput
+			}
+			stats := GenerateStats(combinedContents, excludedBinary)
+			stats.PermissionErrors = len(collected.PermissionDenied)
+			if statsErr := writeStatsFile(statsOutput, stats, args.ioProfile, logger); statsErr != nil {
+				logger.Warn("Failed to write stats", zap.String("statsFile", statsOutput), zap.Error(statsErr))
+			}
+		}()
+	}
+
+	result.FilesIncluded = len(combinedContents) + len(binaryContents) + max(changelogFiles-1, 0)
+	result.FilesSkipped = collectedCount - (result.FilesIncluded - stdinFiles)
+	for _, content := range combinedContents {
+		result.TotalBytes += int64(len(content.Content))
+	}
+
 	// Generate tree structure
-	treeContent, err := GenerateFullTree(args.Paths, gi, logger)
+	treeOpts := args.treeOptions()
+	if args.TreeCounts {
+		treeOpts.IncludedFiles = make(map[string]struct{}, remaining)
+		for _, file := range collected.Regular {
+			treeOpts.IncludedFiles[file] = struct{}{}
+		}
+		if args.Base64EncodeBinary {
+			for _, file := range collected.Binary {
+				treeOpts.IncludedFiles[file] = struct{}{}
+			}
+		}
+	}
+	treeContent, err := GenerateFullTree(args.Paths, parser, treeOpts, WithLogger(logger))
 	if err != nil {
 		logger.Error("Failed to generate tree structure", zap.Error(err))
-		return fmt.Errorf("failed to generate tree structure: %w", err)
+		return result, fmt.Errorf("failed to generate tree structure: %w", err)
 	}
 
+	// Report ignore pattern statistics gathered during collection and tree generation
+	if args.ProfilePatterns {
+		fmt.Fprintln(os.Stderr, "Ignore pattern profile:")
+		if err := gi.WritePatternProfile(os.Stderr); err != nil {
+			logger.Warn("Failed to write pattern profile", zap.Error(err))
+		}
+	}
+
+	if args.ReportSkippedPatterns {
+		if err := gi.WriteUnmatchedPatterns(os.Stderr); err != nil {
+			logger.Warn("Failed to report unmatched patterns", zap.Error(err))
+		}
+	}
+
+	// Render the tree file in its own format; the combined output always embeds the text tree
+	treeFileContent, err := renderTreeFile(args, parser, treeContent, logger)
+	if err != nil {
+		return result, err
+	}
+
+	// List the files deleted since the previous combined output after the tree
+	treeContent += deletedFilesNote(deletedFiles)
+
+	// In preview mode, print the beginning of the output instead of writing any files
+	if args.Preview > 0 {
+		return result, WritePreview(os.Stdout, args.Preview, args.OutputFormat, outputTemplate, treeContent, combinedContents, args.CountTokensPerFile, logger)
+	}
+
+	// In dry-run mode, list what would be combined instead of writing any files
+	if args.DryRun {
+		if err := printDryRunSummary(os.Stdout, combinedContents, excludedBinary, combinedTextSize(treeContent, combinedContents)); err != nil {
+			return result, fmt.Errorf("failed to print dry-run summary: %w", err)
+		}
+		return result, nil
+	}
+
+	// Trace writing the outputs, whichever form they take, until the run ends
+	_, writeSpan := tracer.Start(ctx, "agentexec.write_output")
+	defer func() {
+		endSpan(writeSpan, err)
+	}()
+
 	// Write tree structure to file
-	if err := writeToFile(args.Tree, []byte(treeContent), 0644, logger); err != nil {
-		return fmt.Errorf("failed to write tree structure: %w", err)
+	err = writeOutput(args.Tree, args.ioProfile, logger, func(w io.Writer) error {
+		return writeToFile(w, args.Tree, []byte(treeFileContent), logger)
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to write tree structure: %w", err)
 	}
+	result.TreePath = args.Tree
 
-	// Write combined contents to output file
-	if err := WriteCombinedFile(args.Output, treeContent, combinedContents, logger); err != nil {
+	// Write an archive of the processed files instead of a combined text file
+	if args.CombineIntoArchive != "" {
+		if err := ensureDirectory(filepath.Dir(args.CombineIntoArchive), logger); err != nil {
+			return result, fmt.Errorf("failed to create archive directory: %w", err)
+		}
+		if err := WriteArchive(args.CombineIntoArchive, treeContent, combinedContents, logger); err != nil {
+			return result, fmt.Errorf("failed to write archive: %w", err)
+		}
+		result.OutputPath = args.CombineIntoArchive
+		logger.Info("Successfully archived files",
+			zap.String("archive", args.CombineIntoArchive),
+			zap.Int("totalFiles", len(combinedContents)),
+		)
+		return result, nil
+	}
+
+	// Write one combined file per extension into the output directory
+	if args.OutputPerExtension {
+		if err := ensureDirectory(args.Output, logger); err != nil {
+			return result, fmt.Errorf("failed to create output directory: %w", err)
+		}
+		for ext, group := range GroupByExtension(combinedContents) {
+			paths := make([]string, 0, len(group))
+			for _, content := range group {
+				paths = append(paths, content.Path)
+			}
+			groupPath := filepath.Join(args.Output, perExtensionOutputName(ext))
+			err := writeOutput(groupPath, args.ioProfile, logger, func(w io.Writer) error {
+				return WriteCombinedFile(w, GeneratePathTree(paths, args.treeOptions()), group, WithLogger(logger))
+			})
+			if err != nil {
+				return result, fmt.Errorf("failed to write combined file for %q files: %w", ext, err)
+			}
+			logger.Debug("Wrote per-extension combined file", zap.String("file", groupPath), zap.Int("files", len(group)))
+		}
+		result.OutputPath = args.Output
+		logger.Info("Successfully combined files per extension",
+			zap.String("outputDir", args.Output),
+			zap.Int("totalFiles", len(combinedContents)),
+		)
+		return result, nil
+	}
+
+	// Start a new output file at every file matching the split pattern
+	if args.SplitOnPattern != "" {
+		shards := SplitByPattern(combinedContents, args.SplitOnPattern)
+		for i, shard := range shards {
+			paths := make([]string, 0, len(shard))
+			for _, content := range shard {
+				paths = append(paths, content.Path)
+			}
+			// Without per-shard trees, only the first shard starts with the full tree
+			shardTree := GeneratePathTree(paths, args.treeOptions())
+			if !args.OutputSplitTree {
+				shardTree = ""
+				if i == 0 {
+					shardTree = treeContent
+				}
+			}
+			shardPath := shardOutputName(args.Output, i+1)
+			err := writeOutput(shardPath, args.ioProfile, logger, func(w io.Writer) error {
+				return writeCombinedOutput(w, args, outputTemplate, shardTree, shard, nil, logger)
+			})
+			if err != nil {
+				return result, fmt.Errorf("failed to write combined file shard %d: %w", i+1, err)
+			}
+			logger.Debug("Wrote combined file shard", zap.String("file", shardPath), zap.Int("files", len(shard)))
+		}
+		result.OutputPath = args.Output
+		logger.Info("Successfully combined files into shards",
+			zap.String("outputFile", args.Output),
+			zap.Int("shards", len(shards)),
+			zap.Int("totalFiles", len(combinedContents)),
+		)
+		return result, nil
+	}
+
+	// Split the output into chunks that each stay within the byte limit, including the tree
+	if args.SplitBytes > 0 {
+		limit := args.SplitBytes - len(treeContent)
+		if limit <= 0 {
+			logger.Warn("Tree alone exceeds the split size, writing one file per chunk", zap.Int("treeBytes", len(treeContent)), zap.Int("splitBytes", args.SplitBytes))
+			limit = 1
+		}
+		chunks := SplitCombinedOutput(combinedContents, limit, logger)
+		for i, chunk := range chunks {
+			chunkPath := chunkOutputName(args.Output, i+1)
+			err := writeOutput(chunkPath, args.ioProfile, logger, func(w io.Writer) error {
+				return writeCombinedOutput(w, args, outputTemplate, treeContent, chunk, nil, logger)
+			})
+			if err != nil {
+				return result, fmt.Errorf("failed to write combined file chunk %d: %w", i+1, err)
+			}
+			logger.Debug("Wrote combined file chunk", zap.String("file", chunkPath), zap.Int("files", len(chunk)))
+		}
+		result.OutputPath = args.Output
+		logger.Info("Successfully combined files into chunks",
+			zap.String("outputFile", args.Output),
+			zap.Int("chunks", len(chunks)),
+			zap.Int("totalFiles", len(combinedContents)),
+		)
+		return result, nil
+	}
+
+	// With --write-if-changed or append mode, write to a temporary file next to the output
+	// and compare or append it afterwards
+	writePath := args.Output
+	if (args.WriteIfChanged || args.OutputMode == OutputModeAppend) && args.Output != StdoutPath {
+		tmpFile, err := os.CreateTemp(filepath.Dir(args.Output), filepath.Base(args.Output)+".*.tmp")
+		if err != nil {
+			return result, fmt.Errorf("failed to create temporary output file: %w", err)
+		}
+		writePath = tmpFile.Name()
+		if err := tmpFile.Close(); err != nil {
+			return result, fmt.Errorf("failed to close temporary output file: %w", err)
+		}
+		defer os.Remove(writePath) // No-op once the file has been renamed or removed
+	}
+
+	// Write combined contents to output file in the requested format
+	err = writeOutput(writePath, args.ioProfile, logger, func(w io.Writer) error {
+		return writeCombinedOutput(w, args, outputTemplate, treeContent, combinedContents, binaryContents, logger)
+	})
+	if err != nil {
 		logger.Error("Failed to write combined file", zap.String("combinedFile", args.Output), zap.Error(err))
-		return fmt.Errorf("failed to write combined file: %w", err)
+		return result, fmt.Errorf("failed to write combined file: %w", err)
+	}
+
+	result.OutputPath = args.Output
+
+	if args.WriteIfChanged && writePath != args.Output {
+		changed, err := replaceIfChanged(writePath, args.Output, args.HashAlgorithm, logger)
+		if err != nil {
+			logger.Error("Failed to update combined file", zap.String("combinedFile", args.Output), zap.Error(err))
+			return result, fmt.Errorf("failed to update combined file: %w", err)
+		}
+		if !changed {
+			logger.Info("Combined output unchanged, existing file left untouched", zap.String("outputFile", args.Output))
+			return result, nil
+		}
+	}
+
+	if args.OutputMode == OutputModeAppend && writePath != args.Output {
+		if err := appendFile(writePath, args.Output, logger); err != nil {
+			logger.Error("Failed to append to combined file", zap.String("combinedFile", args.Output), zap.Error(err))
+			return result, fmt.Errorf("failed to append to combined file: %w", err)
+		}
+	}
+
+	if args.TokenCount {
+		if err := writeTokenSummary(os.Stderr, combinedContents); err != nil {
+			logger.Warn("Failed to write token summary", zap.Error(err))
+		}
+	}
+
+	if args.GitHubAction {
+		if err := writeGitHubStepSummary(args.Output, combinedContents, excludedBinary, logger); err != nil {
+			logger.Warn("Failed to write GitHub step summary", zap.Error(err))
+		}
 	}
 
 	logger.Info("Successfully combined files",
 		zap.String("outputFile", args.Output),
 		zap.Int("totalFiles", len(combinedContents)),
 	)
-	return nil
+	return result, nil
+}
+
+// loadIgnorePatterns loads the `.combineignore` files (local and global) and adds the VCS,
+// JavaScript artifact, and command-line patterns selected by args.
+func loadIgnorePatterns(args Arguments, logger *zap.Logger) (*CombineIgnore, error) {
+	// Load ignore patterns from `.combineignore` files (local and global)
+	globalIgnorePaths := args.GlobalIgnoreFiles
+	if len(globalIgnorePaths) == 0 {
+		globalIgnorePaths = []string{os.Getenv("COMBINEIGNORE_GLOBAL")} // Optional environment variable for global ignore file
+	}
+
+	gi, err := LoadIgnoreFiles(globalIgnorePaths, logger)
+	if err != nil {
+		logger.Error("Failed to load ignore patterns", zap.Error(err))
+		return nil, fmt.Errorf("failed to load ignore patterns: %w", err)
+	}
+	logger.Debug("Loaded ignore patterns", zap.Int("totalPatterns", len(gi.patterns)))
+
+	// Ignore version control metadata directories and JavaScript artifacts ahead of command-line patterns
+	if args.IgnoreVCS {
+		gi.CompileIgnoreLines(VCSDirectoryPatterns...)
+		logger.Debug("Added VCS directory ignore patterns", zap.Strings("patterns", VCSDirectoryPatterns))
+	}
+	if args.ExcludeJSArtifacts {
+		gi.CompileIgnoreLines(JSArtifactPatterns...)
+		logger.Debug("Added JavaScript artifact ignore patterns", zap.Strings("patterns", JSArtifactPatterns))
+	}
+
+	// Add command-line ignore patterns to the ignore parser
+	if len(args.IgnorePatterns) > 0 {
+		syntax := args.IgnoreSyntax
+		if syntax == "" {
+			syntax = SyntaxGitignore
+		}
+		if args.ErrorOnEmptyPattern {
+			gi.RejectEmptyPatterns()
+		}
+		if errs := gi.CompileIgnoreLinesWithSyntax(syntax, args.IgnorePatterns...); len(errs) > 0 {
+			joined := make([]error, len(errs))
+			for i, err := range errs {
+				logger.Error("Invalid command-line ignore pattern", zap.Error(err))
+				joined[i] = err
+			}
+			return nil, fmt.Errorf("invalid ignore pattern: %w", errors.Join(joined...))
+		}
+		logger.Debug("Added command-line ignore patterns", zap.Int("count", len(args.IgnorePatterns)), zap.String("syntax", syntax))
+	}
+
+	return gi, nil
+}
+
+// renderTreeFile returns the content of the tree file in args.TreeFormat, which is treeContent
+// itself for the text format.
+func renderTreeFile(args Arguments, parser IgnoreParser, treeContent string, logger *zap.Logger) (string, error) {
+	var err error
+	treeFileContent := treeContent
+	switch args.TreeFormat {
+	case TreeFormatJSON:
+		treeFileContent, err = GenerateTreeJSON(args.Paths, parser, args.treeOptions(), logger)
+	case TreeFormatXML:
+		treeFileContent, err = GenerateTreeXML(args.Paths, parser, args.treeOptions(), logger)
+	}
+	if err != nil {
+		logger.Error("Failed to generate structured tree", zap.String("treeFormat", args.TreeFormat), zap.Error(err))
+		return "", fmt.Errorf("failed to generate %s tree structure: %w", args.TreeFormat, err)
+	}
+	return treeFileContent, nil
+}
+
+// writeCombinedOutput writes the tree and file contents to w using the template, if any, or the
+// built-in writer for the output format.
+func writeCombinedOutput(w io.Writer, args Arguments, tmpl *template.Template, treeContent string, combinedContents []FileContent, binaryContents []BinaryFile, logger *zap.Logger) error {
+	// Declare the encoding at the very start of built-in text output for tools relying on it
+	if declaration := encodingDeclaration(args.EncodingDeclaration); declaration != "" && tmpl == nil && (args.OutputFormat == FormatText || args.OutputFormat == "") {
+		if _, err := io.WriteString(w, declaration); err != nil {
+			logger.Error("Failed to write encoding declaration", zap.Error(err))
+			return fmt.Errorf("failed to write combined file: %w", err)
+		}
+	}
+
+	switch {
+	case args.OutputFormat == FormatZip:
+		return WriteZipOutput(w, treeContent, combinedContents, binaryContents, logger)
+	case tmpl != nil:
+		return WriteTemplatedFile(w, tmpl, treeContent, combinedContents, logger)
+	case args.WrapInXMLTags != "":
+		return WriteXMLTaggedFile(w, args.WrapInXMLTags, treeContent, combinedContents, WithLogger(logger))
+	case args.OutputFormat == FormatJSON:
+		return WriteCombinedJSON(w, treeContent, combinedContents, args.CountTokensPerFile, logger)
+	case args.OutputFormat == FormatYAML:
+		return WriteCombinedYAML(w, treeContent, combinedContents, args.CountTokensPerFile, logger)
+	default:
+		if args.IncludeFileCount && !args.NoHeader {
+			if _, err := io.WriteString(w, fileCountHeader(combinedContents, time.Now())); err != nil {
+				logger.Error("Failed to write file count header", zap.Error(err))
+				return fmt.Errorf("failed to write combined file: %w", err)
+			}
+		}
+		if args.Progress {
+			return WriteCombinedFileWithProgress(w, treeContent, combinedContents, textWriteProgress(os.Stderr), WithLogger(logger))
+		}
+		return WriteCombinedFile(w, treeContent, combinedContents, WithLogger(logger))
+	}
+}
+
+// encodingDeclaration returns the line declaring UTF-8 in the given EncodingDeclaration style,
+// or "" for EncodingDeclarationNone and unknown styles.
+func encodingDeclaration(style string) string {
+	switch style {
+	case EncodingDeclarationPython:
+		return "# -*- coding: utf-8 -*-\n"
+	case EncodingDeclarationXML:
+		return `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+	}
+	return ""
 }
 
 // ensureDirectory ensures a directory exists, creating it if necessary.
@@ -123,9 +866,45 @@ func ensureDirectory(path string, logger *zap.Logger) error {
 	return nil
 }
 
-// writeToFile writes data to a file and logs the operation.
-func writeToFile(path string, data []byte, perm os.FileMode, logger *zap.Logger) error {
-	if err := os.WriteFile(path, data, perm); err != nil {
+// openOutput creates or truncates the file at path for writing. StdoutPath selects standard output,
+// which is left open when the returned writer is closed.
+func openOutput(path string) (io.WriteCloser, error) {
+	if path == StdoutPath {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
+
+// nopWriteCloser adapts a writer that must not be closed to io.WriteCloser.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close does nothing.
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// writeOutput opens path with openOutput, passes the writer to write, and closes it again.
+// Writes are recorded in profile if it is non-nil.
+func writeOutput(path string, profile *ioProfile, logger *zap.Logger, write func(io.Writer) error) (err error) {
+	w, err := openOutput(path)
+	if err != nil {
+		logger.Error("Failed to create output file", zap.String("file", path), zap.Error(err))
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer func() {
+		if closeErr := w.Close(); closeErr != nil && err == nil {
+			logger.Error("Failed to close output file", zap.String("file", path), zap.Error(closeErr))
+			err = fmt.Errorf("failed to close output file: %w", closeErr)
+		}
+	}()
+	return write(profile.writer(w))
+}
+
+// writeToFile writes data to w, which was opened for path, and logs the operation.
+func writeToFile(w io.Writer, path string, data []byte, logger *zap.Logger) error {
+	if _, err := w.Write(data); err != nil {
 		logger.Error("Failed to write file", zap.String("path", path), zap.Error(err))
 		return err
 	}
@@ -1,20 +1,145 @@
 package combine
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
+	"text/template"
+	"time"
+	"unicode/utf8"
 
 	"go.uber.org/zap"
 )
 
+// utf8BOM is the UTF-8 encoded byte order mark.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// errGrepMismatch is returned by ProcessSingleFile for files whose content does not match opts.Grep.
+var errGrepMismatch = errors.New("content does not match grep pattern")
+
+// ErrInvalidEncoding is returned by ProcessSingleFile for files that are not valid UTF-8 when
+// opts.RequireUTF8 is set.
+var ErrInvalidEncoding = errors.New("content is not valid UTF-8")
+
 // ProcessSingleFile reads and formats the content of a single file.
-func ProcessSingleFile(filePath, parentDir string, logger *zap.Logger) (FileContent, error) {
+// Reads failing with a transient error are retried according to opts until ctx is cancelled.
+func ProcessSingleFile(ctx context.Context, filePath, parentDir string, opts ProcessOptions, logger *zap.Logger) (FileContent, error) {
 	logger.Debug("Processing file",
 		zap.String("filePath", filePath),
 		zap.String("parentDir", parentDir))
 
+	relativePath := opts.sourcePath(filePath, parentDir, logger)
+	header := opts.sectionHeader(relativePath, opts.commentPrefix(filePath))
+
+	logger.Debug("Reading file content", zap.String("filePath", filePath))
+
+	// Read file content
+	readStart := time.Now()
+	fileBytes, readErr := readFileWithRetry(ctx, filePath, opts.ReadRetries, opts.ReadRetryDelay, opts.ChunkSize, logger)
+	opts.ioProfile.observe(ioRead, readStart)
+	if readErr != nil {
+		logger.Error("Failed to read file",
+			zap.String("filePath", filePath),
+			zap.Error(readErr))
+		return FileContent{}, fmt.Errorf("error reading file %s: %w", filePath, readErr)
+	}
+
+	logger.Debug("Successfully read file content",
+		zap.String("filePath", filePath),
+		zap.Int("contentSizeBytes", len(fileBytes)))
+
+	// Reject files in another encoding rather than writing garbled text
+	if opts.RequireUTF8 && !utf8.Valid(fileBytes) {
+		logger.Error("File is not valid UTF-8", zap.String("filePath", filePath))
+		return FileContent{}, fmt.Errorf("%w: %s", ErrInvalidEncoding, filePath)
+	}
+
+	// Drop files not matching the grep pattern now that their content has been read anyway
+	if opts.Grep != nil && !opts.Grep.Match(fileBytes) {
+		return FileContent{}, errGrepMismatch
+	}
+
+	// Report secrets before they end up in output shared with others
+	if err := opts.checkSecrets(filePath, fileBytes, logger); err != nil {
+		return FileContent{}, err
+	}
+
+	// Prepend the file's recent git history as a comment block
+	var history string
+	if opts.IncludeGitLog > 0 {
+		entries, err := GetGitLog(ctx, filePath, opts.IncludeGitLog)
+		if err != nil {
+			logger.Debug("Failed to read git log, omitting history", zap.String("filePath", filePath), zap.Error(err))
+		} else {
+			history = gitLogComment(entries)
+		}
+	}
+
+	return opts.fileContent(relativePath, filePath, header, history, fileBytes, logger), nil
+}
+
+// fileContent formats fileBytes, read from absPath, as the FileContent written under relativePath
+// with the given header, preceded by history.
+func (o ProcessOptions) fileContent(relativePath, absPath, header, history string, fileBytes []byte, logger *zap.Logger) FileContent {
+	rawBytes := fileBytes
+
+	// Hash the bytes already in memory instead of reading the file a second time
+	var digest string
+	if o.ComputeHash {
+		sum := sha256.Sum256(fileBytes)
+		digest = hex.EncodeToString(sum[:])
+	}
+
+	// Strip the UTF-8 byte order mark so it does not appear as a stray character
+	if bytes.HasPrefix(fileBytes, utf8BOM) {
+		fileBytes = fileBytes[len(utf8BOM):]
+		logger.Debug("Stripped UTF-8 byte order mark", zap.String("filePath", absPath))
+	}
+
+	content := string(fileBytes)
+	if o.StripDocComments {
+		content = StripDocComments(absPath, content)
+	}
+	content = ReplaceContent(content, o.Replacements)
+	if o.TrimTrailingWhitespace {
+		content = TrimTrailingWhitespace(content)
+	}
+	if o.LineNumbers {
+		content = NumberLines(content)
+	}
+	content = history + content
+
+	// Count tokens in the worker so that large runs are not serialized on it
+	var tokens int
+	if o.CountTokens {
+		tokens = CountTokens(content)
+	}
+
+	// Return the processed file content
+	return FileContent{
+		Path:    relativePath,
+		AbsPath: absPath,
+		Size:    int64(len(rawBytes)),
+		Header:  header,
+		Content: content,
+		SHA256:  digest,
+		Tokens:  tokens,
+	}
+}
+
+// sourceRelativePath returns the path of filePath relative to parentDir,
+// falling back to filePath itself if no relative path can be determined.
+func sourceRelativePath(filePath, parentDir string, logger *zap.Logger) string {
 	// Ensure parentDir is an absolute path
 	absParentDir, err := filepath.Abs(parentDir)
 	if err != nil {
@@ -33,30 +158,211 @@ func ProcessSingleFile(filePath, parentDir string, logger *zap.Logger) (FileCont
 			zap.Error(relErr))
 		relativePath = filePath // Fallback to absolute path
 	}
-	relativePath = normalizePath(relativePath)
+	return relativePath
+}
 
-	// Construct the header for the file
-	separatorLine := "# " + strings.Repeat("-", 78)
-	header := fmt.Sprintf("\n\n%s\n# Source: %s #\n\n", separatorLine, relativePath)
+// sourcePath returns the path of filePath relative to parentDir as written in the output,
+// normalized and, if enabled, sanitized according to o.
+func (o ProcessOptions) sourcePath(filePath, parentDir string, logger *zap.Logger) string {
+	return normalizeSourcePath(o.Sanitizer.Sanitize(sourceRelativePath(filePath, parentDir, logger)), o.PathNormalization)
+}
 
-	logger.Debug("Reading file content", zap.String("filePath", filePath))
+// normalizeSourcePath formats a source path for use in headers and output according to mode.
+func normalizeSourcePath(path, mode string) string {
+	switch mode {
+	case PathNormalizationNone:
+		return path
+	case PathNormalizationOS:
+		return filepath.FromSlash(normalizePath(path))
+	default:
+		return normalizePath(path)
+	}
+}
 
-	// Read file content
-	fileBytes, readErr := os.ReadFile(filePath)
-	if readErr != nil {
-		logger.Error("Failed to read file",
+// commonSourceRoot returns the deepest directory containing the parent directories of all paths.
+// Inputs on disjoint trees, such as /mnt/a/src and /mnt/b/lib, share /mnt; on Windows paths on
+// different volumes share no directory and the empty string is returned.
+func commonSourceRoot(paths []string) string {
+	var root string
+	for i, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			absPath = path
+		}
+		dir := filepath.Dir(absPath)
+		if i == 0 {
+			root = dir
+			continue
+		}
+		for !isWithinDir(dir, root) {
+			parent := filepath.Dir(root)
+			if parent == root {
+				return ""
+			}
+			root = parent
+		}
+	}
+	return root
+}
+
+// applyVirtualRoot rewrites the paths and headers of contents to sit under the virtual root name.
+func applyVirtualRoot(contents []FileContent, virtualRoot string, opts ProcessOptions) {
+	for i := range contents {
+		contents[i].Path = normalizeSourcePath(filepath.Join(virtualRoot, contents[i].Path), opts.PathNormalization)
+		contents[i].Header = opts.sectionHeader(contents[i].Path, opts.commentPrefix(contents[i].Path))
+	}
+}
+
+// sectionHeader returns the separator header written before a file's content in the combined output,
+// using commentPrefix to start the separator and Source lines and padding them with blank lines
+// as configured in o.
+func (o ProcessOptions) sectionHeader(relativePath, commentPrefix string) string {
+	separatorLine := commentPrefix + " " + strings.Repeat("-", max(78-(len(commentPrefix)-1), 1))
+	return fmt.Sprintf("%s%s\n%s Source: %s %s\n%s",
+		strings.Repeat("\n", o.SectionPaddingBefore), separatorLine, commentPrefix, relativePath, commentPrefix,
+		strings.Repeat("\n", o.SectionPaddingAfter))
+}
+
+// HeaderData is the value passed to a custom header template for each file.
+type HeaderData struct {
+	Path          string // Source path as written in the output.
+	AbsPath       string // Absolute path the file was read from.
+	SizeBytes     int64  // Size of the file on disk in bytes.
+	Extension     string // File extension including the dot, e.g. ".go"; empty if there is none.
+	Index         int    // 1-based position of the file in the sorted output.
+	CommentPrefix string // Comment prefix configured for the file's extension.
+}
+
+// ParseHeaderTemplate parses a custom section header template.
+func ParseHeaderTemplate(text string) (*template.Template, error) {
+	return template.New("header").Parse(text)
+}
+
+// applyHeaderTemplate replaces the header of each of the sorted contents with tmpl rendered
+// for that file.
+func applyHeaderTemplate(contents []FileContent, tmpl *template.Template, opts ProcessOptions) error {
+	var header strings.Builder
+	for i := range contents {
+		header.Reset()
+		data := HeaderData{
+			Path:          contents[i].Path,
+			AbsPath:       opts.Sanitizer.Sanitize(contents[i].AbsPath),
+			SizeBytes:     contents[i].Size,
+			Extension:     filepath.Ext(contents[i].Path),
+			Index:         i + 1,
+			CommentPrefix: opts.commentPrefix(contents[i].Path),
+		}
+		if err := tmpl.Execute(&header, data); err != nil {
+			return fmt.Errorf("failed to render header for %s: %w", contents[i].Path, err)
+		}
+		contents[i].Header = header.String()
+	}
+	return nil
+}
+
+// TrimTrailingWhitespace strips trailing spaces and tabs from every line of content.
+// CRLF line endings are preserved.
+func TrimTrailingWhitespace(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		crlf := strings.HasSuffix(line, "\r")
+		line = strings.TrimRight(line, " \t\r")
+		if crlf {
+			line += "\r"
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// NumberLines prefixes every line of content with its 1-based line number, right-justified
+// to the width of the highest line number, e.g. " 9 | " and "10 | ". A final newline does
+// not start another numbered line.
+func NumberLines(content string) string {
+	if content == "" {
+		return content
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	width := len(strconv.Itoa(len(lines)))
+
+	var b strings.Builder
+	b.Grow(len(content) + len(lines)*(width+3))
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%*d | %s", width, i+1, line)
+	}
+	if strings.HasSuffix(content, "\n") {
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// readFileWithRetry reads a file, retrying up to retries times with delay between attempts
+// when the read fails with a transient error. Other errors are returned immediately.
+func readFileWithRetry(ctx context.Context, filePath string, retries int, delay time.Duration, chunkSize int, logger *zap.Logger) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		fileBytes, err := readFile(filePath, chunkSize)
+		if err == nil {
+			return fileBytes, nil
+		}
+		if attempt >= retries || !isTransientReadError(err) {
+			return nil, err
+		}
+
+		logger.Debug("Transient error reading file, retrying",
 			zap.String("filePath", filePath),
-			zap.Error(readErr))
-		return FileContent{}, fmt.Errorf("error reading file %s: %w", filePath, readErr)
+			zap.Int("attempt", attempt+1),
+			zap.Int("maxRetries", retries),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
 	}
+}
 
-	logger.Debug("Successfully read file content",
-		zap.String("filePath", filePath),
-		zap.Int("contentSizeBytes", len(fileBytes)))
+// readFile reads the whole file at filePath. Files larger than streamingReadThreshold are
+// streamed through a reader of chunkSize bytes into a buffer sized from the file's length,
+// avoiding repeated reallocation; smaller files are read with os.ReadFile. On Windows, paths
+// longer than MAX_PATH are read through the long path API.
+func readFile(filePath string, chunkSize int) ([]byte, error) {
+	filePath = makeWindowsLongPath(filePath)
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() <= streamingReadThreshold {
+		return os.ReadFile(filePath)
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
 
-	// Return the processed file content
-	return FileContent{
-		Path:    relativePath,
-		Content: header + string(fileBytes),
-	}, nil
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var buf bytes.Buffer
+	buf.Grow(int(info.Size()) + bytes.MinRead)
+	reader := bufio.NewReaderSize(file, chunkSize)
+	if _, err := io.CopyBuffer(&buf, reader, make([]byte, chunkSize)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// isTransientReadError reports whether a read error is worth retrying.
+func isTransientReadError(err error) bool {
+	return errors.Is(err, syscall.EINTR) ||
+		errors.Is(err, syscall.EAGAIN) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
 }
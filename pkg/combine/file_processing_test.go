@@ -0,0 +1,81 @@
+// File: pkg/combine/file_processing_test.go
+package combine_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"agentexec/pkg/combine"
+)
+
+// TestHeaderTemplate checks that a custom header template replaces the built-in header of each
+// file in the output.
+func TestHeaderTemplate(t *testing.T) {
+	args := testArguments(t, fstest.MapFS{
+		"src/b.py":     text("print('b')\n"),
+		"src/a.go":     text("package a\n"),
+		"src/Makefile": text("all:\n"),
+	})
+	args.HeaderTemplate = "=== {{.Index}}: {{.Path}} ({{.SizeBytes}} bytes, ext {{printf \"%q\" .Extension}}) from {{.AbsPath}} ===\n"
+	runCombine(t, args)
+
+	src := args.Paths[0]
+	want := "=== 1: src/Makefile (5 bytes, ext \"\") from " + filepath.Join(src, "Makefile") + " ===\nall:\n" +
+		"=== 2: src/a.go (10 bytes, ext \".go\") from " + filepath.Join(src, "a.go") + " ===\npackage a\n" +
+		"=== 3: src/b.py (11 bytes, ext \".py\") from " + filepath.Join(src, "b.py") + " ===\nprint('b')\n"
+	output := readFile(t, args.Output)
+	if !strings.HasSuffix(output, want) {
+		t.Errorf("output = %q, want it to end with %q", output, want)
+	}
+	if strings.Contains(output, "# Source:") {
+		t.Errorf("output = %q, want no built-in headers", output)
+	}
+}
+
+func TestNumberLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "empty", content: "", want: ""},
+		{name: "three lines", content: "a\nb\nc\n", want: "1 | a\n2 | b\n3 | c\n"},
+		{name: "no final newline", content: "a\nb", want: "1 | a\n2 | b"},
+		{name: "blank lines", content: "\n\nx\n", want: "1 | \n2 | \n3 | x\n"},
+		{name: "ten lines", content: strings.Repeat("x\n", 10), want: " 1 | x\n 2 | x\n 3 | x\n 4 | x\n 5 | x\n 6 | x\n 7 | x\n 8 | x\n 9 | x\n10 | x\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := combine.NumberLines(tt.content); got != tt.want {
+				t.Errorf("NumberLines(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLineNumbers checks that line numbers are padded to the line count of each file rather than
+// of the longest file, and that headers are not numbered.
+func TestLineNumbers(t *testing.T) {
+	args := testArguments(t, fstest.MapFS{
+		"src/short.go": text("package short\n\nfunc A() {}\n"),
+		"src/long.txt": text(strings.Repeat("line\n", 10)),
+	})
+	args.LineNumbers = true
+	runCombine(t, args)
+
+	files, err := combine.ParseCombinedFile(strings.NewReader(readFile(t, args.Output)))
+	if err != nil {
+		t.Fatalf("ParseCombinedFile() = %v", err)
+	}
+	if got, want := files["src/short.go"], "1 | package short\n2 | \n3 | func A() {}\n"; got != want {
+		t.Errorf("short.go = %q, want %q", got, want)
+	}
+	long := files["src/long.txt"]
+	for _, want := range []string{" 1 | line\n", " 9 | line\n", "10 | line\n"} {
+		if !strings.Contains(long, want) {
+			t.Errorf("long.txt = %q, want it to contain %q", long, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// File: pkg/combine/fileid.go
+package combine
+
+import (
+	"io/fs"
+	"sync"
+)
+
+// fileID identifies a file independently of the path it is reached through.
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// visitedFiles records the identity of collected files so that hard links and symlinks
+// to an already collected file are skipped.
+type visitedFiles struct {
+	mu    sync.Mutex
+	paths map[fileID]string
+}
+
+// newVisitedFiles creates an empty visitedFiles set.
+func newVisitedFiles() *visitedFiles {
+	return &visitedFiles{paths: make(map[fileID]string)}
+}
+
+// visit records the file described by info under path. If the same file was already
+// recorded, it returns the path it was first seen at and false.
+func (v *visitedFiles) visit(path string, info fs.FileInfo) (string, bool) {
+	id, ok := fileIdentity(info)
+	if !ok {
+		return "", true
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if first, seen := v.paths[id]; seen {
+		return first, false
+	}
+	v.paths[id] = path
+	return "", true
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+// File: pkg/combine/fileid_other.go
+package combine
+
+import "io/fs"
+
+// fileIdentity reports that file identities are unavailable on this platform, so no files
+// are deduplicated.
+func fileIdentity(info fs.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
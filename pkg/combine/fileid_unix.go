@@ -0,0 +1,18 @@
+//go:build unix
+
+// File: pkg/combine/fileid_unix.go
+package combine
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileIdentity returns the device and inode number of the file described by info.
+func fileIdentity(info fs.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
@@ -0,0 +1,58 @@
+// File: pkg/combine/fsys.go
+package combine
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// The helpers below access fsys, or the host filesystem when fsys is nil. Names in an fs.FS are
+// slash-separated and relative to its root, so paths built with the filepath package are
+// converted before use.
+
+// absPathFS returns the absolute form of name on the host filesystem, or its clean form in fsys.
+func absPathFS(fsys fs.FS, name string) (string, error) {
+	if fsys == nil {
+		return filepath.Abs(name)
+	}
+	return path.Clean(filepath.ToSlash(name)), nil
+}
+
+// statFS returns the FileInfo describing the named file, following symlinks.
+func statFS(fsys fs.FS, name string) (fs.FileInfo, error) {
+	if fsys == nil {
+		return os.Stat(name)
+	}
+	return fs.Stat(fsys, filepath.ToSlash(name))
+}
+
+// openFS opens the named file for reading.
+func openFS(fsys fs.FS, name string) (fs.File, error) {
+	if fsys == nil {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+	return fsys.Open(filepath.ToSlash(name))
+}
+
+// readFileFS reads the named file and returns its contents.
+func readFileFS(fsys fs.FS, name string) ([]byte, error) {
+	if fsys == nil {
+		return os.ReadFile(name)
+	}
+	return fs.ReadFile(fsys, filepath.ToSlash(name))
+}
+
+// walkDirFS walks the file tree rooted at root, calling fn for each file or directory, as
+// filepath.WalkDir does.
+func walkDirFS(fsys fs.FS, root string, fn fs.WalkDirFunc) error {
+	if fsys == nil {
+		return filepath.WalkDir(root, fn)
+	}
+	return fs.WalkDir(fsys, filepath.ToSlash(root), fn)
+}
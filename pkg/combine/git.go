@@ -0,0 +1,66 @@
+// File: pkg/combine/git.go
+package combine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LogEntry is a single commit from a file's git history.
+type LogEntry struct {
+	Hash    string // Abbreviated commit hash.
+	Date    string // Author date in YYYY-MM-DD form.
+	Author  string // Author name.
+	Subject string // First line of the commit message.
+}
+
+// String formats the entry as a one-line summary, e.g. "abc1234 2024-01-15 John Doe: Fix bug in handler".
+func (e LogEntry) String() string {
+	return fmt.Sprintf("%s %s %s: %s", e.Hash, e.Date, e.Author, e.Subject)
+}
+
+// GetGitLog returns the last n commits touching filePath, most recent first.
+// It runs the equivalent of `git log --oneline -n N FILE` from the file's directory,
+// extended with the author date and name.
+func GetGitLog(ctx context.Context, filePath string, n int) ([]LogEntry, error) {
+	cmd := exec.CommandContext(ctx, "git", "log",
+		"-n", strconv.Itoa(n),
+		"--date=short",
+		"--format=%h%x09%ad%x09%an%x09%s",
+		"--", filepath.Base(filePath))
+	cmd.Dir = filepath.Dir(filePath)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed for %s: %w: %s", filePath, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var entries []LogEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		entries = append(entries, LogEntry{Hash: fields[0], Date: fields[1], Author: fields[2], Subject: fields[3]})
+	}
+	return entries, nil
+}
+
+// gitLogComment renders log entries as a comment block prepended to a file's content.
+func gitLogComment(entries []LogEntry) string {
+	var sb strings.Builder
+	for _, entry := range entries {
+		sb.WriteString("# git log: " + entry.String() + "\n")
+	}
+	if sb.Len() > 0 {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
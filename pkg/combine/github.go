@@ -0,0 +1,149 @@
+// File: pkg/combine/github.go
+package combine
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// githubFileFields lists the log field keys that identify the file an annotation refers to.
+var githubFileFields = []string{"file", "filePath", "path"}
+
+// githubActionsCore is a zapcore.Core that renders warnings and errors as GitHub Actions
+// workflow commands (::warning:: and ::error file=...::).
+type githubActionsCore struct {
+	zapcore.LevelEnabler
+	out    io.Writer
+	mu     *sync.Mutex
+	fields []zapcore.Field
+}
+
+// NewGitHubActionsCore returns a core that writes GitHub Actions annotations for
+// warning and higher level log entries to out.
+func NewGitHubActionsCore(out io.Writer) zapcore.Core {
+	return &githubActionsCore{
+		LevelEnabler: zapcore.WarnLevel,
+		out:          out,
+		mu:           &sync.Mutex{},
+	}
+}
+
+// With returns a copy of the core with additional context fields.
+func (c *githubActionsCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(append([]zapcore.Field{}, c.fields...), fields...)
+	return &clone
+}
+
+// Check adds the core to the checked entry when the level is enabled.
+func (c *githubActionsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write renders the log entry as a workflow command.
+func (c *githubActionsCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range append(append([]zapcore.Field{}, c.fields...), fields...) {
+		field.AddTo(enc)
+	}
+
+	message := ent.Message
+	if errMsg, ok := enc.Fields["error"].(string); ok && errMsg != "" {
+		message += ": " + errMsg
+	}
+
+	command := "warning"
+	if ent.Level >= zapcore.ErrorLevel {
+		command = "error"
+	}
+
+	var line strings.Builder
+	line.WriteString("::" + command)
+	for _, key := range githubFileFields {
+		if file, ok := enc.Fields[key].(string); ok && file != "" {
+			line.WriteString(" file=" + escapeGitHubProperty(file))
+			break
+		}
+	}
+	line.WriteString("::" + escapeGitHubData(message) + "\n")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := io.WriteString(c.out, line.String())
+	return err
+}
+
+// Sync is a no-op; annotations are written unbuffered.
+func (c *githubActionsCore) Sync() error {
+	return nil
+}
+
+// escapeGitHubData escapes a workflow command message.
+func escapeGitHubData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeGitHubProperty escapes a workflow command property value.
+func escapeGitHubProperty(s string) string {
+	s = escapeGitHubData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
+
+// writeGitHubStepSummary appends a markdown summary of the combine run to the
+// file referenced by $GITHUB_STEP_SUMMARY.
+func writeGitHubStepSummary(outputPath string, contents []FileContent, binaryFiles []string, logger *zap.Logger) error {
+	summaryPath := os.Getenv("GITHUB_STEP_SUMMARY")
+	if summaryPath == "" {
+		logger.Debug("GITHUB_STEP_SUMMARY is not set, skipping step summary")
+		return nil
+	}
+
+	var totalBytes int
+	for _, content := range contents {
+		totalBytes += len(content.Content)
+	}
+
+	var summary strings.Builder
+	summary.WriteString("## agentexec combine\n\n")
+	summary.WriteString("| Statistic | Value |\n|---|---|\n")
+	fmt.Fprintf(&summary, "| Output file | `%s` |\n", outputPath)
+	fmt.Fprintf(&summary, "| Files included | %d |\n", len(contents))
+	fmt.Fprintf(&summary, "| Files excluded (binary) | %d |\n", len(binaryFiles))
+	fmt.Fprintf(&summary, "| Total size (bytes) | %d |\n\n", totalBytes)
+
+	summary.WriteString("| File | Status | Size (bytes) |\n|---|---|---|\n")
+	for _, content := range contents {
+		fmt.Fprintf(&summary, "| `%s` | included | %d |\n", content.Path, len(content.Content))
+	}
+	for _, binary := range binaryFiles {
+		fmt.Fprintf(&summary, "| `%s` | excluded (binary) | |\n", binary)
+	}
+	summary.WriteString("\n")
+
+	file, err := os.OpenFile(summaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Error("Failed to open GitHub step summary", zap.String("file", summaryPath), zap.Error(err))
+		return fmt.Errorf("failed to open GitHub step summary: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(summary.String()); err != nil {
+		logger.Error("Failed to write GitHub step summary", zap.String("file", summaryPath), zap.Error(err))
+		return fmt.Errorf("failed to write GitHub step summary: %w", err)
+	}
+
+	logger.Debug("Wrote GitHub step summary", zap.String("file", summaryPath))
+	return nil
+}
@@ -0,0 +1,51 @@
+// File: pkg/combine/go_package.go
+package combine
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// goPackagePattern matches the package clause of a Go source file.
+var goPackagePattern = regexp.MustCompile(`(?m)^package (\w+)`)
+
+// DetectGoPackage returns the name in the first package clause of the Go source content, or ""
+// if it has none.
+func DetectGoPackage(content string) string {
+	if match := goPackagePattern.FindStringSubmatch(content); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// groupByGoPackage reorders contents by the Go package of each file: package main first, then
+// the other packages alphabetically, then all other files. The existing order is kept within a group.
+func groupByGoPackage(contents []FileContent) {
+	packages := make(map[string]string, len(contents))
+	for _, content := range contents {
+		if strings.EqualFold(filepath.Ext(content.Path), ".go") {
+			packages[content.Path] = DetectGoPackage(content.Content)
+		}
+	}
+
+	// rank orders main before other packages, and those before files without a package
+	rank := func(pkg string) int {
+		switch pkg {
+		case "main":
+			return 0
+		case "":
+			return 2
+		default:
+			return 1
+		}
+	}
+	sort.SliceStable(contents, func(i, j int) bool {
+		iPkg, jPkg := packages[contents[i].Path], packages[contents[j].Path]
+		if iRank, jRank := rank(iPkg), rank(jPkg); iRank != jRank {
+			return iRank < jRank
+		}
+		return iPkg < jPkg
+	})
+}
@@ -0,0 +1,61 @@
+// File: pkg/combine/hash.go
+package combine
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// Supported file hashing algorithms.
+const (
+	HashSHA256 = "sha256" // SHA-256 (default).
+	HashSHA512 = "sha512" // SHA-512.
+	HashMD5    = "md5"    // MD5; fast but not collision resistant.
+	HashXXHash = "xxhash" // 64-bit xxHash; fastest, not cryptographic.
+)
+
+// hashConstructors maps each supported algorithm to a constructor for its hash.Hash.
+var hashConstructors = map[string]func() hash.Hash{
+	HashSHA256: sha256.New,
+	HashSHA512: sha512.New,
+	HashMD5:    md5.New,
+	HashXXHash: func() hash.Hash { return xxhash.New() },
+}
+
+// IsSupportedHashAlgorithm reports whether algo can be passed to HashFile.
+func IsSupportedHashAlgorithm(algo string) bool {
+	_, ok := hashConstructors[algo]
+	return ok
+}
+
+// HashFile returns the hex-encoded digest of the file at path using the given algorithm.
+// An empty algo selects SHA-256.
+func HashFile(path string, algo string) (string, error) {
+	if algo == "" {
+		algo = HashSHA256
+	}
+	newHash, ok := hashConstructors[algo]
+	if !ok {
+		return "", fmt.Errorf("unsupported hash algorithm %q", algo)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := newHash()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -2,18 +2,83 @@
 package combine
 
 import (
+	"archive/zip"
 	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 )
 
-// shouldSkipFile determines if a file should be skipped based on ignore patterns, size, and binary content.
-func shouldSkipFile(path string, info fs.FileInfo, gi IgnoreParser, maxFileSizeKB int, logger *zap.Logger, verbose bool) bool {
+// SkipReason describes why a file was excluded from the combined output.
+type SkipReason int
+
+const (
+	SkipNone            SkipReason = iota // File is not skipped.
+	SkipIgnorePattern                     // File matches an ignore pattern.
+	SkipBinaryExtension                   // File has a known binary extension.
+	SkipSizeLimit                         // File exceeds the maximum size limit.
+	SkipBinaryContent                     // File content looks binary.
+	SkipTooOld                            // File was last modified before the age limit.
+	SkipNotIncluded                       // File matches none of the include patterns.
+)
+
+// String returns a short, stable name for the skip reason suitable for logs and statistics.
+func (r SkipReason) String() string {
+	switch r {
+	case SkipNone:
+		return "none"
+	case SkipIgnorePattern:
+		return "ignore-pattern"
+	case SkipBinaryExtension:
+		return "binary-extension"
+	case SkipSizeLimit:
+		return "size-limit"
+	case SkipBinaryContent:
+		return "binary-content"
+	case SkipTooOld:
+		return "too-old"
+	case SkipNotIncluded:
+		return "not-included"
+	default:
+		return fmt.Sprintf("SkipReason(%d)", int(r))
+	}
+}
+
+// MarshalText encodes the skip reason by its name, so that per-reason counts encode as JSON objects.
+func (r SkipReason) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// countSkipped adds n files skipped for reason to the per-reason counts of c.
+func (c *CollectedFiles) countSkipped(reason SkipReason, n int) {
+	if c.Skipped == nil {
+		c.Skipped = make(map[SkipReason]int)
+	}
+	c.Skipped[reason] += n
+}
+
+// matchesAnyInclude reports whether relPath matches one of the include patterns.
+// A nil include set matches every path.
+func matchesAnyInclude(include *CombineIgnore, relPath string) bool {
+	return include == nil || include.MatchesPath(relPath)
+}
+
+// shouldSkipFile determines if a file should be skipped based on ignore and include patterns, size, age, and binary content.
+// It returns SkipNone when the file should be included, or the reason it was excluded.
+func shouldSkipFile(path string, info fs.FileInfo, gi IgnoreParser, maxFileSizeKB int, opts CollectOptions, logger *zap.Logger, verbose bool) SkipReason {
 	relPath, _ := filepath.Rel(filepath.Dir(path), path)
 	relPath = normalizePath(relPath)
 
@@ -21,43 +86,65 @@ func shouldSkipFile(path string, info fs.FileInfo, gi IgnoreParser, maxFileSizeK
 		if verbose {
 			logger.Debug("File matches ignore pattern", zap.String("file", path), zap.String("relPath", relPath))
 		}
-		return true
+		return SkipIgnorePattern
 	}
 
-	if isCommonBinaryExtension(path) {
+	if !matchesAnyInclude(opts.Include, relPath) {
+		if verbose {
+			logger.Debug("File matches no include pattern", zap.String("file", path), zap.String("relPath", relPath))
+		}
+		return SkipNotIncluded
+	}
+
+	// Extensions treated as text bypass both binary checks
+	treatAsText := opts.Binary.HasTextExtension(path)
+	if !treatAsText && opts.Binary.HasBinaryExtension(path) {
 		if verbose {
 			logger.Debug("File has binary extension", zap.String("file", path), zap.String("extension", filepath.Ext(path)))
 		}
-		return true
+		return SkipBinaryExtension
+	}
+
+	if size := effectiveSize(path, info); size > int64(maxFileSizeKB)*1024 {
+		if verbose {
+			logger.Debug("File exceeds size limit", zap.String("file", path), zap.Int64("sizeBytes", size), zap.Int("maxSizeKB", maxFileSizeKB))
+		}
+		return SkipSizeLimit
 	}
 
-	if info.Size() > int64(maxFileSizeKB)*1024 {
+	if opts.isTooOld(info) {
 		if verbose {
-			logger.Debug("File exceeds size limit", zap.String("file", path), zap.Int64("sizeBytes", info.Size()), zap.Int("maxSizeKB", maxFileSizeKB))
+			logger.Debug("File is older than the age limit", zap.String("file", path), zap.Time("modTime", info.ModTime()), zap.Duration("excludeOlderThan", opts.ExcludeOlderThan))
 		}
-		return true
+		return SkipTooOld
 	}
 
-	isBinary, err := isBinaryFile(path)
+	if treatAsText {
+		return SkipNone
+	}
+
+	checkStart := time.Now()
+	isBinary, err := opts.Binary.isBinaryFile(opts.FS, path)
+	opts.ioProfile.observe(ioBinaryCheck, checkStart)
 	if err != nil {
 		logger.Error("Failed to check if file is binary", zap.String("file", path), zap.Error(err))
-		return true
+		return SkipBinaryContent
 	}
 
 	if isBinary {
 		if verbose {
 			logger.Debug("File is binary", zap.String("file", path))
 		}
-		return true
+		return SkipBinaryContent
 	}
 
-	return false
+	return SkipNone
 }
 
 // promptUser displays a message and waits for the user to enter 'y' or 'n'.
 // Returns true if the user enters 'y' or 'yes' (case-insensitive), false otherwise.
 func promptUser(message string) (bool, error) {
-	fmt.Print(message)
+	fmt.Fprint(os.Stderr, message)
 	reader := bufio.NewReader(os.Stdin)
 	response, err := reader.ReadString('\n')
 	if err != nil {
@@ -67,44 +154,422 @@ func promptUser(message string) (bool, error) {
 	return response == "y" || response == "yes", nil
 }
 
-// WriteCombinedFile writes the tree content and combined file contents to the output file.
-func WriteCombinedFile(outputPath string, treeContent string, combinedContents []FileContent, logger *zap.Logger) error {
-	logger.Debug("Writing combined content to output file", zap.String("combinedFile", outputPath))
+// WriteCombinedFile writes the tree content and combined file contents to w as plain text.
+func WriteCombinedFile(w io.Writer, treeContent string, combinedContents []FileContent, options ...Option) error {
+	logger := newOptions(options).logger
+	data := renderCombinedText(treeContent, combinedContents)
 
-	outFile, err := os.Create(outputPath)
-	if err != nil {
-		logger.Error("Failed to create output file", zap.String("file", outputPath), zap.Error(err))
-		return fmt.Errorf("failed to create output file: %w", err)
+	if _, err := w.Write(data); err != nil {
+		logger.Error("Failed to write combined content", zap.Error(err))
+		return fmt.Errorf("failed to write combined file: %w", err)
+	}
+
+	logger.Debug("Wrote combined content", zap.Int("bytes", len(data)))
+	return nil
+}
+
+// WriteXMLTaggedFile writes the tree and file contents to w enclosed in <tag> and </tag>, as used
+// to delimit injected content in LLM prompts. The tree is enclosed in <directory_tree> and each
+// file in <document path="...">, which replaces its header. Contents are written as is, without
+// escaping, so the output is not necessarily well-formed XML.
+func WriteXMLTaggedFile(w io.Writer, tag, treeContent string, combinedContents []FileContent, options ...Option) error {
+	logger := newOptions(options).logger
+
+	var buf bytes.Buffer
+	buf.Grow(combinedTextSize(treeContent, combinedContents))
+	writeTagged := func(open, content, close string) {
+		buf.WriteString(open + "\n")
+		buf.WriteString(content)
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(close + "\n")
+	}
+
+	buf.WriteString("<" + tag + ">\n")
+	writeTagged("<directory_tree>", treeContent, "</directory_tree>")
+	for _, content := range combinedContents {
+		var path strings.Builder
+		_ = xml.EscapeText(&path, []byte(content.Path)) // Writing to a strings.Builder cannot fail
+		writeTagged(`<document path="`+path.String()+`">`, content.Content, "</document>")
+	}
+	buf.WriteString("</" + tag + ">\n")
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		logger.Error("Failed to write combined content", zap.Error(err))
+		return fmt.Errorf("failed to write combined file: %w", err)
+	}
+
+	logger.Debug("Wrote XML-tagged combined content", zap.String("tag", tag), zap.Int("bytes", buf.Len()))
+	return nil
+}
+
+// WriteCombinedFileWithProgress writes the same plain text as WriteCombinedFile, one file section
+// at a time, calling onProgress with the number of sections written so far and the total after
+// each of them. A nil onProgress is ignored.
+func WriteCombinedFileWithProgress(w io.Writer, treeContent string, combinedContents []FileContent, onProgress func(n, total int), options ...Option) error {
+	logger := newOptions(options).logger
+
+	if _, err := io.WriteString(w, treeContent); err != nil {
+		logger.Error("Failed to write tree content", zap.Error(err))
+		return fmt.Errorf("failed to write combined file: %w", err)
+	}
+
+	written := len(treeContent)
+	for i, content := range combinedContents {
+		if _, err := io.WriteString(w, content.Header+content.Content); err != nil {
+			logger.Error("Failed to write file section", zap.String("path", content.Path), zap.Error(err))
+			return fmt.Errorf("failed to write combined file: %w", err)
+		}
+		written += len(content.Header) + len(content.Content)
+		if onProgress != nil {
+			onProgress(i+1, len(combinedContents))
+		}
+	}
+
+	logger.Debug("Wrote combined content", zap.Int("bytes", written))
+	return nil
+}
+
+// OpenFile opens path in the default editor or viewer of the running OS, using open on macOS,
+// start on Windows, and xdg-open elsewhere. It does not wait for the application to exit. On
+// systems without a graphical session, i.e. with neither DISPLAY nor WAYLAND_DISPLAY set outside
+// macOS and Windows, it does nothing.
+func OpenFile(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("cannot open %s: %w", path, err)
+	}
+
+	var name string
+	var args []string
+	switch runtime.GOOS {
+	case "darwin":
+		name = "open"
+		args = []string{path}
+	case "windows":
+		// The empty argument is the window title, so that a quoted path is not taken for it
+		name = "cmd"
+		args = []string{"/c", "start", "", path}
+	default:
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return nil
+		}
+		name = "xdg-open"
+		args = []string{path}
+	}
+
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", name, err)
+	}
+	// Reap the launcher in the background; it usually hands the file over and exits at once
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// renderCombinedText renders the tree followed by each file's header and content as plain text.
+func renderCombinedText(treeContent string, combinedContents []FileContent) []byte {
+	// Pre-size the buffer so the whole output is assembled with a single allocation
+	var buf bytes.Buffer
+	buf.Grow(combinedTextSize(treeContent, combinedContents))
+
+	// Write tree content first, followed by the combined file contents
+	buf.WriteString(treeContent)
+	for _, content := range combinedContents {
+		buf.WriteString(content.Header)
+		buf.WriteString(content.Content)
+	}
+	return buf.Bytes()
+}
+
+// combinedTextSize returns the size in bytes of the plain text output for the tree and file contents.
+func combinedTextSize(treeContent string, combinedContents []FileContent) int {
+	size := len(treeContent)
+	for _, content := range combinedContents {
+		size += len(content.Header) + len(content.Content)
+	}
+	return size
+}
+
+// printDryRunSummary writes the files that would be combined, the binary files that would be
+// skipped, and the estimated output size to w, one entry per line.
+func printDryRunSummary(w io.Writer, combinedContents []FileContent, skippedBinary []string, estimatedBytes int) error {
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "Files to combine (%d):\n", len(combinedContents))
+	for _, content := range combinedContents {
+		summary.WriteString(content.Path + "\n")
+	}
+	fmt.Fprintf(&summary, "Binary files skipped (%d):\n", len(skippedBinary))
+	for _, binary := range skippedBinary {
+		summary.WriteString(binary + "\n")
+	}
+	fmt.Fprintf(&summary, "Estimated output size: %d bytes\n", estimatedBytes)
+
+	_, err := io.WriteString(w, summary.String())
+	return err
+}
+
+// jsonOutput is the document written to the output file in JSON format.
+type jsonOutput struct {
+	Tree                 string     `json:"tree" yaml:"tree"`
+	Files                []jsonFile `json:"files" yaml:"files"`
+	TotalEstimatedTokens *int       `json:"total_estimated_tokens,omitempty" yaml:"total_estimated_tokens,omitempty"`
+	Truncated            bool       `json:"truncated,omitempty" yaml:"truncated,omitempty"`
+}
+
+// jsonFile is a single file entry in the JSON output.
+type jsonFile struct {
+	Path            string `json:"path" yaml:"path"`
+	Content         string `json:"content" yaml:"content"`
+	SHA256          string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+	EstimatedTokens *int   `json:"estimated_tokens,omitempty" yaml:"estimated_tokens,omitempty"`
+}
+
+// WriteCombinedJSON writes the tree content and combined file contents to w as JSON.
+// When countTokens is true, each file entry and the document carry token estimates.
+func WriteCombinedJSON(w io.Writer, treeContent string, combinedContents []FileContent, countTokens bool, logger *zap.Logger) error {
+	logger.Debug("Writing combined content as JSON")
+	return writeStructuredOutput(w, buildJSONOutput(treeContent, combinedContents, countTokens), encodeJSONOutput, logger)
+}
+
+// WriteCombinedYAML writes the tree content and combined file contents to w as a YAML document
+// with the same structure as the JSON output.
+func WriteCombinedYAML(w io.Writer, treeContent string, combinedContents []FileContent, countTokens bool, logger *zap.Logger) error {
+	logger.Debug("Writing combined content as YAML")
+	return writeStructuredOutput(w, buildJSONOutput(treeContent, combinedContents, countTokens), encodeYAMLOutput, logger)
+}
+
+// zipTreeName is the name of the tree structure entry at the root of ZIP output.
+const zipTreeName = "_tree.txt"
+
+// WriteZipOutput writes the tree content, combined file contents, and binary files to w as a ZIP
+// archive. Each file is stored at its relative path; the tree is stored as _tree.txt.
+func WriteZipOutput(w io.Writer, treeContent string, combinedContents []FileContent, binaryContents []BinaryFile, logger *zap.Logger) error {
+	logger.Debug("Writing combined content as ZIP archive")
+
+	zipWriter := zip.NewWriter(w)
+	writeEntry := func(name string, content []byte) error {
+		entry, err := zipWriter.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to create ZIP entry %s: %w", name, err)
+		}
+		if _, err := entry.Write(content); err != nil {
+			return fmt.Errorf("failed to write ZIP entry %s: %w", name, err)
+		}
+		return nil
+	}
+
+	if err := writeEntry(zipTreeName, []byte(treeContent)); err != nil {
+		logger.Error("Failed to write tree to ZIP archive", zap.Error(err))
+		return err
 	}
-	defer func() {
-		if err := outFile.Close(); err != nil {
-			logger.Error("Failed to close output file", zap.String("file", outputPath), zap.Error(err))
+	for _, content := range combinedContents {
+		if err := writeEntry(filepath.ToSlash(content.Path), []byte(content.Content)); err != nil {
+			logger.Error("Failed to write file to ZIP archive", zap.String("contentPath", content.Path), zap.Error(err))
+			return err
 		}
-	}()
+	}
+	for _, binary := range binaryContents {
+		if err := writeEntry(filepath.ToSlash(binary.Path), binary.Content); err != nil {
+			logger.Error("Failed to write binary file to ZIP archive", zap.String("contentPath", binary.Path), zap.Error(err))
+			return err
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		logger.Error("Failed to finalize ZIP archive", zap.Error(err))
+		return fmt.Errorf("failed to finalize ZIP archive: %w", err)
+	}
 
-	writer := bufio.NewWriter(outFile)
+	logger.Debug("Wrote ZIP archive", zap.Int("entries", len(combinedContents)+len(binaryContents)+1))
+	return nil
+}
 
-	// Write tree content first
-	if _, err := writer.WriteString(treeContent); err != nil {
-		logger.Error("Failed to write tree content to combined file", zap.String("file", outputPath), zap.Error(err))
-		return fmt.Errorf("failed to write tree content: %w", err)
+// writeStructuredOutput encodes doc to w using encode.
+func writeStructuredOutput(w io.Writer, doc jsonOutput, encode func(io.Writer, jsonOutput) error, logger *zap.Logger) error {
+	if doc.TotalEstimatedTokens != nil {
+		logger.Debug("Estimated tokens for structured output", zap.Int("totalEstimatedTokens", *doc.TotalEstimatedTokens))
 	}
 
-	// Write combined file contents
+	if err := encode(w, doc); err != nil {
+		logger.Error("Failed to encode structured output", zap.Error(err))
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+
+	return nil
+}
+
+// buildJSONOutput assembles the JSON document for the tree and file contents.
+func buildJSONOutput(treeContent string, combinedContents []FileContent, countTokens bool) jsonOutput {
+	doc := jsonOutput{
+		Tree:  treeContent,
+		Files: make([]jsonFile, 0, len(combinedContents)),
+	}
+	totalTokens := 0
 	for _, content := range combinedContents {
-		if _, err := writer.WriteString(content.Content); err != nil {
-			logger.Error("Failed to write content to combined file",
-				zap.String("file", outputPath),
-				zap.String("contentPath", content.Path),
-				zap.Error(err))
-			return fmt.Errorf("failed to write content: %w", err)
+		entry := jsonFile{Path: content.Path, Content: content.Content, SHA256: content.SHA256}
+		if countTokens {
+			tokens := CountTokens(content.Content)
+			entry.EstimatedTokens = &tokens
+			totalTokens += tokens
+		}
+		doc.Files = append(doc.Files, entry)
+	}
+	if countTokens {
+		doc.TotalEstimatedTokens = &totalTokens
+	}
+	return doc
+}
+
+// encodeJSONOutput writes doc to w as indented JSON.
+func encodeJSONOutput(w io.Writer, doc jsonOutput) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// encodeYAMLOutput writes doc to w as a YAML document.
+func encodeYAMLOutput(w io.Writer, doc jsonOutput) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// replaceIfChanged moves the freshly written file at newPath over outputPath when their
+// digests under hashAlgorithm differ, and discards newPath otherwise. It reports whether outputPath was updated.
+func replaceIfChanged(newPath, outputPath, hashAlgorithm string, logger *zap.Logger) (bool, error) {
+	newHash, err := HashFile(newPath, hashAlgorithm)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash new output: %w", err)
+	}
+
+	oldHash, err := HashFile(outputPath, hashAlgorithm)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("failed to hash existing output: %w", err)
+	}
+
+	if err == nil && oldHash == newHash {
+		logger.Debug("Output unchanged, keeping existing file", zap.String("file", outputPath), zap.String("hash", newHash))
+		if err := os.Remove(newPath); err != nil {
+			logger.Warn("Failed to remove temporary output file", zap.String("file", newPath), zap.Error(err))
 		}
+		return false, nil
+	}
+
+	if err := os.Rename(newPath, outputPath); err != nil {
+		return false, fmt.Errorf("failed to replace output file: %w", err)
 	}
+	logger.Debug("Output changed, replaced file", zap.String("file", outputPath), zap.String("hash", newHash))
+	return true, nil
+}
 
-	if err := writer.Flush(); err != nil {
-		logger.Error("Failed to flush output file", zap.String("file", outputPath), zap.Error(err))
-		return fmt.Errorf("failed to flush output: %w", err)
+// appendFile appends the content of srcPath to dstPath, creating dstPath if needed.
+func appendFile(srcPath, dstPath string, logger *zap.Logger) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to open new output: %w", err)
 	}
+	defer src.Close()
 
+	dst, err := os.OpenFile(dstPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open output file for appending: %w", err)
+	}
+
+	written, err := io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to append output: %w", err)
+	}
+
+	logger.Debug("Appended combined content", zap.String("file", dstPath), zap.Int64("bytes", written))
 	return nil
 }
+
+// GroupByExtension groups file contents by their lower-cased file extension (including the dot).
+// Files without an extension are grouped under the empty string.
+func GroupByExtension(files []FileContent) map[string][]FileContent {
+	groups := make(map[string][]FileContent)
+	for _, file := range files {
+		ext := strings.ToLower(filepath.Ext(file.Path))
+		groups[ext] = append(groups[ext], file)
+	}
+	return groups
+}
+
+// pathDepth returns the number of directories in the source path, e.g. 0 for "README.md" and
+// 2 for "src/lib/util.go".
+func pathDepth(path string) int {
+	return strings.Count(filepath.ToSlash(path), "/")
+}
+
+// perExtensionOutputName returns the combined output file name for an extension group,
+// e.g. "combined.go.txt" for ".go" and "combined.noext.txt" for files without an extension.
+func perExtensionOutputName(ext string) string {
+	if ext == "" {
+		ext = ".noext"
+	}
+	return "combined" + ext + ".txt"
+}
+
+// SplitByPattern groups files, in order, into shards that each start at a file matching pattern.
+// pattern is a comma-separated list of gitignore-style patterns matched against file paths.
+// Files before the first match form the first shard; an empty pattern yields a single shard.
+func SplitByPattern(files []FileContent, pattern string) [][]FileContent {
+	boundaries := NewCombineIgnore(nil)
+	boundaries.CompileIgnoreLines(strings.Split(pattern, ",")...)
+
+	var shards [][]FileContent
+	for _, file := range files {
+		if len(shards) == 0 || (len(shards[len(shards)-1]) > 0 && boundaries.MatchesPath(file.Path)) {
+			shards = append(shards, nil)
+		}
+		shards[len(shards)-1] = append(shards[len(shards)-1], file)
+	}
+	return shards
+}
+
+// SplitCombinedOutput partitions files, in order, into chunks whose headers and contents together
+// take at most limit bytes. A file larger than the limit on its own is put into a chunk by itself.
+func SplitCombinedOutput(files []FileContent, limit int, logger *zap.Logger) [][]FileContent {
+	var chunks [][]FileContent
+	var chunk []FileContent
+	size := 0
+	for _, file := range files {
+		fileSize := len(file.Header) + len(file.Content)
+		if fileSize > limit {
+			logger.Warn("File exceeds the split size and is written to its own chunk",
+				zap.String("file", file.Path), zap.Int("sizeBytes", fileSize), zap.Int("splitBytes", limit))
+		}
+		if len(chunk) > 0 && size+fileSize > limit {
+			chunks = append(chunks, chunk)
+			chunk, size = nil, 0
+		}
+		chunk = append(chunk, file)
+		size += fileSize
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// chunkOutputName returns the output path of the size-limited chunk with the given 1-based index,
+// e.g. "debug/combined_002.txt" for "debug/combined.txt".
+func chunkOutputName(outputPath string, index int) string {
+	ext := filepath.Ext(outputPath)
+	return fmt.Sprintf("%s_%03d%s", strings.TrimSuffix(outputPath, ext), index, ext)
+}
+
+// shardOutputName returns the output path of the shard with the given 1-based index,
+// e.g. "debug/combined.2.txt" for "debug/combined.txt".
+func shardOutputName(outputPath string, index int) string {
+	ext := filepath.Ext(outputPath)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(outputPath, ext), index, ext)
+}
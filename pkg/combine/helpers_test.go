@@ -0,0 +1,181 @@
+// File: pkg/combine/helpers_test.go
+package combine_test
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"agentexec/pkg/combine"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestOutputFormatsRoundTrip checks that the content of each file can be read back unchanged from
+// text, JSON, and YAML output.
+func TestOutputFormatsRoundTrip(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/main.go":        text("package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"),
+		"src/config/app.yml": text("name: \"app\"\nitems:\n  - one: 1\n"),
+		"src/notes.md":       text("# Notes\n\nÜnïcödé and `quotes` \\ backslashes"),
+	}
+	want := map[string]string{
+		"src/main.go":        string(fsys["src/main.go"].Data),
+		"src/config/app.yml": string(fsys["src/config/app.yml"].Data),
+		"src/notes.md":       string(fsys["src/notes.md"].Data),
+	}
+
+	// Parsers of each format, returning the content of each file by path
+	decodeStructured := func(unmarshal func([]byte, any) error) func(t *testing.T, data string) map[string]string {
+		return func(t *testing.T, data string) map[string]string {
+			var doc struct {
+				Tree  string `json:"tree" yaml:"tree"`
+				Files []struct {
+					Path    string `json:"path" yaml:"path"`
+					Content string `json:"content" yaml:"content"`
+				} `json:"files" yaml:"files"`
+			}
+			if err := unmarshal([]byte(data), &doc); err != nil {
+				t.Fatalf("failed to decode output: %v", err)
+			}
+			if !strings.Contains(doc.Tree, "main.go") {
+				t.Errorf("tree = %q, want it to list main.go", doc.Tree)
+			}
+			files := make(map[string]string, len(doc.Files))
+			for _, file := range doc.Files {
+				files[file.Path] = file.Content
+			}
+			return files
+		}
+	}
+	tests := []struct {
+		format string
+		decode func(t *testing.T, data string) map[string]string
+	}{
+		{combine.FormatText, func(t *testing.T, data string) map[string]string {
+			files, err := combine.ParseCombinedFile(strings.NewReader(data))
+			if err != nil {
+				t.Fatalf("ParseCombinedFile() = %v", err)
+			}
+			return files
+		}},
+		{combine.FormatJSON, decodeStructured(json.Unmarshal)},
+		{combine.FormatYAML, decodeStructured(yaml.Unmarshal)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			args := testArguments(t, fsys)
+			args.OutputFormat = tt.format
+			runCombine(t, args)
+
+			if got := tt.decode(t, readFile(t, args.Output)); !maps.Equal(got, want) {
+				t.Errorf("files = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// benchmarkContents returns n file contents of a few hundred bytes each, with headers.
+func benchmarkContents(n int) []combine.FileContent {
+	body := strings.Repeat("func example() int { return 42 }\n", 10)
+	contents := make([]combine.FileContent, n)
+	for i := range contents {
+		path := fmt.Sprintf("pkg/module%03d/file%05d.go", i%100, i)
+		contents[i] = combine.FileContent{Path: path, Header: "# " + path + "\n", Content: body}
+	}
+	return contents
+}
+
+// benchmarkWrite runs write b.N times into the same temporary file, rewinding it in between.
+func benchmarkWrite(b *testing.B, write func(w io.Writer) error) {
+	f, err := os.Create(filepath.Join(b.TempDir(), "combined.txt"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			b.Fatal(err)
+		}
+		if err := write(f); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWriteCombinedFile compares writing 10,000 files as one pre-sized buffer with writing
+// them one section at a time.
+func BenchmarkWriteCombinedFile(b *testing.B) {
+	contents := benchmarkContents(10000)
+	tree := strings.Repeat("├── file.go\n", 10000)
+	b.Run("buffered", func(b *testing.B) {
+		benchmarkWrite(b, func(w io.Writer) error {
+			return combine.WriteCombinedFile(w, tree, contents)
+		})
+	})
+	b.Run("per-file", func(b *testing.B) {
+		benchmarkWrite(b, func(w io.Writer) error {
+			return combine.WriteCombinedFileWithProgress(w, tree, contents, nil)
+		})
+	})
+}
+
+// TestZipOutput checks that ZIP output stores the tree and every collected file, binary ones
+// included, at its relative path with its content unchanged.
+func TestZipOutput(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/main.go":         text("package main\n"),
+		"src/lib/util.go":     text("package lib\n\nfunc Util() {}\n"),
+		"src/assets/logo.png": &fstest.MapFile{Data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")},
+		"src/data.bin":        &fstest.MapFile{Data: []byte{0, 1, 2, 3, 255}},
+	}
+	args := testArguments(t, fsys)
+	args.OutputFormat = combine.FormatZip
+	args.Output = strings.TrimSuffix(args.Output, ".txt") + ".zip"
+	runCombine(t, args)
+
+	archive, err := zip.OpenReader(args.Output)
+	if err != nil {
+		t.Fatalf("zip.OpenReader() = %v", err)
+	}
+	defer archive.Close()
+
+	checksums := make(map[string]string, len(archive.File))
+	for _, entry := range archive.File {
+		r, err := entry.Open()
+		if err != nil {
+			t.Fatalf("failed to open entry %s: %v", entry.Name, err)
+		}
+		hash := sha256.New()
+		if _, err := io.Copy(hash, r); err != nil {
+			t.Fatalf("failed to read entry %s: %v", entry.Name, err)
+		}
+		r.Close()
+		checksums[entry.Name] = hex.EncodeToString(hash.Sum(nil))
+	}
+
+	if _, ok := checksums["_tree.txt"]; !ok {
+		t.Error("archive has no _tree.txt entry")
+	}
+	delete(checksums, "_tree.txt")
+	want := make(map[string]string, len(fsys))
+	for name, file := range fsys {
+		sum := sha256.Sum256(file.Data)
+		want[name] = hex.EncodeToString(sum[:])
+	}
+	if !maps.Equal(checksums, want) {
+		t.Errorf("entry checksums = %v, want %v", checksums, want)
+	}
+}
@@ -0,0 +1,32 @@
+// File: pkg/combine/hooks.go
+package combine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// shellCommand returns the command running command through the system shell.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// RunHook runs cmd through the system shell with env added to the environment and waits for it
+// to complete. Its output goes to stderr so that it cannot corrupt output written to stdout. An
+// error is returned if the command cannot be started or exits with a nonzero status.
+func RunHook(ctx context.Context, cmd string, env []string) error {
+	hook := shellCommand(ctx, cmd)
+	hook.Env = append(os.Environ(), env...)
+	hook.Stdout = os.Stderr
+	hook.Stderr = os.Stderr
+	if err := hook.Run(); err != nil {
+		return fmt.Errorf("hook %q failed: %w", cmd, err)
+	}
+	return nil
+}
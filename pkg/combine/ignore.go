@@ -2,10 +2,14 @@
 package combine
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"go.uber.org/zap"
@@ -15,6 +19,7 @@ import (
 type IgnoreParser interface {
 	MatchesPath(path string) bool
 	MatchesPathWithPattern(path string) (bool, *IgnorePattern)
+	MatchesDirectory(relPath string) bool
 }
 
 // IgnorePattern encapsulates a compiled regular expression pattern,
@@ -24,12 +29,42 @@ type IgnorePattern struct {
 	Negate  bool           // Indicates if the pattern is a negation (starts with '!').
 	LineNo  int            // Line number in the source (1-based).
 	Line    string         // Original pattern line.
+	Syntax  string         // Syntax the pattern was written in (SyntaxGitignore, SyntaxGlob, or SyntaxRegex).
+	Source  string         // Ignore file the pattern was read from; empty for patterns compiled from lines.
+
+	anchored   bool           // Pattern is root-relative and can only match paths starting with prefix.
+	prefix     string         // Literal path prefix of an anchored pattern.
+	matchCount int            // Number of paths this pattern has matched.
+	profile    patternProfile // Evaluation statistics collected when profiling is enabled.
+}
+
+// ErrEmptyPattern is reported by CompileIgnoreLines for blank pattern lines once
+// RejectEmptyPatterns has been called.
+var ErrEmptyPattern = errors.New("empty ignore pattern")
+
+// PatternError describes a pattern line that CompileIgnoreLines could not compile.
+type PatternError struct {
+	Line   string // Pattern line as given.
+	LineNo int    // 1-based position of the line among the lines compiled together.
+	Err    error  // Why the line was rejected, e.g. ErrEmptyPattern or a regular expression syntax error.
+}
+
+// Error implements the error interface.
+func (e PatternError) Error() string {
+	return fmt.Sprintf("pattern %d %q: %v", e.LineNo, e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e PatternError) Unwrap() error {
+	return e.Err
 }
 
 // CombineIgnore represents a collection of ignore patterns.
 type CombineIgnore struct {
-	patterns []*IgnorePattern // Slice of compiled ignore patterns.
-	logger   *zap.Logger      // Logger for debug information.
+	patterns    []*IgnorePattern // Slice of compiled ignore patterns.
+	logger      *zap.Logger      // Logger for debug information.
+	profiling   bool             // If true, per-pattern evaluation statistics are recorded.
+	rejectEmpty bool             // If true, CompileIgnoreLines fails on blank lines instead of skipping them.
 }
 
 // NewCombineIgnore initializes a CombineIgnore instance with a provided logger.
@@ -45,24 +80,38 @@ func NewCombineIgnore(logger *zap.Logger) *CombineIgnore {
 
 // LoadIgnoreFiles loads ignore patterns from `.combineignore` files
 // in the current directory and all parent directories, merging them hierarchically.
-func LoadIgnoreFiles(globalPath string, logger *zap.Logger) (*CombineIgnore, error) {
+// The global ignore files, of which empty paths are skipped, are loaded in order concurrently
+// with the directory walk, since they may live on slow storage; their patterns still take
+// effect before the local ones.
+func LoadIgnoreFiles(globalPaths []string, logger *zap.Logger) (*CombineIgnore, error) {
 	gi := NewCombineIgnore(logger)
 
-	// Load global ignore file if specified
-	if globalPath != "" {
-		absGlobalPath, err := filepath.Abs(globalPath)
-		if err == nil {
-			if err := gi.CompileIgnoreFile(absGlobalPath); err != nil {
+	// Load global ignore files if specified
+	globalDone := make(chan *CombineIgnore, 1)
+	go func() {
+		global := NewCombineIgnore(logger)
+		for _, globalPath := range globalPaths {
+			if globalPath == "" {
+				continue
+			}
+			absGlobalPath, err := filepath.Abs(globalPath)
+			if err != nil {
+				logger.Warn("Failed to get absolute path of global ignore file", zap.String("file", globalPath), zap.Error(err))
+				continue
+			}
+			if err := global.CompileIgnoreFile(absGlobalPath); err != nil {
 				logger.Warn("Failed to load global ignore file", zap.String("file", absGlobalPath), zap.Error(err))
 			} else {
 				logger.Debug("Loaded global ignore file", zap.String("file", absGlobalPath))
 			}
 		}
-	}
+		globalDone <- global
+	}()
 
 	// Traverse directories to load `.combineignore` files from root to current directory
 	startDir, err := os.Getwd()
 	if err != nil {
+		<-globalDone
 		return nil, fmt.Errorf("failed to get current directory: %w", err)
 	}
 
@@ -84,50 +133,88 @@ func LoadIgnoreFiles(globalPath string, logger *zap.Logger) (*CombineIgnore, err
 		currentDir = parentDir
 	}
 
+	// Global patterns come first so that local files can override them
+	gi = gi.Merge(<-globalDone)
+
 	// Compile patterns from all `.combineignore` files
 	for _, file := range ignoreFiles {
 		if err := gi.CompileIgnoreFile(file); err != nil {
 			logger.Warn("Failed to compile .combineignore file", zap.String("file", file), zap.Error(err))
 		} else {
 			logger.Debug("Loaded .combineignore file", zap.String("file", file))
-			fmt.Printf("Loaded ignore file: %s\n", file) // Print loaded file
+			fmt.Fprintf(os.Stderr, "Loaded ignore file: %s\n", file) // Print loaded file; stdout may carry the combined output
 		}
 	}
 
 	if !loadedFiles {
-		fmt.Println("No .combineignore files were loaded.")
+		fmt.Fprintln(os.Stderr, "No .combineignore files were loaded.")
 	} else {
-		fmt.Println("One or more .combineignore files were successfully loaded.")
+		fmt.Fprintln(os.Stderr, "One or more .combineignore files were successfully loaded.")
 	}
 
 	logger.Debug("Finished loading ignore files", zap.Int("totalPatterns", len(gi.patterns)))
 	return gi, nil
 }
 
+// RejectEmptyPatterns makes subsequent CompileIgnoreLines calls report ErrEmptyPattern for
+// blank lines, which usually stem from an unset variable in a script, instead of skipping them.
+// Ignore files may still contain blank lines.
+func (gi *CombineIgnore) RejectEmptyPatterns() {
+	gi.rejectEmpty = true
+}
+
 // CompileIgnoreLines compiles a set of ignore pattern lines into the CombineIgnore instance.
-func (gi *CombineIgnore) CompileIgnoreLines(lines ...string) {
+// See CompileIgnoreLinesWithSyntax for the lines that cannot be compiled.
+func (gi *CombineIgnore) CompileIgnoreLines(lines ...string) []PatternError {
+	return gi.CompileIgnoreLinesWithSyntax(SyntaxGitignore, lines...)
+}
+
+// CompileIgnoreLinesWithSyntax compiles a set of ignore pattern lines written in the given
+// syntax (SyntaxGitignore, SyntaxGlob, or SyntaxRegex) into the CombineIgnore instance.
+// Lines that cannot be compiled, such as invalid regular expressions or, after
+// RejectEmptyPatterns, blank lines, are skipped and returned as errors, all in one pass.
+func (gi *CombineIgnore) CompileIgnoreLinesWithSyntax(syntax string, lines ...string) []PatternError {
+	var errs []PatternError
 	for i, line := range lines {
-		pattern, negate := parsePatternLine(line, len(gi.patterns)+i+1, gi.logger)
+		if gi.rejectEmpty && strings.TrimSpace(line) == "" {
+			errs = append(errs, PatternError{Line: line, LineNo: i + 1, Err: ErrEmptyPattern})
+			continue
+		}
+		pattern, negate, err := parsePatternLineWithSyntax(line, len(gi.patterns)+i+1, syntax, gi.logger)
+		if err != nil {
+			errs = append(errs, PatternError{Line: line, LineNo: i + 1, Err: err})
+			continue
+		}
 		if pattern != nil {
 			ip := &IgnorePattern{
 				Pattern: pattern,
 				Negate:  negate,
 				LineNo:  len(gi.patterns) + i + 1, // 1-based line numbering.
 				Line:    line,
+				Syntax:  syntax,
 			}
+			ip.prefix, ip.anchored = anchoredPrefix(line, syntax)
 			gi.patterns = append(gi.patterns, ip)
 			gi.logger.Debug("Compiled ignore pattern",
 				zap.Int("lineNo", ip.LineNo),
 				zap.String("pattern", ip.Line),
+				zap.String("syntax", syntax),
 				zap.Bool("negate", ip.Negate))
 		}
 	}
+	return errs
 }
 
 // CompileIgnoreFile reads an ignore file, parses its lines, and compiles them into the CombineIgnore instance.
 func (gi *CombineIgnore) CompileIgnoreFile(filePath string) error {
+	return gi.compileIgnoreFileFS(nil, filePath)
+}
+
+// compileIgnoreFileFS is like CompileIgnoreFile but reads the file from fsys, or from the host
+// filesystem when fsys is nil.
+func (gi *CombineIgnore) compileIgnoreFileFS(fsys fs.FS, filePath string) error {
 	gi.logger.Debug("Starting to compile ignore file", zap.String("filePath", filePath))
-	content, err := os.ReadFile(filePath)
+	content, err := readFileFS(fsys, filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			gi.logger.Debug("Ignore file does not exist and will be skipped", zap.String("filePath", filePath))
@@ -140,14 +227,17 @@ func (gi *CombineIgnore) CompileIgnoreFile(filePath string) error {
 	lines := strings.Split(string(content), "\n")
 	gi.logger.Debug("Read ignore file lines", zap.String("filePath", filePath), zap.Int("lineCount", len(lines)))
 	for i, line := range lines {
-		pattern, negate := parsePatternLine(line, i+1, gi.logger)
+		pattern, negate, _ := parsePatternLine(line, i+1, gi.logger)
 		if pattern != nil {
 			ip := &IgnorePattern{
 				Pattern: pattern,
 				Negate:  negate,
 				LineNo:  i + 1, // 1-based line numbering.
 				Line:    line,
+				Syntax:  SyntaxGitignore,
+				Source:  filePath,
 			}
+			ip.prefix, ip.anchored = anchoredPrefix(line, SyntaxGitignore)
 			gi.patterns = append(gi.patterns, ip)
 			gi.logger.Debug("Compiled ignore pattern from file",
 				zap.String("filePath", filePath),
@@ -164,6 +254,65 @@ func (gi *CombineIgnore) CompileIgnoreFile(filePath string) error {
 	return nil
 }
 
+// Merge returns a new CombineIgnore holding the patterns of gi followed by those of other, so
+// that the patterns of other take precedence as if they had been loaded after those of gi. The
+// result uses the logger and settings of gi and starts with fresh match statistics; neither gi
+// nor other is modified. A nil other yields a copy of gi.
+func (gi *CombineIgnore) Merge(other *CombineIgnore) *CombineIgnore {
+	merged := &CombineIgnore{
+		patterns:    []*IgnorePattern{},
+		logger:      gi.logger,
+		profiling:   gi.profiling,
+		rejectEmpty: gi.rejectEmpty,
+	}
+	sources := [][]*IgnorePattern{gi.patterns}
+	if other != nil {
+		sources = append(sources, other.patterns)
+	}
+	for _, patterns := range sources {
+		for _, pattern := range patterns {
+			copied := *pattern
+			copied.matchCount = 0
+			copied.profile = patternProfile{}
+			merged.patterns = append(merged.patterns, &copied)
+		}
+	}
+	return merged
+}
+
+// WriteIgnoreFile writes the patterns of gi to w in `.combineignore` format, in the order they
+// take effect, so that patterns loaded from several ignore files can be merged into one. Each
+// group of consecutive patterns from the same source is preceded by a "# From: <file>" comment;
+// patterns compiled from lines have no file and are preceded by "# From: lines" instead.
+// Glob and regex patterns cannot be expressed in gitignore syntax and are written commented out.
+func (gi *CombineIgnore) WriteIgnoreFile(w io.Writer) error {
+	var sb strings.Builder
+	for i, pattern := range gi.patterns {
+		if i == 0 || pattern.Source != gi.patterns[i-1].Source {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			source := pattern.Source
+			if source == "" {
+				source = "lines"
+			}
+			fmt.Fprintf(&sb, "# From: %s\n", source)
+		}
+
+		line := strings.TrimSpace(pattern.Line)
+		if pattern.Syntax != SyntaxGitignore {
+			fmt.Fprintf(&sb, "# %s pattern: %s\n", pattern.Syntax, line)
+			continue
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write ignore file: %w", err)
+	}
+	return nil
+}
+
 // MatchesPath checks if the given path matches any of the ignore patterns.
 func (gi *CombineIgnore) MatchesPath(path string) bool {
 	matches, _ := gi.MatchesPathWithPattern(path)
@@ -180,7 +329,11 @@ func (gi *CombineIgnore) MatchesPathWithPattern(path string) (bool, *IgnorePatte
 	var matchedPattern *IgnorePattern
 
 	for _, pattern := range gi.patterns {
-		if pattern.Pattern.MatchString(normalizedPath) {
+		// Anchored patterns cannot match paths outside their literal prefix; skip the regex for those
+		if pattern.anchored && !strings.HasPrefix(normalizedPath, pattern.prefix) {
+			continue
+		}
+		if gi.matchPattern(pattern, normalizedPath) {
 			gi.logger.Debug("Path matches pattern",
 				zap.String("path", normalizedPath),
 				zap.String("pattern", pattern.Line),
@@ -204,15 +357,198 @@ func (gi *CombineIgnore) MatchesPathWithPattern(path string) (bool, *IgnorePatte
 	return matched, matchedPattern
 }
 
+// trieIgnoreMinPatterns is the number of patterns from which a TrieIgnore index pays off, as
+// measured by BenchmarkTrieIgnore: with root-relative patterns it matches about 8 times faster
+// at 100 and 50 times faster at 1000, while for a few dozen patterns the gain is small enough
+// that building the index is not worth it. With many floating patterns, which the index cannot
+// skip, it saves about a quarter at any size.
+const trieIgnoreMinPatterns = 100
+
+// trieNode is a node in the path component trie of a TrieIgnore.
+type trieNode struct {
+	children map[string]*trieNode
+	patterns []int // Indices of patterns whose literal prefix ends at this node.
+}
+
+// TrieIgnore indexes the literal path prefixes of root-relative gitignore patterns into a
+// trie of path components, so that only patterns whose prefix matches the path are evaluated.
+// Patterns without a literal prefix (floating patterns, or those starting with a wildcard such
+// as "**" or "?") fall back to regex evaluation for every path. Matching semantics, including
+// negation order, are identical to the underlying CombineIgnore.
+type TrieIgnore struct {
+	gi       *CombineIgnore
+	root     *trieNode
+	fallback []int // Indices of patterns that are evaluated for every path.
+}
+
+// NewTrieIgnore builds a trie index over the patterns currently compiled into gi.
+// Patterns compiled into gi afterwards are not visible to the returned TrieIgnore.
+func NewTrieIgnore(gi *CombineIgnore) *TrieIgnore {
+	t := &TrieIgnore{gi: gi, root: &trieNode{}}
+	for i, pattern := range gi.patterns {
+		prefix := literalPrefix(pattern)
+		if len(prefix) == 0 {
+			t.fallback = append(t.fallback, i)
+			continue
+		}
+
+		node := t.root
+		for _, component := range prefix {
+			if node.children == nil {
+				node.children = make(map[string]*trieNode)
+			}
+			child, ok := node.children[component]
+			if !ok {
+				child = &trieNode{}
+				node.children[component] = child
+			}
+			node = child
+		}
+		node.patterns = append(node.patterns, i)
+	}
+
+	gi.logger.Debug("Built ignore pattern trie",
+		zap.Int("indexedPatterns", len(gi.patterns)-len(t.fallback)),
+		zap.Int("fallbackPatterns", len(t.fallback)))
+	return t
+}
+
+// literalPrefix returns the leading path components of a root-relative gitignore pattern
+// that contain no wildcards. It returns nil if the pattern cannot be prefix-indexed.
+func literalPrefix(pattern *IgnorePattern) []string {
+	if pattern.Syntax != SyntaxGitignore {
+		return nil
+	}
+
+	line := strings.TrimPrefix(strings.TrimSpace(pattern.Line), "!")
+	if !isRootRelative(line) {
+		return nil // Floating patterns may match at any depth
+	}
+
+	var prefix []string
+	for _, component := range strings.Split(strings.Trim(line, "/"), "/") {
+		if component == "" || strings.ContainsAny(component, `*?[\`) {
+			break
+		}
+		prefix = append(prefix, component)
+	}
+	return prefix
+}
+
+// MatchesPath checks if the given path matches any of the ignore patterns.
+func (t *TrieIgnore) MatchesPath(path string) bool {
+	matches, _ := t.MatchesPathWithPattern(path)
+	return matches
+}
+
+// MatchesPathWithPattern checks if the given path matches any ignore pattern, evaluating only
+// the fallback patterns and the patterns whose literal prefix is a prefix of the path.
+func (t *TrieIgnore) MatchesPathWithPattern(path string) (bool, *IgnorePattern) {
+	normalizedPath := normalizePath(path)
+
+	candidates := append([]int{}, t.fallback...)
+	node := t.root
+	for _, component := range strings.Split(strings.Trim(normalizedPath, "/"), "/") {
+		child, ok := node.children[component]
+		if !ok {
+			break
+		}
+		node = child
+		candidates = append(candidates, node.patterns...)
+	}
+	sort.Ints(candidates) // Later patterns take precedence, so keep the original order
+
+	matched := false
+	var matchedPattern *IgnorePattern
+	for _, i := range candidates {
+		pattern := t.gi.patterns[i]
+		if t.gi.matchPattern(pattern, normalizedPath) {
+			matched = !pattern.Negate
+			matchedPattern = pattern
+		}
+	}
+	return matched, matchedPattern
+}
+
+// MatchesDirectory checks if the directory at relPath is ignored. A directory is ignored when
+// the path itself, the path with a trailing slash, or any of its parent directories matches,
+// since all descendants of an ignored directory are ignored as well.
+func (gi *CombineIgnore) MatchesDirectory(relPath string) bool {
+	return matchesDirectory(gi, relPath)
+}
+
+// MatchesDirectory checks if the directory at relPath is ignored; see CombineIgnore.MatchesDirectory.
+func (t *TrieIgnore) MatchesDirectory(relPath string) bool {
+	return matchesDirectory(t, relPath)
+}
+
+// ChainedParser composes several ignore parsers, e.g. a CombineIgnore and a parser for other
+// ignore files, testing them in order. A path is ignored if any of them ignores it, so a
+// negation in one parser cannot re-include a path ignored by another.
+type ChainedParser []IgnoreParser
+
+// MatchesPath reports whether any of the parsers matches path.
+func (c ChainedParser) MatchesPath(path string) bool {
+	for _, p := range c {
+		if p.MatchesPath(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesPathWithPattern returns the pattern of the first parser that matches path.
+func (c ChainedParser) MatchesPathWithPattern(path string) (bool, *IgnorePattern) {
+	for _, p := range c {
+		if matched, pattern := p.MatchesPathWithPattern(path); matched {
+			return true, pattern
+		}
+	}
+	return false, nil
+}
+
+// MatchesDirectory reports whether any of the parsers ignores the directory at relPath.
+func (c ChainedParser) MatchesDirectory(relPath string) bool {
+	for _, p := range c {
+		if p.MatchesDirectory(relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDirectory implements MatchesDirectory on top of the MatchesPath method of p.
+func matchesDirectory(p IgnoreParser, relPath string) bool {
+	trimmed := strings.Trim(filepath.ToSlash(relPath), "/")
+	if trimmed == "" || trimmed == "." {
+		return false
+	}
+
+	components := strings.Split(trimmed, "/")
+	for i := range components {
+		if p.MatchesPath(strings.Join(components[:i+1], "/") + "/") {
+			return true
+		}
+	}
+	return p.MatchesPath(trimmed)
+}
+
 // parsePatternLine processes a single line from an ignore file and returns
 // a compiled regular expression and a negation flag.
-// Returns nil if the line is a comment or empty.
-func parsePatternLine(line string, lineNo int, logger *zap.Logger) (*regexp.Regexp, bool) {
+// Returns nil if the line is a comment or empty, and an error, which is also logged, if the
+// pattern cannot be compiled.
+func parsePatternLine(line string, lineNo int, logger *zap.Logger) (*regexp.Regexp, bool, error) {
+	return parsePatternLineWithSyntax(line, lineNo, SyntaxGitignore, logger)
+}
+
+// parsePatternLineWithSyntax is like parsePatternLine but translates the pattern
+// using the strategy registered for syntax in patternTranslators.
+func parsePatternLineWithSyntax(line string, lineNo int, syntax string, logger *zap.Logger) (*regexp.Regexp, bool, error) {
 	trimmedLine := strings.TrimSpace(line)
 
 	// Ignore empty lines and comments
 	if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
-		return nil, false
+		return nil, false, nil
 	}
 
 	// Handle negation
@@ -222,30 +558,51 @@ func parsePatternLine(line string, lineNo int, logger *zap.Logger) (*regexp.Rege
 		trimmedLine = strings.TrimPrefix(trimmedLine, "!")
 	}
 
-	// Escape special characters in the pattern
-	escapedLine := escapeSpecialChars(trimmedLine)
-
-	// Replace '**' patterns with appropriate regex
-	escapedLine = handleDoubleStarPatterns(escapedLine)
-
-	// Convert wildcards '*' and '?' to regex equivalents
-	regexPattern := wildcardToRegex(escapedLine)
-
-	// Anchor the pattern to match the entire path
-	regexPattern = anchorPattern(regexPattern, trimmedLine)
+	translate, ok := patternTranslators[syntax]
+	if !ok {
+		logger.Error("Unsupported ignore pattern syntax",
+			zap.String("syntax", syntax),
+			zap.String("pattern", trimmedLine),
+			zap.Int("lineNo", lineNo),
+		)
+		return nil, false, fmt.Errorf("unsupported ignore pattern syntax %q", syntax)
+	}
 
 	// Compile the regex
-	compiledRegex, err := regexp.Compile("^" + regexPattern)
+	compiledRegex, err := regexp.Compile(translate(trimmedLine))
 	if err != nil {
 		logger.Error("Invalid regex pattern",
 			zap.String("pattern", trimmedLine),
+			zap.String("syntax", syntax),
 			zap.Int("lineNo", lineNo),
 			zap.Error(err),
 		)
-		return nil, false
+		return nil, false, err
+	}
+
+	return compiledRegex, negate, nil
+}
+
+// ValidatePatternLine checks a single gitignore-style ignore file line without adding it to any
+// ignore state. It returns the regular expression the pattern compiles to, or an empty string for
+// blank lines and comments, and an error describing why the pattern is invalid.
+func ValidatePatternLine(line string) (string, error) {
+	trimmedLine := strings.TrimSpace(line)
+	if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
+		return "", nil
 	}
 
-	return compiledRegex, negate
+	pattern := strings.TrimPrefix(trimmedLine, "!")
+	if strings.TrimSpace(pattern) == "" {
+		return "", fmt.Errorf("pattern %q is empty after removing the negation", trimmedLine)
+	}
+
+	expr := gitignoreToRegex(pattern)
+	compiled, err := regexp.Compile(expr)
+	if err != nil {
+		return "", fmt.Errorf("pattern %q does not compile to a valid regular expression: %w", trimmedLine, err)
+	}
+	return compiled.String(), nil
 }
 
 // normalizePath normalizes the path for matching.
@@ -0,0 +1,117 @@
+// File: pkg/combine/ignore_bench_test.go
+package combine_test
+
+import (
+	"fmt"
+	"testing"
+
+	"agentexec/pkg/combine"
+	"agentexec/pkg/combine/combinetest"
+)
+
+// benchmarkPatterns returns n gitignore patterns, root-relative with a literal prefix as found
+// in large monorepo ignore files. If floating is set, one in five is floating instead.
+func benchmarkPatterns(n int, floating bool) []string {
+	patterns := make([]string, n)
+	for i := range patterns {
+		switch {
+		case floating && i%10 == 0:
+			patterns[i] = fmt.Sprintf("*.gen%d", i)
+		case floating && i%10 == 1:
+			patterns[i] = fmt.Sprintf("**/cache%d/", i)
+		default:
+			patterns[i] = fmt.Sprintf("/services/svc%d/build/*.o", i)
+		}
+	}
+	return patterns
+}
+
+// benchmarkPaths returns n relative file paths spread over services and other top-level
+// directories.
+func benchmarkPaths(n int) []string {
+	paths := make([]string, n)
+	for i := range paths {
+		switch i % 4 {
+		case 0:
+			paths[i] = fmt.Sprintf("services/svc%d/build/main%d.o", i%1000, i)
+		case 1:
+			paths[i] = fmt.Sprintf("services/svc%d/src/handler%d.go", i%1000, i)
+		case 2:
+			paths[i] = fmt.Sprintf("web/src/components/c%d/index.tsx", i)
+		default:
+			paths[i] = fmt.Sprintf("docs/guide/page%d.md", i)
+		}
+	}
+	return paths
+}
+
+// benchmarkMatch matches every path against parser b.N times, reporting paths per second.
+func benchmarkMatch(b *testing.B, parser combine.IgnoreParser, paths []string) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, path := range paths {
+			parser.MatchesPath(path)
+		}
+	}
+	b.ReportMetric(float64(b.N*len(paths))/b.Elapsed().Seconds(), "paths/s")
+}
+
+// BenchmarkTrieIgnore compares matching with and without the trie index for increasing numbers
+// of patterns, to choose the number from which the index is used. Floating patterns are
+// evaluated for every path either way, so the index only pays off for root-relative ones.
+func BenchmarkTrieIgnore(b *testing.B) {
+	paths := benchmarkPaths(1000)
+	for _, floating := range []bool{false, true} {
+		for _, n := range []int{10, 50, 100, 500, 1000} {
+			gi := combinetest.NewTestCombineIgnore(benchmarkPatterns(n, floating))
+			name := fmt.Sprintf("floating=%t/patterns=%d", floating, n)
+			b.Run(name+"/CombineIgnore", func(b *testing.B) {
+				benchmarkMatch(b, gi, paths)
+			})
+			b.Run(name+"/TrieIgnore", func(b *testing.B) {
+				benchmarkMatch(b, combine.NewTrieIgnore(gi), paths)
+			})
+		}
+	}
+}
+
+// BenchmarkCompileIgnore measures compiling 500 patterns, with and without building the trie
+// index over them.
+func BenchmarkCompileIgnore(b *testing.B) {
+	patterns := benchmarkPatterns(500, true)
+	b.Run("CombineIgnore", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			combinetest.NewTestCombineIgnore(patterns)
+		}
+	})
+	b.Run("TrieIgnore", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			combine.NewTrieIgnore(combinetest.NewTestCombineIgnore(patterns))
+		}
+	})
+}
+
+// BenchmarkMatchesPath measures a single match against 500 patterns, cycling through 10,000
+// paths, so that ns/op and allocs/op are per path.
+func BenchmarkMatchesPath(b *testing.B) {
+	paths := benchmarkPaths(10000)
+	gi := combinetest.NewTestCombineIgnore(benchmarkPatterns(500, true))
+	for _, parser := range []struct {
+		name string
+		combine.IgnoreParser
+	}{
+		{"CombineIgnore", gi},
+		{"TrieIgnore", combine.NewTrieIgnore(gi)},
+	} {
+		b.Run(parser.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				parser.MatchesPath(paths[i%len(paths)])
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "paths/s")
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// File: pkg/combine/ignore_test.go
+package combine_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"agentexec/pkg/combine/combinetest"
+)
+
+// TestCombineIgnoreAnchoring checks that patterns with a slash at the start or in the middle
+// only match relative to the root, while slash-free patterns match at any depth.
+func TestCombineIgnoreAnchoring(t *testing.T) {
+	gi := combinetest.NewTestCombineIgnore([]string{"/build", "src/lib/*.go", "*.log", "tmp/"})
+	combinetest.AssertIgnored(t, gi,
+		"build",
+		"build/out.bin",
+		"src/lib/util.go",
+		"debug.log",
+		"pkg/trace/run.log",
+		"tmp/",
+		"pkg/tmp/",
+	)
+	combinetest.AssertNotIgnored(t, gi,
+		"pkg/build",
+		"pkg/src/lib/util.go",
+		"src/lib/sub/util.go",
+		"src/lib/util.txt",
+		"tmp.txt",
+	)
+}
+
+// TestMatchesPathPlatformSeparators checks that paths using the platform separator are matched
+// like slash-separated ones. The paths are written with slashes and converted with
+// filepath.FromSlash, so that they use backslashes when the test runs on Windows.
+func TestMatchesPathPlatformSeparators(t *testing.T) {
+	gi := combinetest.NewTestCombineIgnore([]string{"src/lib/*.go", "!src/lib/keep.go", "**/testdata/*.txt", "docs/**"})
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "src/lib/main.go", want: true},
+		{path: "src/lib/keep.go", want: false},
+		{path: "src/lib/sub/main.go", want: false},
+		{path: "src/main.go", want: false},
+		{path: "cmd/testdata/input.txt", want: true},
+		{path: "testdata/input.txt", want: true},
+		{path: "docs/guide/intro.md", want: true},
+		{path: "pkg/docs/intro.md", want: false},
+	}
+	for _, tt := range tests {
+		path := filepath.FromSlash(tt.path)
+		if got := gi.MatchesPath(path); got != tt.want {
+			t.Errorf("MatchesPath(%q) = %v, want %v", path, got, tt.want)
+		}
+	}
+}
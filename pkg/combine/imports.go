@@ -0,0 +1,65 @@
+// File: pkg/combine/imports.go
+package combine
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"go.uber.org/zap"
+	"golang.org/x/tools/go/packages"
+)
+
+// GoImportGraphFiles returns the absolute paths of all Go source files belonging to the
+// given package pattern and its transitive imports, excluding the standard library.
+func GoImportGraphFiles(pkgPattern string, logger *zap.Logger) (map[string]bool, error) {
+	logger.Debug("Loading Go import graph", zap.String("package", pkgPattern))
+
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedModule,
+	}
+	pkgs, err := packages.Load(cfg, pkgPattern)
+	if err != nil {
+		logger.Error("Failed to load Go package", zap.String("package", pkgPattern), zap.Error(err))
+		return nil, fmt.Errorf("failed to load Go package %s: %w", pkgPattern, err)
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no Go packages match %s", pkgPattern)
+	}
+
+	files := make(map[string]bool)
+	packageCount := 0
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, pkgErr := range pkg.Errors {
+			logger.Warn("Go package loaded with errors", zap.String("package", pkg.PkgPath), zap.String("error", pkgErr.Msg))
+		}
+
+		// Standard library packages do not belong to a module
+		if pkg.Module == nil {
+			return
+		}
+
+		packageCount++
+		for _, file := range pkg.GoFiles {
+			if absFile, err := filepath.Abs(file); err == nil {
+				files[absFile] = true
+			}
+		}
+	})
+
+	logger.Debug("Loaded Go import graph",
+		zap.String("package", pkgPattern),
+		zap.Int("packages", packageCount),
+		zap.Int("files", len(files)))
+	return files, nil
+}
+
+// filterToFileSet returns the files that are present in the allowed set, preserving order.
+func filterToFileSet(files []string, allowed map[string]bool) []string {
+	var filtered []string
+	for _, file := range files {
+		if allowed[file] {
+			filtered = append(filtered, file)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,149 @@
+// File: pkg/combine/io_profile.go
+package combine
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// ioOp identifies a kind of filesystem operation timed by an ioProfile.
+type ioOp int
+
+const (
+	ioStat        ioOp = iota // os.Stat and DirEntry.Info calls.
+	ioReadDir                 // Directory listings during traversal and tree generation.
+	ioRead                    // Reads of a whole file's content, once per file.
+	ioBinaryCheck             // Reads of a file's leading bytes to detect binary content.
+	ioWrite                   // Writes to the output, tree, and stats files.
+	ioOpCount
+)
+
+// ioOpNames are the names of the operations as written in the report.
+var ioOpNames = [ioOpCount]string{"stat", "readdir", "read", "binary-check", "write"}
+
+// ioHistogramBuckets are the upper bounds of the read time histogram buckets.
+var ioHistogramBuckets = []time.Duration{
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// ioHistogramWidth is the length of the bar drawn for the fullest histogram bucket.
+const ioHistogramWidth = 40
+
+// ioProfile records the latency of every filesystem operation of a combine run. A nil
+// *ioProfile records nothing, so that call sites need not check whether profiling is enabled.
+type ioProfile struct {
+	mu      sync.Mutex
+	samples [ioOpCount][]time.Duration
+}
+
+// newIOProfile returns an empty ioProfile.
+func newIOProfile() *ioProfile {
+	return &ioProfile{}
+}
+
+// observe records an operation of kind op that started at start and has just completed.
+func (p *ioProfile) observe(op ioOp, start time.Time) {
+	if p == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	p.mu.Lock()
+	p.samples[op] = append(p.samples[op], elapsed)
+	p.mu.Unlock()
+}
+
+// writer returns w wrapped so that every Write call is recorded as an ioWrite.
+func (p *ioProfile) writer(w io.Writer) io.Writer {
+	if p == nil {
+		return w
+	}
+	return profiledWriter{w: w, profile: p}
+}
+
+// profiledWriter records the latency of each Write to the underlying writer.
+type profiledWriter struct {
+	w       io.Writer
+	profile *ioProfile
+}
+
+// Write writes b to the underlying writer.
+func (pw profiledWriter) Write(b []byte) (int, error) {
+	start := time.Now()
+	n, err := pw.w.Write(b)
+	pw.profile.observe(ioWrite, start)
+	return n, err
+}
+
+// writeReport writes a table with the count, total, and P50, P95, P99, and maximum latency of
+// each kind of operation to w, followed by a histogram of file read times.
+func (p *ioProfile) writeReport(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OPERATION\tCOUNT\tTOTAL\tP50\tP95\tP99\tMAX")
+	for op, samples := range p.samples {
+		sorted := slices.Clone(samples)
+		slices.Sort(sorted)
+		var total time.Duration
+		for _, sample := range sorted {
+			total += sample
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			ioOpNames[op],
+			len(sorted),
+			total,
+			percentile(sorted, 0.50),
+			percentile(sorted, 0.95),
+			percentile(sorted, 0.99),
+			percentile(sorted, 1))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	reads := p.samples[ioRead]
+	if len(reads) == 0 {
+		return nil
+	}
+
+	counts := make([]int, len(ioHistogramBuckets)+1)
+	for _, sample := range reads {
+		bucket, _ := slices.BinarySearch(ioHistogramBuckets, sample)
+		counts[bucket]++
+	}
+	fullest := slices.Max(counts)
+
+	fmt.Fprintf(w, "\nFile read times (%d files):\n", len(reads))
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, count := range counts {
+		label := "> " + ioHistogramBuckets[len(ioHistogramBuckets)-1].String()
+		if i < len(ioHistogramBuckets) {
+			label = "<= " + ioHistogramBuckets[i].String()
+		}
+		bar := strings.Repeat("#", int(math.Ceil(float64(count)*ioHistogramWidth/float64(fullest))))
+		fmt.Fprintf(tw, "  %s\t%d\t%s\n", label, count, bar)
+	}
+	return tw.Flush()
+}
+
+// percentile returns the sample at quantile q of the sorted samples using the nearest-rank
+// method, or zero if there are none.
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
@@ -0,0 +1,133 @@
+// File: pkg/combine/json_stream.go
+package combine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// jsonStreamLine is a single line of the newline-delimited JSON output.
+type jsonStreamLine struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	SHA256  string `json:"sha256"`
+}
+
+// executeJSONStream writes the tree file and then streams each processed file to args.Output as
+// one JSON object per line, in path order as soon as each file and those before it are processed.
+// Binary files are appended base64-encoded when enabled, followed by stdinData, if not nil, as the
+// virtual stdin file; it comes first instead when prioritized. Each regular file is reported to
+// progress. It completes executeProcess from result.
+func executeJSONStream(ctx context.Context, args Arguments, parser IgnoreParser, collected CollectedFiles, sourceRoot string, opts ProcessOptions, stdinData []byte, progress Progress, result CombineResult, logger *zap.Logger) (CombineResult, error) {
+	treeContent, err := GenerateFullTree(args.Paths, parser, args.treeOptions(), WithLogger(logger))
+	if err != nil {
+		logger.Error("Failed to generate tree structure", zap.Error(err))
+		return result, fmt.Errorf("failed to generate tree structure: %w", err)
+	}
+	treeFileContent, err := renderTreeFile(args, parser, treeContent, logger)
+	if err != nil {
+		return result, err
+	}
+	err = writeOutput(args.Tree, args.ioProfile, logger, func(w io.Writer) error {
+		return writeToFile(w, args.Tree, []byte(treeFileContent), logger)
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to write tree structure: %w", err)
+	}
+	result.TreePath = args.Tree
+
+	failedFiles := 0
+	stdinFiles := 0
+	err = writeOutput(args.Output, args.ioProfile, logger, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		emit := func(content FileContent) error {
+			if args.VirtualRoot != "" {
+				remapped := []FileContent{content}
+				applyVirtualRoot(remapped, args.VirtualRoot, opts)
+				content = remapped[0]
+			}
+			if err := encoder.Encode(jsonStreamLine{Path: content.Path, Content: content.Content, SHA256: content.SHA256}); err != nil {
+				return fmt.Errorf("failed to write %s: %w", content.Path, err)
+			}
+			result.FilesIncluded++
+			result.TotalBytes += int64(len(content.Content))
+			return nil
+		}
+		emitStdin := func() error {
+			stdinFile, ok := stdinContent(stdinData, args.StdinExtension, opts, logger)
+			if !ok {
+				return nil
+			}
+			stdinFiles++
+			return emit(stdinFile)
+		}
+
+		prioritizeStdin := args.Prioritize == PrioritizeStdin
+		if stdinData != nil && prioritizeStdin {
+			if err := emitStdin(); err != nil {
+				return err
+			}
+		}
+
+		// Files are written as they are processed, so the span covers both
+		processCtx, processSpan := tracer.Start(ctx, "agentexec.process_files")
+		failed, err := StreamFilesConcurrently(processCtx, collected.Regular, sourceRoot, opts, emit, WithLogger(logger), WithMaxWorkers(args.MaxWorkers), WithMaxMemoryMB(args.MaxMemoryMB), WithMaxWorkersPerDir(args.MaxWorkersPerDir), WithOrderedResults(true), WithFailFast(args.OnError == OnErrorFail), WithProgress(progress))
+		processSpan.SetAttributes(attribute.Int("agentexec.files.failed", failed))
+		endSpan(processSpan, err)
+		failedFiles += failed
+		if err != nil {
+			return err
+		}
+
+		if args.Base64EncodeBinary {
+			for _, binaryFile := range collected.Binary {
+				encoded, err := EncodeBinaryFile(ctx, binaryFile, sourceRoot, opts, logger)
+				if err != nil && args.OnError == OnErrorFail {
+					return fmt.Errorf("failed to encode binary file %s: %w", binaryFile, err)
+				}
+				if err != nil {
+					logger.Warn("Skipping binary file that could not be encoded", zap.String("filePath", binaryFile), zap.Error(err))
+					failedFiles++
+					continue
+				}
+				if err := emit(encoded); err != nil {
+					return err
+				}
+			}
+		}
+
+		if stdinData != nil && !prioritizeStdin {
+			return emitStdin()
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to write combined file", zap.String("combinedFile", args.Output), zap.Error(err))
+		return result, fmt.Errorf("failed to write combined file: %w", err)
+	}
+	result.OutputPath = args.Output
+
+	// Files dropped by the grep pattern or failing to process count as skipped as well
+	expected := len(collected.Regular) + stdinFiles
+	if args.Base64EncodeBinary {
+		expected += len(collected.Binary)
+	}
+	result.FilesSkipped += expected - result.FilesIncluded
+
+	if failedFiles > 0 {
+		logger.Warn("Some files could not be processed and are missing from the output", zap.Int("failedFiles", failedFiles))
+		result.ExitCode = ExitPartialSuccess
+	}
+
+	logger.Info("Successfully streamed files as JSON lines",
+		zap.String("outputFile", args.Output),
+		zap.Int("totalFiles", result.FilesIncluded),
+	)
+	return result, nil
+}
@@ -0,0 +1,157 @@
+// File: pkg/combine/library.go
+
+// Package combine collects files under a set of paths and combines them, together with a tree
+// of their directory structure, into a single output.
+//
+// The agentexec combine command runs the whole pipeline through ExecuteWithContext. Programs
+// embedding the package can run the steps themselves instead, mixing CollectOptions for
+// traversal, ProcessOptions for reading and formatting files, and TreeOptions for the tree:
+//
+//	gi, err := combine.LoadIgnoreFiles(nil, logger)
+//	if err != nil {
+//		return err
+//	}
+//	paths := []string{"./src"}
+//	collected, err := combine.CollectFiles(paths, gi, combine.CollectOptions{},
+//		combine.WithLogger(logger), combine.WithMaxFileSizeKB(512))
+//	if err != nil {
+//		return err
+//	}
+//	processOpts := combine.DefaultProcessOptions()
+//	processOpts.ComputeHash = true
+//	contents, failed, err := combine.ProcessFilesConcurrently(ctx, collected.Regular, ".",
+//		processOpts, combine.WithMaxWorkers(4))
+//	if err != nil {
+//		return err
+//	}
+//	if failed > 0 {
+//		log.Printf("%d files could not be read", failed)
+//	}
+//	sort.Slice(contents, func(i, j int) bool { return contents[i].Path < contents[j].Path })
+//	tree, err := combine.GenerateFullTree(paths, gi, combine.TreeOptions{})
+//	if err != nil {
+//		return err
+//	}
+//	return combine.WriteCombinedFile(os.Stdout, tree, contents)
+//
+// Options that are not given fall back to the defaults of the command: no logging, one worker
+// per CPU, and files up to DefaultMaxFileSizeKB.
+package combine
+
+import (
+	"go.uber.org/zap"
+)
+
+// DefaultMaxFileSizeKB is the default maximum size, in KB, of the files that are collected.
+const DefaultMaxFileSizeKB = 10240
+
+// DefaultProcessOptions returns the ProcessOptions used by the combine command without flags,
+// which, unlike the zero value, pads section headers with blank lines.
+func DefaultProcessOptions() ProcessOptions {
+	return Arguments{}.processOptions()
+}
+
+// Option configures a call to one of the library entry points CollectFiles,
+// ProcessFilesConcurrently, StreamFilesConcurrently, GenerateFullTree, and WriteCombinedFile.
+// Each entry point ignores the options that do not apply to it.
+type Option func(*options)
+
+// options holds the settings applied by Option values.
+type options struct {
+	logger           *zap.Logger // Logger for progress and diagnostics.
+	maxWorkers       int         // Number of concurrent workers; non-positive means one per CPU.
+	maxFileSizeKB    int         // Maximum size of collected files in KB.
+	verbose          bool        // If true, files skipped during collection are logged with the reason.
+	progress         Progress    // Notified as the worker pool starts and finishes each file.
+	ordered          bool        // If true, the worker pool yields processed files in path order.
+	failFast         bool        // If true, the worker pool stops at the first file that fails to process.
+	maxMemoryMB      int         // If positive, the worker pool pauses handing out files while the heap is near this many MB.
+	maxWorkersPerDir int         // If positive, the most files of one directory processed at once.
+}
+
+// newOptions returns the defaults with opts applied in order.
+func newOptions(opts []Option) options {
+	o := options{
+		logger:        zap.NewNop(),
+		maxFileSizeKB: DefaultMaxFileSizeKB,
+		progress:      NoopProgress{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithLogger sets the logger used for progress and diagnostics; a nil logger disables logging.
+func WithLogger(logger *zap.Logger) Option {
+	return func(o *options) {
+		if logger == nil {
+			logger = zap.NewNop()
+		}
+		o.logger = logger
+	}
+}
+
+// WithMaxWorkers sets the number of files processed concurrently; non-positive means one per CPU.
+func WithMaxWorkers(n int) Option {
+	return func(o *options) {
+		o.maxWorkers = n
+	}
+}
+
+// WithMaxFileSizeKB sets the maximum size, in KB, of the files that are collected.
+func WithMaxFileSizeKB(n int) Option {
+	return func(o *options) {
+		o.maxFileSizeKB = n
+	}
+}
+
+// WithVerbose logs each file skipped during collection, e.g. for its size, at debug level.
+func WithVerbose(verbose bool) Option {
+	return func(o *options) {
+		o.verbose = verbose
+	}
+}
+
+// WithOrderedResults makes the worker pool yield processed files in path order rather than in
+// the order they complete, holding back at most a bounded number of completed files.
+func WithOrderedResults(ordered bool) Option {
+	return func(o *options) {
+		o.ordered = ordered
+	}
+}
+
+// WithFailFast makes the worker pool stop at the first file that fails to process and return its
+// error, instead of skipping the file and counting it as failed.
+func WithFailFast(failFast bool) Option {
+	return func(o *options) {
+		o.failFast = failFast
+	}
+}
+
+// WithMaxMemoryMB makes the worker pool pause handing out files while the Go heap is near n
+// megabytes, resuming once it drops, e.g. after a garbage collection; non-positive disables it.
+func WithMaxMemoryMB(n int) Option {
+	return func(o *options) {
+		o.maxMemoryMB = n
+	}
+}
+
+// WithMaxWorkersPerDir limits the worker pool to processing n files of the same directory at
+// once, e.g. to reduce seeking on spinning disks; non-positive disables it.
+func WithMaxWorkersPerDir(n int) Option {
+	return func(o *options) {
+		o.maxWorkersPerDir = n
+	}
+}
+
+// WithProgress notifies progress as the worker pool starts and finishes each file; a nil
+// progress disables notifications.
+func WithProgress(progress Progress) Option {
+	return func(o *options) {
+		if progress == nil {
+			progress = NoopProgress{}
+		}
+		o.progress = progress
+	}
+}
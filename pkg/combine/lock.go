@@ -0,0 +1,71 @@
+// File: pkg/combine/lock.go
+package combine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ErrOutputLocked is returned by acquireOutputLock if another live process holds the lock.
+var ErrOutputLocked = errors.New("output is locked")
+
+// lockFileName returns the path of the lock file guarding outputPath, e.g. "combined.txt.lock".
+func lockFileName(outputPath string) string {
+	return outputPath + ".lock"
+}
+
+// acquireOutputLock creates the lock file of outputPath holding the ID of this process, and
+// returns a function removing it. A lock file left behind by a process that is no longer
+// running is replaced; one held by a running process fails with ErrOutputLocked.
+func acquireOutputLock(outputPath string, logger *zap.Logger) (func(), error) {
+	lockPath := lockFileName(outputPath)
+	for attempt := 0; ; attempt++ {
+		file, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, writeErr := file.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+			if closeErr := file.Close(); writeErr == nil {
+				writeErr = closeErr
+			}
+			if writeErr != nil {
+				os.Remove(lockPath)
+				return nil, fmt.Errorf("failed to write lock file %s: %w", lockPath, writeErr)
+			}
+			logger.Debug("Acquired output lock", zap.String("lockFile", lockPath))
+			return func() {
+				if err := os.Remove(lockPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+					logger.Warn("Failed to remove lock file", zap.String("lockFile", lockPath), zap.Error(err))
+				}
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) || attempt > 0 {
+			return nil, fmt.Errorf("failed to create lock file %s: %w", lockPath, err)
+		}
+
+		// Replace the lock of a process that exited without removing it
+		if pid, ok := readLockPID(lockPath); ok && processRunning(pid) {
+			return nil, fmt.Errorf("%w: another agentexec process is writing to %s (PID %d)", ErrOutputLocked, outputPath, pid)
+		}
+		logger.Warn("Removing stale lock file", zap.String("lockFile", lockPath))
+		if err := os.Remove(lockPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove stale lock file %s: %w", lockPath, err)
+		}
+	}
+}
+
+// readLockPID returns the process ID recorded in the lock file at path.
+func readLockPID(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	return pid, true
+}
@@ -0,0 +1,44 @@
+// File: pkg/combine/logging.go
+package combine
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// levelBelowCore wraps a core and only lets through entries below a maximum level.
+type levelBelowCore struct {
+	zapcore.Core
+	max zapcore.Level
+}
+
+// Enabled reports whether the level is enabled by the wrapped core and below the maximum level.
+func (c *levelBelowCore) Enabled(level zapcore.Level) bool {
+	return level < c.max && c.Core.Enabled(level)
+}
+
+// With returns a copy of the core with additional context fields.
+func (c *levelBelowCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelBelowCore{Core: c.Core.With(fields), max: c.max}
+}
+
+// Check adds the core to the checked entry when the level is enabled.
+func (c *levelBelowCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level >= c.max {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// NewErrorRedirectCore returns a core that keeps entries below error level on core and
+// writes error and higher level entries as JSON lines to errOut instead.
+func NewErrorRedirectCore(core zapcore.Core, errOut zapcore.WriteSyncer) zapcore.Core {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.TimeKey = "timestamp"
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	return zapcore.NewTee(
+		&levelBelowCore{Core: core, max: zapcore.ErrorLevel},
+		zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), errOut, zapcore.ErrorLevel),
+	)
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+// File: pkg/combine/longpath_other.go
+package combine
+
+// makeWindowsLongPath returns path unchanged; paths are only length-limited on Windows.
+func makeWindowsLongPath(path string) string {
+	return path
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+// File: pkg/combine/longpath_windows.go
+package combine
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// windowsMaxPath is MAX_PATH, the length limit of paths passed to the Win32 API without the
+// long path prefix, including the terminating NUL.
+const windowsMaxPath = 260
+
+// makeWindowsLongPath prepends the `\\?\` prefix to paths of MAX_PATH characters or more, so
+// that the Win32 API accepts them. The prefix disables path normalization, so the path is made
+// absolute and cleaned first; UNC paths take the `\\?\UNC\` form.
+func makeWindowsLongPath(path string) string {
+	if len(path) < windowsMaxPath || strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if strings.HasPrefix(absPath, `\\`) {
+		return `\\?\UNC\` + absPath[len(`\\`):]
+	}
+	return `\\?\` + absPath
+}
@@ -0,0 +1,117 @@
+// File: pkg/combine/memory.go
+package combine
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// memoryPollInterval is how often the memory monitor samples the heap.
+const memoryPollInterval = 20 * time.Millisecond
+
+// memoryHighWaterPercent is the share of the memory limit at which dispatching pauses.
+const memoryHighWaterPercent = 90
+
+// memoryMonitor paces the dispatch of files to the worker pool by heap usage. A monitoring
+// goroutine hands out permits on a channel while the heap is below the high-water mark and
+// withholds them, until the heap drops again, while it is above.
+type memoryMonitor struct {
+	permits    chan struct{}
+	highWater  uint64
+	dispatched atomic.Int64 // Files handed out to the workers.
+	completed  atomic.Int64 // Files whose result has been received.
+	warned     bool         // Whether exceeding the limit with no file in flight was reported; only used by run.
+	logger     *zap.Logger
+}
+
+// startMemoryMonitor starts monitoring the heap against a limit of limitMB megabytes until ctx
+// is cancelled.
+func startMemoryMonitor(ctx context.Context, limitMB int, logger *zap.Logger) *memoryMonitor {
+	m := &memoryMonitor{
+		permits:   make(chan struct{}),
+		highWater: uint64(limitMB) * 1024 * 1024 * memoryHighWaterPercent / 100,
+		logger:    logger,
+	}
+	go m.run(ctx)
+	return m
+}
+
+// acquire waits until the next file may be dispatched, reporting false if ctx is cancelled first.
+func (m *memoryMonitor) acquire(ctx context.Context) bool {
+	select {
+	case <-m.permits:
+		m.dispatched.Add(1)
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// done records that the result of a dispatched file has been received.
+func (m *memoryMonitor) done() {
+	m.completed.Add(1)
+}
+
+// run hands out permits while the heap is below the high-water mark, sampling it every
+// memoryPollInterval.
+func (m *memoryMonitor) run(ctx context.Context) {
+	ticker := time.NewTicker(memoryPollInterval)
+	defer ticker.Stop()
+
+	paused := false
+	for {
+		if paused {
+			select {
+			case <-ticker.C:
+				paused = m.overLimit()
+				if !paused {
+					m.logger.Debug("Heap usage dropped, resuming file dispatch")
+				}
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		select {
+		case m.permits <- struct{}{}:
+		case <-ticker.C:
+			paused = m.overLimit()
+			if paused {
+				m.logger.Debug("Heap usage near memory limit, pausing file dispatch",
+					zap.Uint64("highWaterBytes", m.highWater),
+					zap.Int64("inFlight", m.dispatched.Load()-m.completed.Load()))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// overLimit reports whether the heap is above the high-water mark even after a garbage collection.
+// With no file in flight nothing would free memory by waiting, so dispatching continues then.
+func (m *memoryMonitor) overLimit() bool {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if stats.HeapAlloc < m.highWater {
+		return false
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	if stats.HeapAlloc < m.highWater {
+		return false
+	}
+	if m.dispatched.Load() == m.completed.Load() {
+		if !m.warned {
+			m.warned = true
+			m.logger.Warn("Processed files alone exceed the memory limit, continuing one batch at a time",
+				zap.Uint64("heapAllocBytes", stats.HeapAlloc),
+				zap.Uint64("highWaterBytes", m.highWater))
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+// File: pkg/combine/notify.go
+package combine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// notifyTimeout bounds how long sending a desktop notification may take.
+const notifyTimeout = 10 * time.Second
+
+// windowsToastScript shows a toast notification whose title and message are read from the
+// environment, so that neither needs quoting for PowerShell.
+const windowsToastScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:AGENTEXEC_NOTIFY_TITLE)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode($env:AGENTEXEC_NOTIFY_MESSAGE)) > $null
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('agentexec').Show([Windows.UI.Notifications.ToastNotification]::new($template))`
+
+// NotifyDone sends a desktop notification summarizing a completed combine run. Failures,
+// including a missing notification tool, are only logged at debug level.
+func NotifyDone(ctx context.Context, result CombineResult, logger *zap.Logger) {
+	message := fmt.Sprintf("Combined %d files in %s", result.FilesIncluded, result.Duration.Round(time.Millisecond))
+	if result.OutputPath != "" && result.OutputPath != StdoutPath {
+		message += " into " + result.OutputPath
+	}
+	if err := sendNotification(ctx, "agentexec combine finished", message); err != nil {
+		logger.Debug("Failed to send desktop notification", zap.String("os", runtime.GOOS), zap.Error(err))
+	}
+}
+
+// sendNotification shows a desktop notification with the native tool of the running OS.
+func sendNotification(ctx context.Context, title, message string) error {
+	var name string
+	var args, env []string
+	switch runtime.GOOS {
+	case "darwin":
+		// Passing the strings as arguments avoids quoting them inside the AppleScript source
+		name = "osascript"
+		args = []string{
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, message,
+		}
+	case "linux":
+		name = "notify-send"
+		args = []string{title, message}
+	case "windows":
+		name = "powershell"
+		args = []string{"-NoProfile", "-NonInteractive", "-Command", windowsToastScript}
+		env = append(os.Environ(), "AGENTEXEC_NOTIFY_TITLE="+title, "AGENTEXEC_NOTIFY_MESSAGE="+message)
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return fmt.Errorf("notification tool not available: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = env
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", name, err, output)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// File: pkg/combine/output_template.go
+package combine
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"go.uber.org/zap"
+)
+
+// TemplateData is the value passed to user-provided output templates.
+type TemplateData struct {
+	Tree  string        // Rendered directory tree.
+	Files []FileContent // Processed files in output order.
+}
+
+// templateFuncs are the helper functions available to output templates.
+var templateFuncs = template.FuncMap{
+	// json encodes a value as JSON, which lets JSON templates embed file content safely.
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// LoadOutputTemplate loads the template for the given output format from dir.
+// Templates are located by convention as DIR/<format>.tmpl (e.g. DIR/text.tmpl, DIR/json.tmpl).
+// It returns a nil template when dir is empty or the template file does not exist,
+// in which case the built-in output for the format should be used.
+func LoadOutputTemplate(dir, format string, logger *zap.Logger) (*template.Template, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if format == "" {
+		format = FormatText
+	}
+
+	templatePath := filepath.Join(dir, format+".tmpl")
+	content, err := os.ReadFile(templatePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			logger.Debug("No output template for format, using built-in default",
+				zap.String("format", format),
+				zap.String("templatePath", templatePath))
+			return nil, nil
+		}
+		logger.Error("Failed to read output template", zap.String("templatePath", templatePath), zap.Error(err))
+		return nil, fmt.Errorf("failed to read output template %s: %w", templatePath, err)
+	}
+
+	tmpl, err := template.New(filepath.Base(templatePath)).Funcs(templateFuncs).Parse(string(content))
+	if err != nil {
+		logger.Error("Failed to parse output template", zap.String("templatePath", templatePath), zap.Error(err))
+		return nil, fmt.Errorf("failed to parse output template %s: %w", templatePath, err)
+	}
+
+	logger.Debug("Loaded output template", zap.String("format", format), zap.String("templatePath", templatePath))
+	return tmpl, nil
+}
+
+// WriteTemplatedFile renders the tree and file contents through tmpl into w.
+func WriteTemplatedFile(w io.Writer, tmpl *template.Template, treeContent string, combinedContents []FileContent, logger *zap.Logger) error {
+	logger.Debug("Writing templated content", zap.String("template", tmpl.Name()))
+
+	writer := bufio.NewWriter(w)
+	data := TemplateData{Tree: treeContent, Files: combinedContents}
+	if err := tmpl.Execute(writer, data); err != nil {
+		logger.Error("Failed to execute output template", zap.String("template", tmpl.Name()), zap.Error(err))
+		return fmt.Errorf("failed to execute output template: %w", err)
+	}
+
+	if err := writer.Flush(); err != nil {
+		logger.Error("Failed to flush templated output", zap.Error(err))
+		return fmt.Errorf("failed to flush output: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+// File: pkg/combine/parse.go
+package combine
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// sectionHeaderPattern matches the separator and Source lines of a section header written by
+// sectionHeader. Both lines must start with the same comment prefix, which is checked separately.
+var sectionHeaderPattern = regexp.MustCompile(`(?m)^(\S+) -+\n(\S+) Source: (.*) (\S+)\n`)
+
+// ParseCombinedFile reads text output as written by WriteCombinedFile and returns the content of
+// each file keyed by its source path. The tree and anything else before the first section header
+// is skipped. Headers are assumed to use the default section padding.
+func ParseCombinedFile(r io.Reader) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read combined file: %w", err)
+	}
+	text := string(data)
+
+	var headers [][]int
+	for _, match := range sectionHeaderPattern.FindAllStringSubmatchIndex(text, -1) {
+		prefix := text[match[2]:match[3]]
+		if text[match[4]:match[5]] == prefix && text[match[8]:match[9]] == prefix {
+			headers = append(headers, match)
+		}
+	}
+
+	files := make(map[string]string, len(headers))
+	for i, header := range headers {
+		start := header[1]
+		for n := 0; n < DefaultSectionPaddingAfter && strings.HasPrefix(text[start:], "\n"); n++ {
+			start++
+		}
+		end := len(text)
+		if i+1 < len(headers) {
+			end = headers[i+1][0]
+			for n := 0; n < DefaultSectionPaddingBefore && end > start && text[end-1] == '\n'; n++ {
+				end--
+			}
+		}
+		path := text[header[6]:header[7]]
+		if _, ok := files[path]; ok {
+			return nil, fmt.Errorf("combined file contains %s more than once", path)
+		}
+		files[path] = text[start:end]
+	}
+	return files, nil
+}
+
+// CombinedDiff lists the differences between two combined outputs.
+type CombinedDiff struct {
+	Added    []DiffFile `json:"added"`
+	Removed  []DiffFile `json:"removed"`
+	Modified []DiffFile `json:"modified"`
+}
+
+// DiffFile is a file that was added, removed, or modified.
+type DiffFile struct {
+	Path      string `json:"path"`
+	OldLines  int    `json:"old_lines"`
+	NewLines  int    `json:"new_lines"`
+	LineDelta int    `json:"line_delta"`
+}
+
+// DiffCombined compares the files of two combined outputs as returned by ParseCombinedFile.
+// Each list in the result is sorted by path.
+func DiffCombined(oldFiles, newFiles map[string]string) CombinedDiff {
+	diff := CombinedDiff{Added: []DiffFile{}, Removed: []DiffFile{}, Modified: []DiffFile{}}
+	for path, newContent := range newFiles {
+		oldContent, ok := oldFiles[path]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, newDiffFile(path, "", newContent))
+		case oldContent != newContent:
+			diff.Modified = append(diff.Modified, newDiffFile(path, oldContent, newContent))
+		}
+	}
+	for path, oldContent := range oldFiles {
+		if _, ok := newFiles[path]; !ok {
+			diff.Removed = append(diff.Removed, newDiffFile(path, oldContent, ""))
+		}
+	}
+	for _, files := range [][]DiffFile{diff.Added, diff.Removed, diff.Modified} {
+		sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	}
+	return diff
+}
+
+// changedContents returns the contents whose file is new or whose content hash differs from the
+// file at the same path in previous, as returned by ParseCombinedFile, along with the sorted
+// paths of the previous files that are no longer among contents.
+func changedContents(contents []FileContent, previous map[string]string) ([]FileContent, []string) {
+	current := make(map[string]struct{}, len(contents))
+	var changed []FileContent
+	for _, content := range contents {
+		current[content.Path] = struct{}{}
+		oldContent, ok := previous[content.Path]
+		if ok && sha256.Sum256([]byte(oldContent)) == sha256.Sum256([]byte(content.Content)) {
+			continue
+		}
+		changed = append(changed, content)
+	}
+
+	var deleted []string
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			deleted = append(deleted, path)
+		}
+	}
+	sort.Strings(deleted)
+	return changed, deleted
+}
+
+// loadPreviousCombined parses the combined output file at path for --diff-output.
+func loadPreviousCombined(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open previous combined file: %w", err)
+	}
+	defer file.Close()
+
+	files, err := ParseCombinedFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse previous combined file %s: %w", path, err)
+	}
+	return files, nil
+}
+
+// deletedFilesNote returns a blank line followed by one "# Deleted: path" line per deleted file,
+// or the empty string if no file was deleted.
+func deletedFilesNote(deleted []string) string {
+	if len(deleted) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n")
+	for _, path := range deleted {
+		b.WriteString(DefaultCommentPrefix + " Deleted: " + path + "\n")
+	}
+	return b.String()
+}
+
+// newDiffFile describes the file at path with its old and new content.
+func newDiffFile(path, oldContent, newContent string) DiffFile {
+	oldLines, newLines := countLines(oldContent), countLines(newContent)
+	return DiffFile{Path: path, OldLines: oldLines, NewLines: newLines, LineDelta: newLines - oldLines}
+}
@@ -0,0 +1,57 @@
+// File: pkg/combine/paths_file.go
+package combine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadPathsFile reads the paths to combine from the file at path, or from standard input if path
+// is StdinPath. Each line holds one path; blank lines and lines starting with "#" are skipped.
+func ReadPathsFile(path string) ([]string, error) {
+	if path == StdinPath {
+		return readPaths(os.Stdin)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open paths file: %w", err)
+	}
+	defer file.Close()
+	return readPaths(file)
+}
+
+// readPaths returns the non-blank, non-comment lines of r with surrounding whitespace removed.
+func readPaths(r io.Reader) ([]string, error) {
+	var paths []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read paths: %w", err)
+	}
+	return paths, nil
+}
+
+// DedupPaths returns paths without the entries that name the same cleaned path as an earlier one.
+func DedupPaths(paths []string) []string {
+	seen := make(map[string]struct{}, len(paths))
+	unique := make([]string, 0, len(paths))
+	for _, path := range paths {
+		key := filepath.Clean(path)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		unique = append(unique, path)
+	}
+	return unique
+}
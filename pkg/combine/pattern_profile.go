@@ -0,0 +1,120 @@
+// File: pkg/combine/pattern_profile.go
+package combine
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// patternProfile holds evaluation statistics for a single ignore pattern.
+type patternProfile struct {
+	tested  int           // Number of paths tested against the pattern.
+	matched int           // Number of paths the pattern matched.
+	elapsed time.Duration // Total time spent evaluating the pattern's regex.
+}
+
+// EnableProfiling starts recording per-pattern evaluation statistics for subsequent matches.
+func (gi *CombineIgnore) EnableProfiling() {
+	gi.profiling = true
+}
+
+// matchPattern evaluates a single pattern against a normalized path,
+// recording statistics when profiling is enabled.
+func (gi *CombineIgnore) matchPattern(pattern *IgnorePattern, normalizedPath string) bool {
+	if !gi.profiling {
+		matched := pattern.Pattern.MatchString(normalizedPath)
+		if matched {
+			pattern.matchCount++
+		}
+		return matched
+	}
+
+	start := time.Now()
+	matched := pattern.Pattern.MatchString(normalizedPath)
+	pattern.profile.elapsed += time.Since(start)
+	pattern.profile.tested++
+	if matched {
+		pattern.profile.matched++
+		pattern.matchCount++
+	}
+	return matched
+}
+
+// UnmatchedPatterns returns the patterns that have not matched any path so far,
+// excluding the built-in VCS directory and JavaScript artifact patterns, which are applied as a set.
+func (gi *CombineIgnore) UnmatchedPatterns() []*IgnorePattern {
+	builtin := make(map[string]bool, len(VCSDirectoryPatterns)+len(JSArtifactPatterns))
+	for _, pattern := range VCSDirectoryPatterns {
+		builtin[pattern] = true
+	}
+	for _, pattern := range JSArtifactPatterns {
+		builtin[pattern] = true
+	}
+
+	var unmatched []*IgnorePattern
+	for _, pattern := range gi.patterns {
+		if pattern.matchCount == 0 && !builtin[pattern.Line] {
+			unmatched = append(unmatched, pattern)
+		}
+	}
+	return unmatched
+}
+
+// WriteUnmatchedPatterns writes the list of patterns that never matched any file to w.
+func (gi *CombineIgnore) WriteUnmatchedPatterns(w io.Writer) error {
+	unmatched := gi.UnmatchedPatterns()
+	if len(unmatched) == 0 {
+		_, err := fmt.Fprintln(w, "All ignore patterns matched at least one path.")
+		return err
+	}
+
+	fmt.Fprintf(w, "Ignore patterns that matched no files (%d):\n", len(unmatched))
+	for _, pattern := range unmatched {
+		if _, err := fmt.Fprintf(w, "  %d: %s\n", pattern.LineNo, strings.TrimSpace(pattern.Line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WritePatternProfile writes a table of per-pattern evaluation statistics to w,
+// followed by the list of patterns that never matched any path.
+func (gi *CombineIgnore) WritePatternProfile(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LINE\tPATTERN\tTESTED\tMATCHED\tTIME")
+
+	var dead []*IgnorePattern
+	var totalTime time.Duration
+	for _, pattern := range gi.patterns {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%s\n",
+			pattern.LineNo,
+			pattern.Line,
+			pattern.profile.tested,
+			pattern.profile.matched,
+			pattern.profile.elapsed)
+		totalTime += pattern.profile.elapsed
+		if pattern.profile.matched == 0 {
+			dead = append(dead, pattern)
+		}
+	}
+	fmt.Fprintf(tw, "\t%d patterns\t\t\t%s\n", len(gi.patterns), totalTime)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(dead) == 0 {
+		_, err := fmt.Fprintln(w, "\nNo dead patterns.")
+		return err
+	}
+
+	fmt.Fprintf(w, "\nDead patterns (never matched, %d):\n", len(dead))
+	for _, pattern := range dead {
+		if _, err := fmt.Fprintf(w, "  %d: %s\n", pattern.LineNo, pattern.Line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
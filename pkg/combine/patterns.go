@@ -16,6 +16,121 @@ var (
 	RootRelativePattern          = regexp.MustCompile(`^/`)
 )
 
+// VCSDirectoryPatterns lists the metadata directories of common version control systems.
+// They are applied ahead of command-line patterns when VCS ignoring is enabled.
+var VCSDirectoryPatterns = []string{
+	".git/",   // Git
+	".svn/",   // Subversion
+	".hg/",    // Mercurial
+	".bzr/",   // Bazaar
+	"CVS/",    // CVS
+	"_darcs/", // Darcs
+}
+
+// JSArtifactPatterns lists the build outputs, caches, and reports of common JavaScript tooling.
+// They are applied ahead of command-line patterns when JavaScript artifact excluding is enabled.
+var JSArtifactPatterns = []string{
+	"node_modules/.cache/", // Tool caches (Babel, ESLint, webpack)
+	"dist/",                // Bundler output
+	"build/",               // Build output
+	"coverage/",            // Test coverage reports
+	".next/",               // Next.js
+	".nuxt/",               // Nuxt
+	".svelte-kit/",         // SvelteKit
+	"storybook-static/",    // Storybook static build
+}
+
+// Supported syntaxes for ignore patterns.
+const (
+	SyntaxGitignore = "gitignore" // gitignore-style patterns with '**' support.
+	SyntaxGlob      = "glob"      // Simple shell globs ('*', '?', '[...]') without '**'.
+	SyntaxRegex     = "regex"     // Raw Go regular expressions.
+)
+
+// patternTranslators maps each ignore syntax to the function translating a
+// pattern (without negation prefix) into a regular expression.
+var patternTranslators = map[string]func(pattern string) string{
+	SyntaxGitignore: gitignoreToRegex,
+	SyntaxGlob:      globToRegex,
+	SyntaxRegex:     func(pattern string) string { return pattern },
+}
+
+// gitignoreToRegex translates a gitignore-style pattern into an anchored regular expression.
+func gitignoreToRegex(pattern string) string {
+	// Escape special characters in the pattern
+	escaped := escapeSpecialChars(pattern)
+
+	// Mark '**' patterns, then convert wildcards '*' and '?' to regex equivalents before expanding
+	// the marks, so that the wildcards in their expansions are left alone
+	escaped = handleDoubleStarPatterns(escaped)
+	regexPattern := doubleStarExpander.Replace(wildcardToRegex(escaped))
+
+	// Anchor the pattern to match the entire path
+	return "^" + anchorPattern(regexPattern, pattern)
+}
+
+// globToRegex translates a shell glob into an anchored regular expression.
+// Wildcards never cross '/' boundaries. Globs without a '/' match the last path
+// component; globs containing a '/' match the full relative path.
+func globToRegex(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			sb.WriteString(`[^/]*`)
+		case '?':
+			sb.WriteString(`[^/]`)
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	body := strings.TrimSuffix(strings.TrimPrefix(sb.String(), "/"), "/")
+	if strings.Contains(strings.Trim(pattern, "/"), "/") || strings.HasPrefix(pattern, "/") {
+		return "^" + body + "/?$"
+	}
+	return "^(.*/)?" + body + "/?$"
+}
+
+// isRootRelative reports whether a gitignore or glob pattern is relative to the root rather than
+// matching at any depth, i.e. whether it has a '/' at the start or in the middle. A trailing '/'
+// only restricts the pattern to directories.
+func isRootRelative(pattern string) bool {
+	return RootRelativePattern.MatchString(pattern) || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+}
+
+// anchoredPrefix returns the literal path prefix of a root-relative gitignore or glob pattern line,
+// i.e. the text after any leading '/' up to the first wildcard, without a trailing '/'. Every path
+// such a pattern matches starts with the prefix. It reports false for unanchored patterns, regex
+// patterns, and patterns starting with a wildcard.
+func anchoredPrefix(line, syntax string) (string, bool) {
+	if syntax != SyntaxGitignore && syntax != SyntaxGlob {
+		return "", false
+	}
+	pattern := strings.TrimPrefix(strings.TrimSpace(line), "!")
+	if !isRootRelative(pattern) {
+		return "", false
+	}
+	prefix := strings.TrimPrefix(pattern, "/")
+	if i := strings.IndexAny(prefix, `*?[\`); i >= 0 {
+		prefix = prefix[:i]
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix, prefix != ""
+}
+
 // escapeSpecialChars escapes regex special characters except for '*', '?', and '/'.
 func escapeSpecialChars(pattern string) string {
 	var specialChars = `.+()|^$[]{}`
@@ -25,11 +140,25 @@ func escapeSpecialChars(pattern string) string {
 	return pattern
 }
 
-// handleDoubleStarPatterns replaces '**' patterns with appropriate regex.
+// Placeholders for '**' patterns, which cannot occur in pattern lines.
+const (
+	doubleStarMiddle   = "\x00middle\x00"
+	doubleStarTrailing = "\x00trailing\x00"
+	doubleStarLeading  = "\x00leading\x00"
+)
+
+// doubleStarExpander replaces the placeholders left by handleDoubleStarPatterns with regex.
+var doubleStarExpander = strings.NewReplacer(
+	doubleStarMiddle, `(/|/.+/)`,
+	doubleStarTrailing, `(/.*)?`,
+	doubleStarLeading, `(.*/)?`,
+)
+
+// handleDoubleStarPatterns replaces '**' patterns with placeholders for doubleStarExpander.
 func handleDoubleStarPatterns(pattern string) string {
-	pattern = DoubleStarMiddlePattern.ReplaceAllString(pattern, `(/|/.+/)`)
-	pattern = DoubleStarTrailingPattern.ReplaceAllString(pattern, `(/.*)?`)
-	pattern = DoubleStarLeadingPattern.ReplaceAllString(pattern, `(.*/)?`)
+	pattern = DoubleStarMiddlePattern.ReplaceAllLiteralString(pattern, doubleStarMiddle)
+	pattern = DoubleStarTrailingPattern.ReplaceAllLiteralString(pattern, doubleStarTrailing)
+	pattern = DoubleStarLeadingPattern.ReplaceAllLiteralString(pattern, doubleStarLeading)
 	return pattern
 }
 
@@ -40,7 +169,8 @@ func wildcardToRegex(pattern string) string {
 	return pattern
 }
 
-// anchorPattern anchors the regex pattern to match the entire path.
+// anchorPattern anchors the regex pattern to match the entire path. Root-relative patterns, such
+// as "/build" or "src/lib/*.go", match from the start of the path; others match at any depth.
 func anchorPattern(pattern string, originalPattern string) string {
 	if DirectoryEndPattern.MatchString(originalPattern) {
 		pattern = pattern + "(/.*)?$"
@@ -48,8 +178,8 @@ func anchorPattern(pattern string, originalPattern string) string {
 		pattern = pattern + "(|/.*)?$"
 	}
 
-	if RootRelativePattern.MatchString(originalPattern) {
-		return "^" + pattern
+	if isRootRelative(originalPattern) {
+		return "^" + strings.TrimPrefix(pattern, "/")
 	}
 	return "^(|.*/)" + pattern
 }
@@ -0,0 +1,96 @@
+// File: pkg/combine/preview.go
+package combine
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"go.uber.org/zap"
+)
+
+// WritePreview writes the first n lines of the combined output to w instead of writing the output file.
+// For JSON and YAML output, the tree and file contents are truncated to n lines in total so that the
+// preview remains a valid document, marked with "truncated": true when content was cut.
+func WritePreview(w io.Writer, n int, format string, tmpl *template.Template, treeContent string, combinedContents []FileContent, countTokens bool, logger *zap.Logger) error {
+	logger.Debug("Writing combined output preview", zap.Int("lines", n), zap.String("format", format))
+
+	if tmpl == nil && format == FormatJSON {
+		return writeStructuredPreview(w, n, treeContent, combinedContents, countTokens, encodeJSONOutput)
+	}
+	if tmpl == nil && format == FormatYAML {
+		return writeStructuredPreview(w, n, treeContent, combinedContents, countTokens, encodeYAMLOutput)
+	}
+
+	var rendered []byte
+	if tmpl != nil {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, TemplateData{Tree: treeContent, Files: combinedContents}); err != nil {
+			return fmt.Errorf("failed to execute output template: %w", err)
+		}
+		rendered = buf.Bytes()
+	} else {
+		rendered = renderCombinedText(treeContent, combinedContents)
+	}
+
+	head, _ := headLines(string(rendered), n)
+	writer := bufio.NewWriter(w)
+	if _, err := writer.WriteString(head); err != nil {
+		return fmt.Errorf("failed to write preview: %w", err)
+	}
+	if head != "" && !strings.HasSuffix(head, "\n") {
+		if err := writer.WriteByte('\n'); err != nil {
+			return fmt.Errorf("failed to write preview: %w", err)
+		}
+	}
+	return writer.Flush()
+}
+
+// writeStructuredPreview writes a document whose tree and file contents together span at most n lines.
+func writeStructuredPreview(w io.Writer, n int, treeContent string, combinedContents []FileContent, countTokens bool, encode func(io.Writer, jsonOutput) error) error {
+	tree, used := headLines(treeContent, n)
+	truncated := tree != treeContent
+	remaining := n - used
+
+	var previewContents []FileContent
+	for _, content := range combinedContents {
+		if remaining <= 0 {
+			truncated = true
+			break
+		}
+		head, lines := headLines(content.Content, remaining)
+		if head != content.Content {
+			truncated = true
+		}
+		content.Content = head
+		previewContents = append(previewContents, content)
+		remaining -= lines
+	}
+
+	doc := buildJSONOutput(tree, previewContents, countTokens)
+	doc.Truncated = truncated
+	if err := encode(w, doc); err != nil {
+		return fmt.Errorf("failed to encode preview: %w", err)
+	}
+	return nil
+}
+
+// headLines returns the first n lines of s, including their line endings, and the number of lines returned.
+func headLines(s string, n int) (string, int) {
+	lines := 0
+	for i := 0; i < len(s); i++ {
+		if lines == n {
+			return s[:i], lines
+		}
+		if s[i] == '\n' {
+			lines++
+		}
+	}
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		lines++ // Count a final line without a trailing newline
+	}
+	return s, lines
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+// File: pkg/combine/process_other.go
+package combine
+
+import "os"
+
+// processRunning reports whether a process with the given ID exists; on Windows os.FindProcess
+// fails for processes that do not.
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
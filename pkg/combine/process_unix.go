@@ -0,0 +1,21 @@
+//go:build unix
+
+// File: pkg/combine/process_unix.go
+package combine
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// processRunning reports whether a process with the given ID exists. os.FindProcess always
+// succeeds on Unix, so the process is probed with signal 0, which checks without delivering one.
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
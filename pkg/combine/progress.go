@@ -0,0 +1,166 @@
+// File: pkg/combine/progress.go
+package combine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Progress receives notifications as files are combined, e.g. to display a progress bar.
+// OnFileStart and OnFileDone are called by the worker pool, from several goroutines at once,
+// so implementations must be safe for concurrent use. OnFileDone is called exactly once for
+// every file that OnFileStart was called for; files dropped by a grep pattern are done with a
+// nil error. OnSummary is called once by ExecuteWithContext after all files are processed;
+// programs driving ProcessFilesConcurrently themselves call it if they need it.
+type Progress interface {
+	OnFileStart(path string)
+	OnFileDone(path string, err error)
+	OnSummary(total, skipped, binary int)
+}
+
+// NoopProgress is a Progress that ignores all notifications.
+type NoopProgress struct{}
+
+// OnFileStart does nothing.
+func (NoopProgress) OnFileStart(string) {}
+
+// OnFileDone does nothing.
+func (NoopProgress) OnFileDone(string, error) {}
+
+// OnSummary does nothing.
+func (NoopProgress) OnSummary(int, int, int) {}
+
+// TextProgress is a Progress that writes one line per file, e.g. "Processing file 3/120: main.go",
+// and a final summary line to an io.Writer.
+type TextProgress struct {
+	mu      sync.Mutex
+	w       io.Writer
+	total   int
+	started int
+	failed  int
+}
+
+// NewTextProgress returns a TextProgress writing to w for a run processing total files.
+func NewTextProgress(w io.Writer, total int) *TextProgress {
+	return &TextProgress{w: w, total: total}
+}
+
+// OnFileStart writes the position of the file among all files and its path.
+func (p *TextProgress) OnFileStart(path string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.started++
+	fmt.Fprintf(p.w, "Processing file %d/%d: %s\n", p.started, p.total, path)
+}
+
+// OnFileDone counts files that failed to process.
+func (p *TextProgress) OnFileDone(path string, err error) {
+	if err == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failed++
+}
+
+// OnSummary writes the number of files processed, skipped, and binary, and how many failed.
+func (p *TextProgress) OnSummary(total, skipped, binary int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "Processed %d files: %d skipped, %d binary, %d failed\n", total, skipped, binary, p.failed)
+}
+
+// textWriteProgress returns a callback for WriteCombinedFileWithProgress that writes one line per
+// file section to w, e.g. "Writing file 3/120".
+func textWriteProgress(w io.Writer) func(n, total int) {
+	return func(n, total int) {
+		fmt.Fprintf(w, "Writing file %d/%d\n", n, total)
+	}
+}
+
+// JSONProgress is a Progress that appends a JSON object to an io.Writer every interval files,
+// e.g. {"processed":42,"total":1203,"current":"src/main.go","elapsed_ms":1240}, so that
+// external tools can follow the run with tail -f. A final object is written by OnSummary.
+type JSONProgress struct {
+	mu        sync.Mutex
+	w         io.Writer
+	total     int
+	interval  int
+	processed int
+	start     time.Time
+}
+
+// jsonProgressLine is a single line written by JSONProgress.
+type jsonProgressLine struct {
+	Processed int    `json:"processed"`
+	Total     int    `json:"total"`
+	Current   string `json:"current"`
+	ElapsedMS int64  `json:"elapsed_ms"`
+}
+
+// NewJSONProgress returns a JSONProgress writing to w for a run processing total files, every
+// interval files; a non-positive interval selects DefaultProgressInterval.
+func NewJSONProgress(w io.Writer, total, interval int) *JSONProgress {
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+	return &JSONProgress{w: w, total: total, interval: interval, start: time.Now()}
+}
+
+// OnFileStart does nothing; files are reported once they are done.
+func (p *JSONProgress) OnFileStart(string) {}
+
+// OnFileDone counts the file and writes the progress if interval files were done since the last write.
+func (p *JSONProgress) OnFileDone(path string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.processed++
+	if p.processed%p.interval == 0 {
+		p.write(path)
+	}
+}
+
+// OnSummary writes the final progress, with no current file.
+func (p *JSONProgress) OnSummary(total, skipped, binary int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.write("")
+}
+
+// write writes the progress as one JSON line. Errors are ignored, as progress is best effort.
+func (p *JSONProgress) write(current string) {
+	line, _ := json.Marshal(jsonProgressLine{
+		Processed: p.processed,
+		Total:     p.total,
+		Current:   current,
+		ElapsedMS: time.Since(p.start).Milliseconds(),
+	})
+	p.w.Write(append(line, '\n'))
+}
+
+// multiProgress is a Progress forwarding every notification to each of its elements in turn.
+type multiProgress []Progress
+
+// OnFileStart forwards the notification to every Progress.
+func (m multiProgress) OnFileStart(path string) {
+	for _, p := range m {
+		p.OnFileStart(path)
+	}
+}
+
+// OnFileDone forwards the notification to every Progress.
+func (m multiProgress) OnFileDone(path string, err error) {
+	for _, p := range m {
+		p.OnFileDone(path, err)
+	}
+}
+
+// OnSummary forwards the notification to every Progress.
+func (m multiProgress) OnSummary(total, skipped, binary int) {
+	for _, p := range m {
+		p.OnSummary(total, skipped, binary)
+	}
+}
@@ -0,0 +1,104 @@
+// File: pkg/combine/progress_test.go
+package combine_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"agentexec/pkg/combine"
+)
+
+// mockProgress records the notifications of a Progress, which may arrive from several workers.
+type mockProgress struct {
+	mu      sync.Mutex
+	started map[string]int
+	done    map[string]int
+	errs    map[string]error
+}
+
+func newMockProgress() *mockProgress {
+	return &mockProgress{started: map[string]int{}, done: map[string]int{}, errs: map[string]error{}}
+}
+
+func (p *mockProgress) OnFileStart(path string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.started[path]++
+}
+
+func (p *mockProgress) OnFileDone(path string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started[path] <= p.done[path] {
+		p.errs[path] = errors.New("OnFileDone without OnFileStart")
+		return
+	}
+	p.done[path]++
+	if err != nil {
+		p.errs[path] = err
+	}
+}
+
+func (p *mockProgress) OnSummary(int, int, int) {}
+
+// TestProgress checks that every file, failed ones included, is started and done exactly once.
+func TestProgress(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	want := map[string]int{}
+	for i := range 50 {
+		path := filepath.Join(dir, fmt.Sprintf("file%02d.go", i))
+		if err := os.WriteFile(path, []byte(fmt.Sprintf("package p // %d\n", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+		want[path] = 1
+	}
+	missing := filepath.Join(dir, "missing.go")
+	files = append(files, missing)
+	want[missing] = 1
+
+	progress := newMockProgress()
+	contents, failed, err := combine.ProcessFilesConcurrently(context.Background(), files, dir, combine.DefaultProcessOptions(),
+		combine.WithProgress(progress), combine.WithMaxWorkers(4))
+	if err != nil {
+		t.Fatalf("ProcessFilesConcurrently() = %v", err)
+	}
+	if len(contents) != 50 || failed != 1 {
+		t.Errorf("ProcessFilesConcurrently() = %d contents, %d failed, want 50 and 1", len(contents), failed)
+	}
+
+	if !maps.Equal(progress.started, want) {
+		t.Errorf("OnFileStart calls = %v, want one per file", progress.started)
+	}
+	if !maps.Equal(progress.done, want) {
+		t.Errorf("OnFileDone calls = %v, want one per file", progress.done)
+	}
+	if len(progress.errs) != 1 || progress.errs[missing] == nil {
+		t.Errorf("OnFileDone errors = %v, want only one for %s", progress.errs, missing)
+	}
+}
+
+func TestTextProgress(t *testing.T) {
+	var b strings.Builder
+	progress := combine.NewTextProgress(&b, 2)
+	progress.OnFileStart("a.go")
+	progress.OnFileDone("a.go", nil)
+	progress.OnFileStart("b.go")
+	progress.OnFileDone("b.go", errors.New("unreadable"))
+	progress.OnSummary(4, 1, 1)
+
+	want := "Processing file 1/2: a.go\n" +
+		"Processing file 2/2: b.go\n" +
+		"Processed 4 files: 1 skipped, 1 binary, 1 failed\n"
+	if got := b.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
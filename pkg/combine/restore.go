@@ -0,0 +1,86 @@
+// File: pkg/combine/restore.go
+package combine
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// base64BinaryMarker starts the content of a binary file embedded by EncodeBinaryFile and is
+// followed by the MIME type.
+const base64BinaryMarker = "# Binary file, base64-encoded, MIME: "
+
+// ErrUnsafeRestorePath is returned by RestoreCombinedFile for source paths that are absolute or
+// lead outside the output directory.
+var ErrUnsafeRestorePath = errors.New("path is not local to the output directory")
+
+// RestoreCombinedFile recreates the files of text output as written by WriteCombinedFile below
+// outputDir and returns the number of files written. Files embedded with --base64-encode-binary
+// are decoded. Existing files are only replaced if overwrite is set.
+func RestoreCombinedFile(r io.Reader, outputDir string, overwrite bool, logger *zap.Logger) (int, error) {
+	files, err := ParseCombinedFile(r)
+	if err != nil {
+		return 0, err
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		localPath := filepath.FromSlash(path)
+		if !filepath.IsLocal(localPath) {
+			return 0, fmt.Errorf("cannot restore %s: %w", path, ErrUnsafeRestorePath)
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	for _, path := range paths {
+		data, err := restoredContent(files[path])
+		if err != nil {
+			return 0, fmt.Errorf("cannot restore %s: %w", path, err)
+		}
+
+		target := filepath.Join(outputDir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return 0, fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+		file, err := os.OpenFile(target, flags, 0o644)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create %s: %w", target, err)
+		}
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		logger.Debug("Restored file", zap.String("filePath", target), zap.Int("sizeBytes", len(data)))
+	}
+	return len(paths), nil
+}
+
+// restoredContent returns the original bytes of a file's content section, decoding it if it
+// was embedded as base64.
+func restoredContent(content string) ([]byte, error) {
+	if !strings.HasPrefix(content, base64BinaryMarker) {
+		return []byte(content), nil
+	}
+	_, encoded, _ := strings.Cut(content, "\n")
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(encoded), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 content: %w", err)
+	}
+	return data, nil
+}
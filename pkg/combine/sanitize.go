@@ -0,0 +1,69 @@
+// File: pkg/combine/sanitize.go
+package combine
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Placeholders written by SanitizePath in place of the directories they stand for.
+const (
+	SanitizedRoot = "<ROOT>"
+	SanitizedHome = "<HOME>"
+)
+
+// PathSanitizer rewrites the absolute paths written to the output so that they reveal neither
+// the user's home directory nor where the project lives on disk. A nil *PathSanitizer leaves
+// paths unchanged.
+type PathSanitizer struct {
+	HomeDir string // Home directory replaced by SanitizedHome; empty disables the replacement.
+	Root    string // Project root replaced by SanitizedRoot; empty disables the replacement.
+}
+
+// NewPathSanitizer returns a PathSanitizer for the current user's home directory and root.
+func NewPathSanitizer(root string) *PathSanitizer {
+	homeDir, _ := os.UserHomeDir()
+	return &PathSanitizer{HomeDir: homeDir, Root: root}
+}
+
+// Sanitize returns SanitizePath(path, s.HomeDir, s.Root), or path itself if s is nil.
+func (s *PathSanitizer) Sanitize(path string) string {
+	if s == nil {
+		return path
+	}
+	return SanitizePath(path, s.HomeDir, s.Root)
+}
+
+// SanitizePath replaces the leading root or homeDir of the absolute path with SanitizedRoot
+// or SanitizedHome, e.g. "/home/alice/project/src/main.go" becomes "<ROOT>/src/main.go" for
+// the root "/home/alice/project" and "<HOME>/project/src/main.go" otherwise. If the path lies
+// within both, the deeper directory is replaced, so that a root of "/" never exposes the home
+// directory. The rest of the path is slash-separated. Relative paths and absolute paths
+// outside both directories are returned unchanged.
+func SanitizePath(path, homeDir, root string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+
+	dir, placeholder := "", ""
+	for _, candidate := range []struct{ dir, placeholder string }{
+		{root, SanitizedRoot},
+		{homeDir, SanitizedHome},
+	} {
+		if candidate.dir == "" || !isWithinDir(path, candidate.dir) {
+			continue
+		}
+		if dir == "" || isWithinDir(candidate.dir, dir) && filepath.Clean(candidate.dir) != filepath.Clean(dir) {
+			dir, placeholder = candidate.dir, candidate.placeholder
+		}
+	}
+	if dir == "" {
+		return path
+	}
+
+	rel, _ := filepath.Rel(dir, path)
+	if rel == "." {
+		return placeholder
+	}
+	return placeholder + "/" + filepath.ToSlash(rel)
+}
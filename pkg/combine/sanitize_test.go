@@ -0,0 +1,44 @@
+// File: pkg/combine/sanitize_test.go
+package combine_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"agentexec/pkg/combine"
+)
+
+// TestSanitizePath checks that the deeper of the root and home directories is replaced, and that
+// paths outside both are left alone.
+func TestSanitizePath(t *testing.T) {
+	if !filepath.IsAbs("/home") {
+		t.Skip("slash-rooted paths are not absolute on this platform")
+	}
+	const home = "/home/alice"
+	tests := []struct {
+		name string
+		path string
+		root string
+		want string
+	}{
+		{name: "root inside home", path: "/home/alice/project/src/main.go", root: "/home/alice/project", want: "<ROOT>/src/main.go"},
+		{name: "root itself", path: "/home/alice/project", root: "/home/alice/project", want: "<ROOT>"},
+		{name: "home outside root", path: "/home/alice/other/x", root: "/home/alice/project", want: "<HOME>/other/x"},
+		{name: "root above home", path: "/home/alice/project/main.go", root: "/", want: "<HOME>/project/main.go"},
+		{name: "neither", path: "/srv/data/x", root: "/home/alice/project", want: "/srv/data/x"},
+		{name: "relative", path: "src/main.go", root: "/home/alice/project", want: "src/main.go"},
+		{name: "no root", path: "/home/alice/project/main.go", want: "<HOME>/project/main.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := combine.SanitizePath(tt.path, home, tt.root); got != tt.want {
+				t.Errorf("SanitizePath(%q, %q, %q) = %q, want %q", tt.path, home, tt.root, got, tt.want)
+			}
+		})
+	}
+
+	var nilSanitizer *combine.PathSanitizer
+	if got := nilSanitizer.Sanitize("/home/alice/x"); got != "/home/alice/x" {
+		t.Errorf("nil Sanitize() = %q, want the path unchanged", got)
+	}
+}
@@ -0,0 +1,82 @@
+// File: pkg/combine/schema.go
+package combine
+
+import (
+	"reflect"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// jsonSchemaDialect is the JSON Schema version the generated schemas declare.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// ArgumentsSchema returns the JSON Schema of the configuration files read by LoadConfigFile, for
+// validation and autocompletion in editors. Keys are the Arguments field names in lower camel
+// case, e.g. "maxFileSizeKB"; the loader matches them case-insensitively.
+func ArgumentsSchema() map[string]any {
+	schema := structSchema(reflect.TypeOf(Arguments{}), lowerCamel)
+	schema["$schema"] = jsonSchemaDialect
+	schema["title"] = "agentexec configuration"
+	return schema
+}
+
+// CombineResultSchema returns the JSON Schema of a CombineResult encoded with encoding/json.
+func CombineResultSchema() map[string]any {
+	schema := structSchema(reflect.TypeOf(CombineResult{}), func(name string) string { return name })
+	schema["$schema"] = jsonSchemaDialect
+	schema["title"] = "agentexec combine result"
+	return schema
+}
+
+// structSchema returns the schema of an object with the exported fields of t as properties,
+// named by propertyName. Other properties are rejected.
+func structSchema(t reflect.Type, propertyName func(string) string) map[string]any {
+	properties := make(map[string]any)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		properties[propertyName(field.Name)] = typeSchema(field.Type, propertyName)
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// typeSchema returns the schema of the JSON encoding of values of type t.
+func typeSchema(t reflect.Type, propertyName func(string) string) map[string]any {
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return map[string]any{"type": "integer", "description": "Duration in nanoseconds"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), propertyName)
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), propertyName)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), propertyName)}
+	case reflect.Struct:
+		return structSchema(t, propertyName)
+	default:
+		return map[string]any{}
+	}
+}
+
+// lowerCamel lowercases the first letter of a field name, e.g. "MaxFileSizeKB" to "maxFileSizeKB".
+func lowerCamel(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
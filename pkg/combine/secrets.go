@@ -0,0 +1,53 @@
+// File: pkg/combine/secrets.go
+package combine
+
+import (
+	"errors"
+	"regexp"
+
+	"go.uber.org/zap"
+)
+
+// errSecretDetected is returned by ProcessSingleFile for files containing a secret when
+// opts.ExcludeSecrets is set.
+var errSecretDetected = errors.New("content contains a secret")
+
+// secretPatterns are the secret formats reported by --detect-secrets, in the order they are checked.
+var secretPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"AWS access key", regexp.MustCompile(`AKIA[0-9A-Z]{16}`)},
+	{"GitHub token", regexp.MustCompile(`ghp_[a-zA-Z0-9]{36}`)},
+	{"private key", regexp.MustCompile(`-----BEGIN.*PRIVATE KEY-----`)},
+}
+
+// DetectSecrets returns the kinds of secrets found in data, each at most once, e.g.
+// ["AWS access key"]. The secrets themselves are not returned so that they cannot leak into logs.
+func DetectSecrets(data []byte) []string {
+	var kinds []string
+	for _, secret := range secretPatterns {
+		if secret.pattern.Match(data) {
+			kinds = append(kinds, secret.kind)
+		}
+	}
+	return kinds
+}
+
+// checkSecrets warns about the secrets in data, the content of the file at path, if
+// opts.DetectSecrets is set, and returns errSecretDetected if any are found and
+// opts.ExcludeSecrets is set.
+func (o ProcessOptions) checkSecrets(path string, data []byte, logger *zap.Logger) error {
+	if !o.DetectSecrets {
+		return nil
+	}
+	kinds := DetectSecrets(data)
+	if len(kinds) == 0 {
+		return nil
+	}
+	logger.Warn("Possible secret detected", zap.String("filePath", path), zap.Strings("secretTypes", kinds), zap.Bool("excluded", o.ExcludeSecrets))
+	if o.ExcludeSecrets {
+		return errSecretDetected
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// File: pkg/combine/size_warning.go
+package combine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LargeOutputWarning returns the warning printed when the included content of result exceeds
+// args.WarnOnLargeCombinedMB megabytes, suggesting the size-reducing flags that are not already
+// in use, or "" if the threshold is not set or not exceeded.
+func LargeOutputWarning(args Arguments, result CombineResult) string {
+	threshold := int64(args.WarnOnLargeCombinedMB) << 20
+	if threshold <= 0 || result.TotalBytes <= threshold {
+		return ""
+	}
+
+	var suggestions []string
+	if args.MaxFileSizeKB == DefaultMaxFileSizeKB {
+		suggestions = append(suggestions, "--max-size")
+	}
+	if !args.RespectGitignore {
+		suggestions = append(suggestions, "--respect-gitignore")
+	}
+	if !args.ExcludeJSArtifacts {
+		suggestions = append(suggestions, "--exclude-js-artifacts")
+	}
+	if args.MaxTokens == 0 {
+		suggestions = append(suggestions, "--max-tokens")
+	}
+	if args.SplitBytes == 0 && !args.OutputPerExtension {
+		suggestions = append(suggestions, "--split-bytes")
+	}
+
+	warning := fmt.Sprintf("Warning: combined output is %d MB.", result.TotalBytes>>20)
+	if len(suggestions) == 0 {
+		return warning
+	}
+	flags := suggestions[0]
+	if last := len(suggestions) - 1; last == 1 {
+		flags = suggestions[0] + " or " + suggestions[1]
+	} else if last > 1 {
+		flags = strings.Join(suggestions[:last], ", ") + ", or " + suggestions[last]
+	}
+	return fmt.Sprintf("%s Consider using %s to reduce size.", warning, flags)
+}
@@ -0,0 +1,70 @@
+//go:build linux
+
+// File: pkg/combine/sparse_linux.go
+package combine
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// statBlockSize is the unit of syscall.Stat_t.Blocks, which is 512 bytes regardless of the
+// filesystem's block size.
+const statBlockSize = 512
+
+// sparseFraction bounds the allocated size, as a fraction of the apparent size, below which a
+// file is taken to be sparse when the filesystem cannot report its holes.
+const sparseFraction = 8
+
+// effectiveSize returns the size of the file at path, described by info, for size limits.
+// Sparse files, whose holes occupy no disk space, count with their allocated size instead of
+// their apparent size, so that a mostly empty file is not excluded for its length alone.
+//
+// An allocated size below the apparent size does not prove holes: compressing filesystems such
+// as btrfs or ZFS store dense files in fewer blocks, and their content must still be read in
+// full. Such files are told apart by asking for the first hole with SEEK_HOLE, which costs an
+// extra open for each file smaller on disk than its length. Filesystems without hole tracking
+// report none, so that their sparse files count with their apparent size. If SEEK_HOLE fails,
+// only files allocating less than 1/sparseFraction of their length count as sparse, so highly
+// compressible files may then still be mistaken for sparse ones.
+func effectiveSize(path string, info fs.FileInfo) int64 {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.Size()
+	}
+	allocated := stat.Blocks * statBlockSize
+	if allocated >= info.Size() {
+		return info.Size()
+	}
+
+	if hasHoles, ok := seekHole(path, info.Size()); ok {
+		if hasHoles {
+			return allocated
+		}
+		return info.Size()
+	}
+	if allocated < info.Size()/sparseFraction {
+		return allocated
+	}
+	return info.Size()
+}
+
+// seekHole reports whether the file at path, of the given size, has a hole before its end.
+// The second result is false if the filesystem cannot tell.
+func seekHole(path string, size int64) (hasHoles bool, ok bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, false
+	}
+	defer f.Close()
+
+	// Filesystems without hole tracking report a single implicit hole at the end of the file
+	hole, err := unix.Seek(int(f.Fd()), 0, unix.SEEK_HOLE)
+	if err != nil {
+		return false, false
+	}
+	return hole < size, true
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+// File: pkg/combine/sparse_linux_test.go
+package combine
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEffectiveSize(t *testing.T) {
+	dir := t.TempDir()
+
+	dense := filepath.Join(dir, "dense.txt")
+	if err := os.WriteFile(dense, bytes.Repeat([]byte("a"), 64*1024), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(dense)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := effectiveSize(dense, info); got != info.Size() {
+		t.Errorf("effectiveSize(dense) = %d, want the apparent size %d", got, info.Size())
+	}
+
+	sparse := filepath.Join(dir, "sparse.txt")
+	f, err := os.Create(sparse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("head"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(16 << 20); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	info, err = os.Stat(sparse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasHoles, ok := seekHole(sparse, info.Size()); !ok || !hasHoles {
+		t.Skip("the temporary directory's filesystem does not report holes")
+	}
+	if got := effectiveSize(sparse, info); got >= info.Size() {
+		t.Errorf("effectiveSize(sparse) = %d, want less than the apparent size %d", got, info.Size())
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+// File: pkg/combine/sparse_other.go
+package combine
+
+import "io/fs"
+
+// effectiveSize returns the apparent size of the file at path, described by info; sparse files
+// are only detected on Linux.
+func effectiveSize(path string, info fs.FileInfo) int64 {
+	return info.Size()
+}
@@ -0,0 +1,136 @@
+// File: pkg/combine/stats.go
+package combine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// DefaultStatsOutput is the file the --stats summary is written to when no path is given.
+const DefaultStatsOutput = "debug/stats.json"
+
+// languageUnknown is the language reported for files whose extension is not recognized.
+const languageUnknown = "Other"
+
+// languagesByExtension maps lowercase file extensions to the language reported in Stats.
+var languagesByExtension = map[string]string{
+	".go":    "Go",
+	".py":    "Python",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".rs":    "Rust",
+	".rb":    "Ruby",
+	".php":   "PHP",
+	".swift": "Swift",
+	".sh":    "Shell",
+	".bash":  "Shell",
+	".sql":   "SQL",
+	".html":  "HTML",
+	".css":   "CSS",
+	".scss":  "CSS",
+	".md":    "Markdown",
+	".json":  "JSON",
+	".yaml":  "YAML",
+	".yml":   "YAML",
+	".toml":  "TOML",
+	".xml":   "XML",
+	".txt":   "Text",
+}
+
+// Stats is a machine-readable summary of a combine run.
+type Stats struct {
+	TotalFiles         int                      `json:"total_files"`
+	TotalBytes         int64                    `json:"total_bytes"`
+	TotalLines         int                      `json:"total_lines"`
+	Languages          map[string]LanguageStats `json:"languages"`
+	SkippedBinaryFiles []string                 `json:"skipped_binary_files"`
+	PermissionErrors   int                      `json:"permission_errors"`
+}
+
+// LanguageStats holds the totals for the files of one language.
+type LanguageStats struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+	Lines int   `json:"lines"`
+}
+
+// GenerateStats computes the totals and per-language breakdown of contents, inferring each
+// file's language from its extension, and lists the binary files that were skipped.
+func GenerateStats(contents []FileContent, binary []string) Stats {
+	stats := Stats{
+		Languages:          make(map[string]LanguageStats),
+		SkippedBinaryFiles: append([]string{}, binary...),
+	}
+	for _, content := range contents {
+		bytes := int64(len(content.Content))
+		lines := countLines(content.Content)
+
+		stats.TotalFiles++
+		stats.TotalBytes += bytes
+		stats.TotalLines += lines
+
+		language := languageOf(content.Path)
+		lang := stats.Languages[language]
+		lang.Files++
+		lang.Bytes += bytes
+		lang.Lines += lines
+		stats.Languages[language] = lang
+	}
+	return stats
+}
+
+// languageOf returns the language of the file at path based on its extension.
+func languageOf(path string) string {
+	if language, ok := languagesByExtension[strings.ToLower(filepath.Ext(path))]; ok {
+		return language
+	}
+	return languageUnknown
+}
+
+// countLines returns the number of lines in content; a final line without a newline counts.
+func countLines(content string) int {
+	if content == "" {
+		return 0
+	}
+	lines := strings.Count(content, "\n")
+	if !strings.HasSuffix(content, "\n") {
+		lines++
+	}
+	return lines
+}
+
+// writeStatsFile writes stats as indented JSON to path, creating its directory if needed.
+// Writes are recorded in profile if it is non-nil.
+func writeStatsFile(path string, stats Stats, profile *ioProfile, logger *zap.Logger) error {
+	if path != StdoutPath {
+		if err := ensureDirectory(filepath.Dir(path), logger); err != nil {
+			return fmt.Errorf("failed to create stats directory: %w", err)
+		}
+	}
+	err := writeOutput(path, profile, logger, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write stats: %w", err)
+	}
+	logger.Debug("Wrote stats", zap.String("statsFile", path), zap.Int("files", stats.TotalFiles))
+	return nil
+}
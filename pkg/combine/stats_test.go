@@ -0,0 +1,48 @@
+// File: pkg/combine/stats_test.go
+package combine_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"agentexec/pkg/combine"
+)
+
+// TestStats checks the exact counts written with --stats for a known set of files.
+func TestStats(t *testing.T) {
+	args := testArguments(t, fstest.MapFS{
+		"src/main.go":      text("package main\n\nfunc main() {}\n"), // 29 bytes, 3 lines
+		"src/lib/util.go":  text("package lib\n"),                    // 12 bytes, 1 line
+		"src/README.md":    text("# Title\n\nText"),                  // 13 bytes, 3 lines
+		"src/app.py":       text("print('hi')\n"),                    // 12 bytes, 1 line
+		"src/LICENSE":      text("MIT\n"),                            // 4 bytes, 1 line
+		"src/empty.txt":    text(""),                                 // 0 bytes, 0 lines
+		"src/data/raw.bin": &fstest.MapFile{Data: []byte{0, 1, 2}},
+	})
+	args.Stats = true
+	runCombine(t, args)
+
+	var got combine.Stats
+	if err := json.Unmarshal([]byte(readFile(t, args.StatsOutput)), &got); err != nil {
+		t.Fatalf("failed to decode stats: %v", err)
+	}
+	want := combine.Stats{
+		TotalFiles: 6,
+		TotalBytes: 70,
+		TotalLines: 9,
+		Languages: map[string]combine.LanguageStats{
+			"Go":       {Files: 2, Bytes: 41, Lines: 4},
+			"Markdown": {Files: 1, Bytes: 13, Lines: 3},
+			"Python":   {Files: 1, Bytes: 12, Lines: 1},
+			"Other":    {Files: 1, Bytes: 4, Lines: 1},
+			"Text":     {Files: 1, Bytes: 0, Lines: 0},
+		},
+		SkippedBinaryFiles: []string{filepath.Join(args.Paths[0], "data", "raw.bin")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+// File: pkg/combine/stdin.go
+package combine
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// StdinFileName is the name of the virtual file holding standard input, before the extension
+// given by StdinExtension is appended, e.g. "stdin.go".
+const StdinFileName = "stdin"
+
+// PrioritizeStdin is the Prioritize value that puts the standard input file first in the output.
+const PrioritizeStdin = "stdin"
+
+// stdinFileName returns the name of the virtual standard input file for ext, e.g. "stdin.go"
+// for ".go" or "go".
+func stdinFileName(ext string) string {
+	if strings.TrimSpace(ext) == "" {
+		return StdinFileName
+	}
+	return StdinFileName + normalizeExtension(ext)
+}
+
+// readStdin returns the content piped to stdin. It reports false, without reading, if stdin is
+// a terminal or another character device such as /dev/null, and for empty input.
+func readStdin(stdin *os.File, logger *zap.Logger) ([]byte, bool, error) {
+	info, err := stdin.Stat()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to inspect standard input: %w", err)
+	}
+	if info.Mode()&os.ModeCharDevice != 0 {
+		logger.Debug("Standard input is a terminal, no virtual stdin file is added")
+		return nil, false, nil
+	}
+
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read standard input: %w", err)
+	}
+	if len(data) == 0 {
+		logger.Debug("Standard input is empty, no virtual stdin file is added")
+		return nil, false, nil
+	}
+	return data, true, nil
+}
+
+// stdinContent formats the content read from stdin as the virtual file named after ext. It
+// reports false if opts.Grep or a detected secret drops the content.
+func stdinContent(data []byte, ext string, opts ProcessOptions, logger *zap.Logger) (FileContent, bool) {
+	if opts.Grep != nil && !opts.Grep.Match(data) {
+		logger.Debug("Skipping standard input not matching grep pattern", zap.String("pattern", opts.Grep.String()))
+		return FileContent{}, false
+	}
+	name := stdinFileName(ext)
+	if err := opts.checkSecrets(name, data, logger); err != nil {
+		return FileContent{}, false
+	}
+	header := opts.sectionHeader(name, opts.commentPrefix(name))
+	return opts.fileContent(name, "", header, "", data, logger), true
+}
+
+// addStdinContent places the virtual stdin file last in contents, or first if prioritized.
+func addStdinContent(contents []FileContent, stdinFile FileContent, prioritized bool) []FileContent {
+	if prioritized {
+		return append([]FileContent{stdinFile}, contents...)
+	}
+	return append(contents, stdinFile)
+}
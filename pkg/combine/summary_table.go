@@ -0,0 +1,105 @@
+// File: pkg/combine/summary_table.go
+package combine
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// WriteSummaryTable writes the metrics of result to w as a table drawn with box-drawing characters.
+func WriteSummaryTable(w io.Writer, result CombineResult) error {
+	rows := [][2]string{
+		{"Files included", formatThousands(int64(result.FilesIncluded))},
+		{"Files skipped", formatThousands(int64(result.FilesSkipped))},
+		{"Total size", formatByteSize(result.TotalBytes)},
+		{"Processing time", formatElapsed(result.Duration)},
+	}
+	header := [2]string{"Metric", "Value"}
+
+	widths := [2]int{utf8.RuneCountInString(header[0]), utf8.RuneCountInString(header[1])}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	border := func(left, middle, right string) string {
+		return left + strings.Repeat("─", widths[0]+2) + middle + strings.Repeat("─", widths[1]+2) + right + "\n"
+	}
+	line := func(row [2]string) string {
+		return fmt.Sprintf("│ %s │ %s │\n", padRight(row[0], widths[0]), padRight(row[1], widths[1]))
+	}
+
+	var table strings.Builder
+	table.WriteString(border("┌", "┬", "┐"))
+	table.WriteString(line(header))
+	table.WriteString(border("├", "┼", "┤"))
+	for _, row := range rows {
+		table.WriteString(line(row))
+	}
+	table.WriteString(border("└", "┴", "┘"))
+
+	_, err := io.WriteString(w, table.String())
+	return err
+}
+
+// fileCountHeader returns the comment line written before the tree in text output, e.g.
+// "# Files: 1,203 | Size: 47.2 MB | Generated: 2024-01-15T10:30:00Z".
+func fileCountHeader(contents []FileContent, generated time.Time) string {
+	var size int64
+	for _, content := range contents {
+		size += int64(len(content.Content))
+	}
+	return fmt.Sprintf("# Files: %s | Size: %s | Generated: %s\n\n",
+		formatThousands(int64(len(contents))), formatByteSize(size), generated.UTC().Format(time.RFC3339))
+}
+
+// padRight pads s with spaces to width runes.
+func padRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-utf8.RuneCountInString(s), 0))
+}
+
+// formatThousands formats n with commas between groups of three digits, e.g. 1,203.
+func formatThousands(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return sign + b.String()
+}
+
+// formatByteSize formats a size in bytes with a binary unit, e.g. 47.2 MB.
+func formatByteSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size) / unit
+	units := []string{"KB", "MB", "GB", "TB"}
+	i := 0
+	for value >= unit && i < len(units)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
+}
+
+// formatElapsed formats a duration with one decimal of its largest sensible unit, e.g. 3.2s or 450ms.
+func formatElapsed(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
@@ -0,0 +1,50 @@
+// File: pkg/combine/symlinks.go
+package combine
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// symlinkTarget returns the target of the symlink at path as written in the link, and whether
+// it resolves to a directory.
+func symlinkTarget(path string) (string, bool) {
+	target, err := os.Readlink(path)
+	if err != nil {
+		target = "?"
+	}
+	info, err := os.Stat(path)
+	return target, err == nil && info.IsDir()
+}
+
+// exceedsSymlinkDepth follows the chain of symlinks starting at path and reports whether
+// resolving it takes more than maxDepth redirects. A non-positive maxDepth disables the limit.
+func exceedsSymlinkDepth(path string, maxDepth int) (bool, error) {
+	if maxDepth <= 0 {
+		return false, nil
+	}
+
+	current := path
+	for hops := 0; ; hops++ {
+		info, err := os.Lstat(current)
+		if err != nil {
+			return false, err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return false, nil
+		}
+		if hops >= maxDepth {
+			return true, nil
+		}
+
+		target, err := os.Readlink(current)
+		if err != nil {
+			return false, err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(current), target)
+		}
+		current = target
+	}
+}
@@ -0,0 +1,149 @@
+// File: pkg/combine/templates.go
+package combine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Project types recognized by DetectProjectType.
+const (
+	ProjectGo      = "go"      // Go module, detected by go.mod.
+	ProjectNode    = "node"    // Node.js package, detected by package.json.
+	ProjectPython  = "python"  // Python project, detected by pyproject.toml.
+	ProjectRust    = "rust"    // Rust crate, detected by Cargo.toml.
+	ProjectJava    = "java"    // Java project, detected by pom.xml, build.gradle, or build.gradle.kts.
+	ProjectGeneric = "generic" // Any other project.
+)
+
+// projectMarkers lists the file that identifies each project type, in detection order.
+var projectMarkers = []struct {
+	file        string
+	projectType string
+}{
+	{"go.mod", ProjectGo},
+	{"package.json", ProjectNode},
+	{"pyproject.toml", ProjectPython},
+	{"Cargo.toml", ProjectRust},
+	{"pom.xml", ProjectJava},
+	{"build.gradle", ProjectJava},
+	{"build.gradle.kts", ProjectJava},
+}
+
+// projectLanguages maps the languages accepted by ProjectTypeForLanguage to their project type.
+var projectLanguages = map[string]string{
+	"go":     ProjectGo,
+	"js":     ProjectNode,
+	"node":   ProjectNode,
+	"python": ProjectPython,
+	"rust":   ProjectRust,
+	"java":   ProjectJava,
+}
+
+// genericIgnorePatterns are the default patterns written for every project type.
+var genericIgnorePatterns = []string{
+	"# Build output",
+	"dist/",
+	"build/",
+	"out/",
+	"",
+	"# Dependencies and lock files",
+	"*.lock",
+	"",
+	"# Test fixtures",
+	"testdata/",
+	"fixtures/",
+	"__fixtures__/",
+	"",
+	"# Version control",
+	".git/",
+	".svn/",
+	".hg/",
+	"",
+	"# Editors",
+	".idea/",
+	".vscode/",
+	".vs/",
+	"*.swp",
+	"*~",
+	"",
+	"# OS files",
+	".DS_Store",
+	"Thumbs.db",
+	"desktop.ini",
+	"",
+	"# Logs",
+	"*.log",
+}
+
+// projectIgnorePatterns are the default patterns written in addition to the generic ones.
+var projectIgnorePatterns = map[string][]string{
+	ProjectGo: {
+		"# Go",
+		"vendor/",
+		"go.sum",
+		"*.test",
+		"*.out",
+	},
+	ProjectNode: {
+		"# Node.js",
+		"node_modules/",
+		"package-lock.json",
+		"coverage/",
+		".next/",
+		"*.min.js",
+	},
+	ProjectPython: {
+		"# Python",
+		"__pycache__/",
+		"*.py[cod]",
+		".venv/",
+		"venv/",
+		"*.egg-info/",
+		".pytest_cache/",
+		".mypy_cache/",
+	},
+	ProjectRust: {
+		"# Rust",
+		"target/",
+	},
+	ProjectJava: {
+		"# Java",
+		"target/",
+		".gradle/",
+		"*.class",
+		"*.jar",
+		"*.war",
+	},
+}
+
+// DetectProjectType returns the project type of dir based on the marker files it contains,
+// or ProjectGeneric if it has none.
+func DetectProjectType(dir string) string {
+	for _, marker := range projectMarkers {
+		if info, err := os.Stat(filepath.Join(dir, marker.file)); err == nil && !info.IsDir() {
+			return marker.projectType
+		}
+	}
+	return ProjectGeneric
+}
+
+// ProjectTypeForLanguage returns the project type for a language name given to agentexec init,
+// one of "go", "js" (or "node"), "python", "rust", and "java", case-insensitively. It reports
+// false for unknown languages.
+func ProjectTypeForLanguage(lang string) (string, bool) {
+	projectType, ok := projectLanguages[strings.ToLower(strings.TrimSpace(lang))]
+	return projectType, ok
+}
+
+// IgnoreTemplate returns the contents of a default .combineignore file for projectType.
+func IgnoreTemplate(projectType string) string {
+	var b strings.Builder
+	b.WriteString("# .combineignore generated by agentexec init for a " + projectType + " project\n\n")
+	if patterns, ok := projectIgnorePatterns[projectType]; ok {
+		b.WriteString(strings.Join(patterns, "\n") + "\n\n")
+	}
+	b.WriteString(strings.Join(genericIgnorePatterns, "\n") + "\n")
+	return b.String()
+}
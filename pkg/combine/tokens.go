@@ -0,0 +1,68 @@
+// File: pkg/combine/tokens.go
+package combine
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"unicode"
+
+	"go.uber.org/zap"
+)
+
+// CountTokens approximates the number of LLM tokens in content.
+// Runs of letters and digits count as one token each, every punctuation or
+// symbol character counts as its own token, and whitespace is ignored.
+func CountTokens(content string) int {
+	tokens := 0
+	inWord := false
+	for _, r := range content {
+		switch {
+		case unicode.IsSpace(r):
+			inWord = false
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			tokens++
+			inWord = false
+		default:
+			if !inWord {
+				tokens++
+				inWord = true
+			}
+		}
+	}
+	return tokens
+}
+
+// limitTokens returns the leading files of contents whose combined token estimate stays within
+// maxTokens, and the paths of the files dropped after the limit was reached.
+func limitTokens(contents []FileContent, maxTokens int, logger *zap.Logger) ([]FileContent, []string) {
+	total := 0
+	for i, content := range contents {
+		if total+content.Tokens > maxTokens {
+			dropped := make([]string, 0, len(contents)-i)
+			for _, rest := range contents[i:] {
+				dropped = append(dropped, rest.Path)
+			}
+			logger.Warn("Token limit reached, dropping remaining files",
+				zap.Int("maxTokens", maxTokens),
+				zap.Int("includedTokens", total),
+				zap.Strings("droppedFiles", dropped))
+			return contents[:i], dropped
+		}
+		total += content.Tokens
+	}
+	return contents, nil
+}
+
+// writeTokenSummary writes a table of each file's estimated token count and the total to w.
+func writeTokenSummary(w io.Writer, contents []FileContent) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tTOKENS")
+	total := 0
+	for _, content := range contents {
+		fmt.Fprintf(tw, "%s\t%d\n", content.Path, content.Tokens)
+		total += content.Tokens
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\n", total)
+	return tw.Flush()
+}
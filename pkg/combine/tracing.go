@@ -0,0 +1,102 @@
+// File: pkg/combine/tracing.go
+package combine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+
+	"agentexec/pkg/version"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of a combine run. Until StartTracing installs a tracer provider, its
+// spans are no-ops.
+var tracer = otel.Tracer("agentexec/pkg/combine")
+
+// StartTracing exports the spans of combine runs over OTLP/gRPC to endpoint, e.g.
+// "http://localhost:4317"; an http endpoint is connected to without TLS. The returned function
+// flushes the pending spans and stops the export, and must be called before the program exits.
+func StartTracing(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: expected an http or https URL", endpoint)
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", "agentexec"),
+			attribute.String("service.version", version.Version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// endSpan ends span, marking it as failed with err if err is not nil.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// argumentAttributes returns one span attribute per exported Arguments field, keyed by the field
+// name in lower camel case under "agentexec.args.", e.g. "agentexec.args.maxWorkers".
+func argumentAttributes(args Arguments) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	v := reflect.ValueOf(args)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := "agentexec.args." + lowerCamel(field.Name)
+		value := v.Field(i)
+		switch {
+		case value.Type() == reflect.TypeOf(time.Duration(0)):
+			attrs = append(attrs, attribute.String(key, time.Duration(value.Int()).String()))
+		case value.Kind() == reflect.Bool:
+			attrs = append(attrs, attribute.Bool(key, value.Bool()))
+		case value.CanInt():
+			attrs = append(attrs, attribute.Int64(key, value.Int()))
+		case value.Kind() == reflect.Float64:
+			attrs = append(attrs, attribute.Float64(key, value.Float()))
+		case value.Kind() == reflect.String:
+			attrs = append(attrs, attribute.String(key, value.String()))
+		case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String:
+			attrs = append(attrs, attribute.StringSlice(key, value.Interface().([]string)))
+		case value.Kind() == reflect.Pointer:
+			switch {
+			case value.IsNil():
+			case value.Elem().Kind() == reflect.Bool:
+				attrs = append(attrs, attribute.Bool(key, value.Elem().Bool()))
+			case value.Elem().CanInt():
+				attrs = append(attrs, attribute.Int64(key, value.Elem().Int()))
+			}
+		default:
+			// Maps and other composite values are recorded in their JSON encoding
+			data, err := json.Marshal(value.Interface())
+			if err == nil {
+				attrs = append(attrs, attribute.String(key, string(data)))
+			}
+		}
+	}
+	return attrs
+}
@@ -2,26 +2,52 @@
 package combine
 
 import (
+	"errors"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 // CollectFiles traverses the provided paths and collects regular and binary files.
-func CollectFiles(paths []string, gi IgnoreParser, maxFileSizeKB int, logger *zap.Logger, verbose bool) (CollectedFiles, error) {
+// Paths are read from opts.FS when it is set, and from the host filesystem otherwise.
+// It honors the WithLogger, WithMaxFileSizeKB, and WithVerbose options.
+func CollectFiles(paths []string, gi IgnoreParser, opts CollectOptions, options ...Option) (CollectedFiles, error) {
+	o := newOptions(options)
+	logger, maxFileSizeKB, verbose := o.logger, o.maxFileSizeKB, o.verbose
+
 	var collected CollectedFiles
 	logger.Debug("Starting file collection", zap.Int("pathCount", len(paths)))
 
+	// A file reachable from several inputs, e.g. through hard links, is collected once
+	if opts.visited == nil {
+		opts.visited = newVisitedFiles()
+	}
+
 	for _, path := range paths {
-		absPath, err := filepath.Abs(path)
+		absPath, err := absPathFS(opts.FS, path)
 		if err != nil {
 			logger.Warn("Failed to get absolute path", zap.String("path", path), zap.Error(err))
 			continue
 		}
 
-		info, err := os.Stat(absPath)
+		if opts.FS == nil {
+			if exceeded, err := exceedsSymlinkDepth(absPath, opts.MaxSymlinkDepth); err != nil {
+				logger.Warn("Failed to resolve symlink", zap.String("path", absPath), zap.Error(err))
+				continue
+			} else if exceeded {
+				logger.Debug("Skipping path exceeding maximum symlink depth", zap.String("path", absPath), zap.Int("maxSymlinkDepth", opts.MaxSymlinkDepth))
+				continue
+			}
+		}
+
+		statStart := time.Now()
+		info, err := statFS(opts.FS, absPath)
+		opts.ioProfile.observe(ioStat, statStart)
 		if err != nil {
 			logger.Warn("Path does not exist or cannot be accessed", zap.String("path", absPath), zap.Error(err))
 			continue
@@ -29,15 +55,26 @@ func CollectFiles(paths []string, gi IgnoreParser, maxFileSizeKB int, logger *za
 
 		if info.IsDir() {
 			logger.Debug("Processing directory", zap.String("dir", absPath))
-			c, err := TraverseAndCollectFiles(absPath, gi, maxFileSizeKB, logger, verbose)
+			c, err := TraverseAndCollectFiles(absPath, gi, maxFileSizeKB, opts, logger, verbose)
 			if err != nil {
 				logger.Warn("Failed to traverse directory", zap.String("dir", absPath), zap.Error(err))
 				continue
 			}
 			collected.Regular = append(collected.Regular, c.Regular...)
 			collected.Binary = append(collected.Binary, c.Binary...)
+			collected.PermissionDenied = append(collected.PermissionDenied, c.PermissionDenied...)
+			collected.Symlinks = append(collected.Symlinks, c.Symlinks...)
+			for reason, n := range c.Skipped {
+				collected.countSkipped(reason, n)
+			}
 		} else {
-			if shouldSkipFile(absPath, info, gi, maxFileSizeKB, logger, verbose) {
+			if reason := shouldSkipFile(absPath, info, gi, maxFileSizeKB, opts, logger, verbose); reason != SkipNone {
+				logger.Debug("Skipping file", zap.String("file", absPath), zap.Stringer("reason", reason))
+				collected.countSkipped(reason, 1)
+				continue
+			}
+			if first, ok := opts.visited.visit(absPath, info); !ok {
+				logger.Debug("Skipping file already collected under another path", zap.String("file", absPath), zap.String("firstPath", first))
 				continue
 			}
 			collected.Regular = append(collected.Regular, absPath)
@@ -48,12 +85,70 @@ func CollectFiles(paths []string, gi IgnoreParser, maxFileSizeKB int, logger *za
 }
 
 // TraverseAndCollectFiles traverses a directory and collects files based on criteria.
-func TraverseAndCollectFiles(parentDir string, gi IgnoreParser, maxFileSizeKB int, logger *zap.Logger, verbose bool) (CollectedFiles, error) {
+func TraverseAndCollectFiles(parentDir string, gi IgnoreParser, maxFileSizeKB int, opts CollectOptions, logger *zap.Logger, verbose bool) (CollectedFiles, error) {
 	var collected CollectedFiles
 	logger.Debug("Starting file traversal and collection", zap.String("parentDir", parentDir), zap.Int("maxFileSizeKB", maxFileSizeKB))
 
-	err := filepath.WalkDir(parentDir, func(path string, d fs.DirEntry, err error) error {
+	if opts.visited == nil {
+		opts.visited = newVisitedFiles()
+	}
+
+	// Apply `.combineignore` files found inside the tree only to their own directories
+	scoped := NewScopedIgnoreParser(gi, parentDir, logger)
+	scoped.fsys = opts.FS
+	if opts.RespectGitignore {
+		scoped.EnableGitignore()
+	}
+	gi = scoped
+
+	// Real paths of the directories entered, so that following symlinks cannot loop forever
+	seenDirs := make(map[string]struct{})
+	if opts.FS == nil {
+		if realPath, err := filepath.EvalSymlinks(parentDir); err == nil {
+			seenDirs[realPath] = struct{}{}
+		}
+	}
+
+	// walkDir walks root, reporting its entries under displayRoot, so that the contents of a
+	// symlinked directory appear beneath the link
+	var walkDir func(root, displayRoot string) error
+	var visit fs.WalkDirFunc
+	walkDir = func(root, displayRoot string) error {
+		// WalkDir lists a directory between the callback for the directory and the next callback
+		var readDirStart time.Time
+		endReadDir := func() {
+			if !readDirStart.IsZero() {
+				opts.ioProfile.observe(ioReadDir, readDirStart)
+				readDirStart = time.Time{}
+			}
+		}
+		defer endReadDir()
+
+		return walkDirFS(opts.FS, root, func(walkPath string, d fs.DirEntry, err error) error {
+			endReadDir()
+			path := walkPath
+			if rel, relErr := filepath.Rel(root, walkPath); relErr == nil {
+				path = filepath.Join(displayRoot, rel)
+			}
+			visitErr := visit(path, d, err)
+			if visitErr == nil && err == nil && d.IsDir() && opts.ioProfile != nil {
+				readDirStart = time.Now()
+			}
+			return visitErr
+		})
+	}
+
+	visit = func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
+			if errors.Is(err, fs.ErrPermission) {
+				collected.PermissionDenied = append(collected.PermissionDenied, path)
+				if d != nil && d.IsDir() {
+					logger.Warn("Skipping directory (permission denied)", zap.String("directory", path))
+				} else {
+					logger.Warn("Skipping path (permission denied)", zap.String("path", path))
+				}
+				return nil
+			}
 			logger.Warn("Error accessing path during traversal", zap.String("path", path), zap.Error(err))
 			return nil // Skip paths that cause errors
 		}
@@ -61,17 +156,108 @@ func TraverseAndCollectFiles(parentDir string, gi IgnoreParser, maxFileSizeKB in
 		relPath, _ := filepath.Rel(parentDir, path)
 		relPath = normalizePath(relPath)
 
-		if d.IsDir() && gi.MatchesPath(relPath) {
+		if d.IsDir() && gi.MatchesDirectory(relPath) {
 			logger.Debug("Skipping ignored directory during traversal", zap.String("directory", path))
 			return filepath.SkipDir
 		}
+		if d.IsDir() && atMaxDepth(relPath, opts.MaxDepth) {
+			logger.Debug("Not descending into directory at maximum depth during traversal", zap.String("directory", path), zap.Int("maxDepth", opts.MaxDepth))
+			return filepath.SkipDir
+		}
 
-		if !d.IsDir() && !gi.MatchesPath(relPath) {
-			isBinary, err := isBinaryFile(path)
-			if err != nil {
-				logger.Warn("Failed to check if file is binary during traversal", zap.String("filePath", path), zap.Error(err))
+		// Symlinks to directories are only descended into when following symlinks
+		if d.Type()&fs.ModeSymlink != 0 && opts.FS == nil {
+			statStart := time.Now()
+			info, err := os.Stat(path)
+			opts.ioProfile.observe(ioStat, statStart)
+			if err == nil && info.IsDir() {
+				if !opts.FollowSymlinks {
+					collected.Symlinks = append(collected.Symlinks, path)
+					logger.Debug("Not following symlink to directory during traversal", zap.String("path", path))
+					return nil
+				}
+				if gi.MatchesDirectory(relPath) {
+					logger.Debug("Skipping ignored symlinked directory during traversal", zap.String("directory", path))
+					return nil
+				}
+				if atMaxDepth(relPath, opts.MaxDepth) {
+					logger.Debug("Not following symlink to directory at maximum depth during traversal", zap.String("path", path), zap.Int("maxDepth", opts.MaxDepth))
+					return nil
+				}
+				realPath, err := filepath.EvalSymlinks(path)
+				if err != nil {
+					logger.Warn("Failed to resolve symlink during traversal", zap.String("path", path), zap.Error(err))
+					return nil
+				}
+				if _, seen := seenDirs[realPath]; seen {
+					logger.Debug("Skipping symlink to already traversed directory", zap.String("path", path), zap.String("target", realPath))
+					return nil
+				}
+				seenDirs[realPath] = struct{}{}
+				collected.Symlinks = append(collected.Symlinks, path)
+				if err := walkDir(realPath, path); err != nil {
+					logger.Warn("Failed to traverse symlinked directory", zap.String("path", path), zap.Error(err))
+				}
 				return nil
 			}
+		}
+
+		if !d.IsDir() && gi.MatchesPath(relPath) {
+			if verbose {
+				logger.Debug("Skipping ignored file during traversal", zap.String("filePath", path))
+			}
+			collected.countSkipped(SkipIgnorePattern, 1)
+			return nil
+		}
+
+		if !d.IsDir() && !matchesAnyInclude(opts.Include, relPath) {
+			if verbose {
+				logger.Debug("Skipping file matching no include pattern during traversal", zap.String("filePath", path))
+			}
+			collected.countSkipped(SkipNotIncluded, 1)
+			return nil
+		}
+
+		if !d.IsDir() {
+			if d.Type()&fs.ModeSymlink != 0 && opts.FS == nil {
+				if opts.SkipFileSymlinks {
+					logger.Debug("Skipping symlink to file during traversal", zap.String("path", path))
+					return nil
+				}
+				exceeded, err := exceedsSymlinkDepth(path, opts.MaxSymlinkDepth)
+				if err != nil {
+					logger.Warn("Failed to resolve symlink during traversal", zap.String("path", path), zap.Error(err))
+					return nil
+				}
+				if exceeded {
+					logger.Debug("Skipping symlink exceeding maximum depth during traversal", zap.String("path", path), zap.Int("maxSymlinkDepth", opts.MaxSymlinkDepth))
+					return nil
+				}
+			}
+
+			// Hard links and symlinks resolve to the same device and inode as the file they share
+			statStart := time.Now()
+			target, statErr := statFS(opts.FS, path)
+			opts.ioProfile.observe(ioStat, statStart)
+			if statErr == nil {
+				if first, ok := opts.visited.visit(path, target); !ok {
+					logger.Debug("Skipping file already collected under another path during traversal", zap.String("filePath", path), zap.String("firstPath", first))
+					return nil
+				}
+			}
+
+			// Extensions treated as text bypass both binary checks
+			treatAsText := opts.Binary.HasTextExtension(path)
+			isBinary := !treatAsText && opts.Binary.HasBinaryExtension(path)
+			if !isBinary && !treatAsText {
+				checkStart := time.Now()
+				isBinary, err = opts.Binary.isBinaryFile(opts.FS, path)
+				opts.ioProfile.observe(ioBinaryCheck, checkStart)
+				if err != nil {
+					logger.Warn("Failed to check if file is binary during traversal", zap.String("filePath", path), zap.Error(err))
+					return nil
+				}
+			}
 
 			if isBinary {
 				collected.Binary = append(collected.Binary, path)
@@ -81,16 +267,27 @@ func TraverseAndCollectFiles(parentDir string, gi IgnoreParser, maxFileSizeKB in
 				return nil
 			}
 
+			infoStart := time.Now()
 			info, err := d.Info()
+			opts.ioProfile.observe(ioStat, infoStart)
 			if err != nil {
 				logger.Warn("Failed to get file info during traversal", zap.String("filePath", path), zap.Error(err))
 				return nil
 			}
 
-			if info.Size() > int64(maxFileSizeKB)*1024 {
+			if size := effectiveSize(path, info); size > int64(maxFileSizeKB)*1024 {
 				if verbose {
-					logger.Debug("Skipping file due to size limit during traversal", zap.String("filePath", path), zap.Int64("sizeBytes", info.Size()))
+					logger.Debug("Skipping file due to size limit during traversal", zap.String("filePath", path), zap.Int64("sizeBytes", size))
 				}
+				collected.countSkipped(SkipSizeLimit, 1)
+				return nil
+			}
+
+			if opts.isTooOld(info) {
+				if verbose {
+					logger.Debug("Skipping file older than the age limit during traversal", zap.String("filePath", path), zap.Time("modTime", info.ModTime()))
+				}
+				collected.countSkipped(SkipTooOld, 1)
 				return nil
 			}
 
@@ -99,13 +296,194 @@ func TraverseAndCollectFiles(parentDir string, gi IgnoreParser, maxFileSizeKB in
 		}
 
 		return nil
-	})
+	}
 
-	if err != nil {
+	if err := walkDir(parentDir, parentDir); err != nil {
 		logger.Error("Error during file traversal", zap.Error(err))
 		return collected, err
 	}
 
-	logger.Debug("Completed file traversal and collection", zap.Int("regularFiles", len(collected.Regular)), zap.Int("binaryFiles", len(collected.Binary)))
+	logger.Debug("Completed file traversal and collection", zap.Int("regularFiles", len(collected.Regular)), zap.Int("binaryFiles", len(collected.Binary)), zap.Any("skippedFiles", collected.Skipped))
 	return collected, nil
 }
+
+// ScopedIgnoreParser applies a base IgnoreParser to every path and, in addition, one CombineIgnore
+// per base directory that only applies to paths under that directory. Paths are interpreted relative
+// to root. `.combineignore` files inside root are discovered lazily as paths are matched; those in
+// the current directory and its parents are skipped since LoadIgnoreFiles already applies them globally.
+// When gitignore support is enabled, `.gitignore` files are scoped the same way from the enclosing
+// repository root down, with a directory's `.combineignore` taking precedence over its `.gitignore`.
+type ScopedIgnoreParser struct {
+	base    IgnoreParser
+	root    string
+	cwd     string
+	gitRoot string // Repository root from which `.gitignore` files apply; empty if gitignore support is disabled.
+	fsys    fs.FS  // File system holding root and its ignore files; nil for the host filesystem.
+	logger  *zap.Logger
+
+	mu     sync.Mutex
+	scopes map[string]*CombineIgnore // Keyed by absolute base directory; nil if the directory has no ignore file.
+}
+
+// NewScopedIgnoreParser creates a ScopedIgnoreParser for paths relative to root.
+func NewScopedIgnoreParser(base IgnoreParser, root string, logger *zap.Logger) *ScopedIgnoreParser {
+	cwd, _ := os.Getwd()
+	return &ScopedIgnoreParser{
+		base:   base,
+		root:   filepath.Clean(root),
+		cwd:    cwd,
+		logger: logger,
+		scopes: make(map[string]*CombineIgnore),
+	}
+}
+
+// EnableGitignore makes the parser also apply `.gitignore` files, from the root of the git
+// repository containing root, or from root itself if it is not inside a repository.
+func (s *ScopedIgnoreParser) EnableGitignore() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gitRoot = findGitRoot(s.fsys, s.root)
+	if s.gitRoot == "" {
+		s.gitRoot = s.root
+	}
+	s.scopes = make(map[string]*CombineIgnore)
+}
+
+// AddScope registers gi to apply only to paths under baseDir.
+func (s *ScopedIgnoreParser) AddScope(baseDir string, gi *CombineIgnore) {
+	absBaseDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		absBaseDir = baseDir
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scopes[absBaseDir] = gi
+}
+
+// MatchesPath checks if the given path matches the base patterns or any applicable scoped patterns.
+func (s *ScopedIgnoreParser) MatchesPath(path string) bool {
+	matches, _ := s.MatchesPathWithPattern(path)
+	return matches
+}
+
+// MatchesPathWithPattern checks the base patterns first and then the scoped patterns from the
+// outermost to the innermost directory containing path; the last matching pattern wins.
+func (s *ScopedIgnoreParser) MatchesPathWithPattern(path string) (bool, *IgnorePattern) {
+	matched, matchedPattern := s.base.MatchesPathWithPattern(path)
+
+	absPath := filepath.FromSlash(path)
+	if !filepath.IsAbs(absPath) {
+		absPath = filepath.Join(s.root, absPath)
+	}
+
+	// Collect scopes from the innermost directory outwards
+	var bases []string
+	var scopes []*CombineIgnore
+	for dir := filepath.Dir(absPath); ; dir = filepath.Dir(dir) {
+		if gi := s.scopeFor(dir); gi != nil {
+			bases = append(bases, dir)
+			scopes = append(scopes, gi)
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	if len(scopes) == 0 {
+		return matched, matchedPattern
+	}
+
+	isDir := strings.HasSuffix(filepath.ToSlash(path), "/")
+	if info, err := statFS(s.fsys, absPath); err == nil && info.IsDir() {
+		isDir = true
+	}
+
+	for i := len(scopes) - 1; i >= 0; i-- {
+		relPath, err := filepath.Rel(bases[i], absPath)
+		if err != nil {
+			continue
+		}
+		relPath = filepath.ToSlash(relPath)
+		if isDir {
+			relPath += "/"
+		}
+		if m, pattern := scopes[i].MatchesPathWithPattern(relPath); pattern != nil {
+			matched, matchedPattern = m, pattern
+		}
+	}
+	return matched, matchedPattern
+}
+
+// MatchesDirectory checks if the directory at relPath is ignored; see CombineIgnore.MatchesDirectory.
+func (s *ScopedIgnoreParser) MatchesDirectory(relPath string) bool {
+	return matchesDirectory(s, relPath)
+}
+
+// scopeFor returns the scoped patterns registered for dir, loading dir's `.combineignore`
+// file on first use when dir lies inside root, preceded by its `.gitignore` file when
+// gitignore support is enabled and dir lies inside the repository.
+func (s *ScopedIgnoreParser) scopeFor(dir string) *CombineIgnore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if gi, ok := s.scopes[dir]; ok {
+		return gi
+	}
+
+	// Later files override earlier ones, so `.combineignore` is compiled last
+	var ignoreFiles []string
+	if s.gitRoot != "" && isWithinDir(dir, s.gitRoot) {
+		ignoreFiles = append(ignoreFiles, filepath.Join(dir, ".gitignore"))
+	}
+	if isWithinDir(dir, s.root) && !isWithinDir(s.cwd, dir) {
+		ignoreFiles = append(ignoreFiles, filepath.Join(dir, ".combineignore"))
+	}
+
+	var gi *CombineIgnore
+	for _, ignoreFilePath := range ignoreFiles {
+		if _, err := statFS(s.fsys, ignoreFilePath); err != nil {
+			continue
+		}
+		if gi == nil {
+			gi = NewCombineIgnore(s.logger)
+		}
+		if err := gi.compileIgnoreFileFS(s.fsys, ignoreFilePath); err != nil {
+			s.logger.Warn("Failed to compile scoped ignore file", zap.String("file", ignoreFilePath), zap.Error(err))
+			continue
+		}
+		s.logger.Debug("Loaded scoped ignore file", zap.String("file", ignoreFilePath))
+	}
+	s.scopes[dir] = gi
+	return gi
+}
+
+// findGitRoot returns the nearest directory at or above dir in fsys that contains a `.git` entry,
+// or the empty string if there is none.
+func findGitRoot(fsys fs.FS, dir string) string {
+	for {
+		if _, err := statFS(fsys, filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// isWithinDir reports whether path is dir or lies underneath it.
+func isWithinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// atMaxDepth reports whether the directory at relPath, a slash-separated path relative to its root,
+// lies maxDepth levels below the root, so that its entries would exceed the limit. A non-positive
+// maxDepth means unlimited.
+func atMaxDepth(relPath string, maxDepth int) bool {
+	relPath = strings.Trim(relPath, "/")
+	if maxDepth <= 0 || relPath == "" || relPath == "." {
+		return false
+	}
+	return strings.Count(relPath, "/")+1 >= maxDepth
+}
@@ -0,0 +1,411 @@
+// File: pkg/combine/traversal_test.go
+package combine_test
+
+import (
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"agentexec/pkg/combine"
+	"agentexec/pkg/combine/combinetest"
+)
+
+// collect runs CollectFiles with opts on the root of fsys and returns the sorted names of the
+// regular and binary files it collected. A nil gi ignores nothing.
+func collect(t *testing.T, fsys fs.FS, gi combine.IgnoreParser, opts combine.CollectOptions, options ...combine.Option) (regular, binary []string) {
+	t.Helper()
+	if gi == nil {
+		gi = combinetest.NewTestCombineIgnore(nil)
+	}
+	opts.FS = fsys
+	collected, err := combine.CollectFiles([]string{"."}, gi, opts, options...)
+	if err != nil {
+		t.Fatalf("CollectFiles() = %v", err)
+	}
+	slices.Sort(collected.Regular)
+	slices.Sort(collected.Binary)
+	return collected.Regular, collected.Binary
+}
+
+func text(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+
+func TestCollectFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":            text("package main\n"),
+		"README.md":          text("# readme\n"),
+		"internal/util.go":   text("package internal\n"),
+		"internal/deep/x.go": text("package deep\n"),
+		"build/out.txt":      text("build output\n"),
+		"empty":              &fstest.MapFile{Mode: fs.ModeDir},
+		"image.bin":          &fstest.MapFile{Data: []byte{0x7F, 'E', 'L', 'F', 0, 1, 2}},
+		"nul.txt":            &fstest.MapFile{Data: []byte("text\x00more")},
+	}
+
+	tests := []struct {
+		name        string
+		patterns    []string
+		opts        combine.CollectOptions
+		wantRegular []string
+		wantBinary  []string
+	}{
+		{
+			name:        "everything",
+			wantRegular: []string{"README.md", "build/out.txt", "internal/deep/x.go", "internal/util.go", "main.go"},
+			wantBinary:  []string{"image.bin", "nul.txt"},
+		},
+		{
+			name:        "ignored directory and extension",
+			patterns:    []string{"build/", "*.md"},
+			wantRegular: []string{"internal/deep/x.go", "internal/util.go", "main.go"},
+			wantBinary:  []string{"image.bin", "nul.txt"},
+		},
+		{
+			name:        "ignored nested file",
+			patterns:    []string{"internal/deep/x.go", "nul.txt"},
+			wantRegular: []string{"README.md", "build/out.txt", "internal/util.go", "main.go"},
+			wantBinary:  []string{"image.bin"},
+		},
+		{
+			name:        "include patterns",
+			opts:        combine.CollectOptions{Include: combinetest.NewTestCombineIgnore([]string{"*.go"})},
+			wantRegular: []string{"internal/deep/x.go", "internal/util.go", "main.go"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regular, binary := collect(t, fsys, combinetest.NewTestCombineIgnore(tt.patterns), tt.opts)
+			if !slices.Equal(regular, tt.wantRegular) {
+				t.Errorf("regular files = %q, want %q", regular, tt.wantRegular)
+			}
+			if !slices.Equal(binary, tt.wantBinary) {
+				t.Errorf("binary files = %q, want %q", binary, tt.wantBinary)
+			}
+		})
+	}
+}
+
+func TestCollectFilesSizeLimit(t *testing.T) {
+	fsys := fstest.MapFS{
+		"small.txt": text("small\n"),
+		"large.txt": text(strings.Repeat("a", 2048)),
+	}
+	regular, binary := collect(t, fsys, nil, combine.CollectOptions{}, combine.WithMaxFileSizeKB(1))
+	if want := []string{"small.txt"}; !slices.Equal(regular, want) || len(binary) != 0 {
+		t.Errorf("regular files = %q, binary files = %q, want %q and no binary files", regular, binary, want)
+	}
+}
+
+func TestCollectFilesExcludeOlderThan(t *testing.T) {
+	now := time.Now()
+	fsys := fstest.MapFS{
+		"new.go":     &fstest.MapFile{Data: []byte("package new\n"), ModTime: now.Add(-time.Hour)},
+		"old.go":     &fstest.MapFile{Data: []byte("package old\n"), ModTime: now.Add(-48 * time.Hour)},
+		"lib/new.go": &fstest.MapFile{Data: []byte("package lib\n"), ModTime: now},
+	}
+	collected, err := combine.CollectFiles([]string{".", "old.go"}, combinetest.NewTestCombineIgnore(nil), combine.CollectOptions{FS: fsys, ExcludeOlderThan: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("CollectFiles() = %v", err)
+	}
+	slices.Sort(collected.Regular)
+	if want := []string{"lib/new.go", "new.go"}; !slices.Equal(collected.Regular, want) {
+		t.Errorf("regular files = %q, want %q", collected.Regular, want)
+	}
+	if n := collected.Skipped[combine.SkipTooOld]; n != 2 {
+		t.Errorf("files skipped for their age = %d, want 2", n)
+	}
+}
+
+func TestCollectFilesSkipped(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":     text("package main\n"),
+		"main.log":    text("log\n"),
+		"app.log":     text("log\n"),
+		"large.txt":   text(strings.Repeat("a", 2048)),
+		"lib/app.log": text("log\n"),
+	}
+	collected, err := combine.CollectFiles([]string{".", "main.log"}, combinetest.NewTestCombineIgnore([]string{"*.log"}), combine.CollectOptions{FS: fsys}, combine.WithMaxFileSizeKB(1))
+	if err != nil {
+		t.Fatalf("CollectFiles() = %v", err)
+	}
+	want := map[combine.SkipReason]int{combine.SkipIgnorePattern: 4, combine.SkipSizeLimit: 1}
+	if !maps.Equal(collected.Skipped, want) {
+		t.Errorf("skipped files = %v, want %v", collected.Skipped, want)
+	}
+}
+
+func TestCollectFilesSingleFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cmd/main.go":   text("package main\n"),
+		"cmd/image.png": text("not really a png\n"),
+	}
+	collected, err := combine.CollectFiles([]string{"cmd/main.go", "cmd/image.png", "missing.go"}, combinetest.NewTestCombineIgnore(nil), combine.CollectOptions{FS: fsys})
+	if err != nil {
+		t.Fatalf("CollectFiles() = %v", err)
+	}
+	if want := []string{"cmd/main.go"}; !slices.Equal(collected.Regular, want) {
+		t.Errorf("regular files = %q, want %q", collected.Regular, want)
+	}
+	if n := collected.Skipped[combine.SkipBinaryExtension]; n != 1 {
+		t.Errorf("files skipped for their extension = %d, want 1", n)
+	}
+}
+
+func TestCollectFilesScopedIgnoreFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":                text("root log\n"),
+		"sub/.combineignore":   text("*.txt\n"),
+		"sub/b.txt":            text("sub log\n"),
+		"sub/b.go":             text("package sub\n"),
+		"sub/deep/c.txt":       text("deep log\n"),
+		"other/c.txt":          text("other log\n"),
+		"other/.combineignore": text("# no patterns\n"),
+	}
+	regular, _ := collect(t, fsys, nil, combine.CollectOptions{})
+	want := []string{"a.txt", "other/.combineignore", "other/c.txt", "sub/.combineignore", "sub/b.go"}
+	if !slices.Equal(regular, want) {
+		t.Errorf("regular files = %q, want %q", regular, want)
+	}
+}
+
+// TestCollectFilesIncludeAndIgnore checks that files must match an include pattern and no ignore
+// pattern when both overlap.
+func TestCollectFilesIncludeAndIgnore(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":          text("package main\n"),
+		"main_test.go":     text("package main\n"),
+		"lib/util.go":      text("package lib\n"),
+		"lib/gen.go":       text("package lib\n"),
+		"lib/README.md":    text("# lib\n"),
+		"docs/guide.md":    text("# guide\n"),
+		"docs/example.go":  text("package docs\n"),
+		"scripts/build.py": text("print()\n"),
+	}
+
+	tests := []struct {
+		name    string
+		include []string
+		ignore  []string
+		want    []string
+	}{
+		{
+			name:    "ignore narrows include",
+			include: []string{"*.go"},
+			ignore:  []string{"*_test.go", "gen.go"},
+			want:    []string{"docs/example.go", "lib/util.go", "main.go"},
+		},
+		{
+			name:    "ignored directory wins over include",
+			include: []string{"*.go", "*.md"},
+			ignore:  []string{"docs/"},
+			want:    []string{"lib/README.md", "lib/gen.go", "lib/util.go", "main.go", "main_test.go"},
+		},
+		{
+			name:    "same pattern in both",
+			include: []string{"*.md"},
+			ignore:  []string{"*.md"},
+		},
+		{
+			name:    "negated ignore inside include",
+			include: []string{"lib/*"},
+			ignore:  []string{"*.go", "!util.go"},
+			want:    []string{"lib/README.md", "lib/util.go"},
+		},
+		{
+			name:   "no include patterns",
+			ignore: []string{"*.go"},
+			want:   []string{"docs/guide.md", "lib/README.md", "scripts/build.py"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts combine.CollectOptions
+			if len(tt.include) > 0 {
+				opts.Include = combinetest.NewTestCombineIgnore(tt.include)
+			}
+			regular, _ := collect(t, fsys, combinetest.NewTestCombineIgnore(tt.ignore), opts)
+			if !slices.Equal(regular, tt.want) {
+				t.Errorf("regular files = %q, want %q", regular, tt.want)
+			}
+		})
+	}
+}
+
+// TestCollectFilesNestedGitignore checks that a .gitignore in a subdirectory only applies to
+// files in its subtree, and only when gitignore files are respected.
+func TestCollectFilesNestedGitignore(t *testing.T) {
+	fsys := fstest.MapFS{
+		"gen.go":                 text("package root\n"),
+		"app/.gitignore":         text("gen.go\n/local.txt\n"),
+		"app/gen.go":             text("package app\n"),
+		"app/main.go":            text("package app\n"),
+		"app/local.txt":          text("local\n"),
+		"app/internal/gen.go":    text("package internal\n"),
+		"app/internal/local.txt": text("nested local\n"),
+		"lib/gen.go":             text("package lib\n"),
+		"lib/local.txt":          text("local\n"),
+	}
+
+	t.Run("respected", func(t *testing.T) {
+		regular, _ := collect(t, fsys, nil, combine.CollectOptions{RespectGitignore: true})
+		want := []string{"app/.gitignore", "app/internal/local.txt", "app/main.go", "gen.go", "lib/gen.go", "lib/local.txt"}
+		if !slices.Equal(regular, want) {
+			t.Errorf("regular files = %q, want %q", regular, want)
+		}
+	})
+	t.Run("not respected", func(t *testing.T) {
+		regular, _ := collect(t, fsys, nil, combine.CollectOptions{})
+		if len(regular) != len(fsys) {
+			t.Errorf("regular files = %q, want all %d files", regular, len(fsys))
+		}
+	})
+}
+
+// TestCollectFilesNestedCombineignoreOverridesNegation checks that an inner .combineignore
+// re-ignores a file that a negation in the global patterns un-ignored, for its subtree only.
+func TestCollectFilesNestedCombineignoreOverridesNegation(t *testing.T) {
+	fsys := fstest.MapFS{
+		"foo.txt":              text("root foo\n"),
+		"bar.txt":              text("root bar\n"),
+		"inner/.combineignore": text("foo.txt\n"),
+		"inner/foo.txt":        text("inner foo\n"),
+		"inner/deep/foo.txt":   text("deep foo\n"),
+		"other/foo.txt":        text("other foo\n"),
+	}
+	regular, _ := collect(t, fsys, combinetest.NewTestCombineIgnore([]string{"*.txt", "!foo.txt"}), combine.CollectOptions{})
+	want := []string{"foo.txt", "inner/.combineignore", "other/foo.txt"}
+	if !slices.Equal(regular, want) {
+		t.Errorf("regular files = %q, want %q", regular, want)
+	}
+}
+
+// relPaths returns paths relative to root, slash-separated and sorted.
+func relPaths(t *testing.T, root string, paths []string) []string {
+	t.Helper()
+	rel := make([]string, 0, len(paths))
+	for _, path := range paths {
+		r, err := filepath.Rel(root, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	slices.Sort(rel)
+	return rel
+}
+
+// TestCollectFilesSymlinks checks that each kind of symlink is collected or skipped, and that
+// none makes the traversal loop forever.
+func TestCollectFilesSymlinks(t *testing.T) {
+	// Targets outside the traversed directory
+	outside := writeTree(t, fstest.MapFS{
+		"secret.txt":       text("outside\n"),
+		"lib/helper.go":    text("package lib\n"),
+		"chain/hop2":       symlink("hop3"),
+		"chain/hop3":       symlink("target.txt"),
+		"chain/target.txt": text("end of chain\n"),
+	})
+
+	tests := []struct {
+		name         string
+		fsys         fstest.MapFS
+		opts         combine.CollectOptions
+		wantRegular  []string
+		wantSymlinks []string
+	}{
+		{
+			name: "file outside the root",
+			fsys: fstest.MapFS{
+				"main.go": text("package main\n"),
+				"secret":  symlink(filepath.Join(outside, "secret.txt")),
+			},
+			wantRegular: []string{"main.go", "secret"},
+		},
+		{
+			name: "file outside the root with file symlinks skipped",
+			fsys: fstest.MapFS{
+				"main.go": text("package main\n"),
+				"secret":  symlink(filepath.Join(outside, "secret.txt")),
+			},
+			opts:        combine.CollectOptions{SkipFileSymlinks: true},
+			wantRegular: []string{"main.go"},
+		},
+		{
+			name: "directory cycle",
+			fsys: fstest.MapFS{
+				"main.go":  text("package main\n"),
+				"sub/a.go": text("package sub\n"),
+				"sub/loop": symlink(".."),
+				"sub/self": symlink("."),
+			},
+			// self is entered once, then its links lead only to directories already entered
+			opts:         combine.CollectOptions{FollowSymlinks: true},
+			wantRegular:  []string{"main.go", "sub/a.go"},
+			wantSymlinks: []string{"sub/self"},
+		},
+		{
+			name: "directory not followed",
+			fsys: fstest.MapFS{
+				"main.go": text("package main\n"),
+				"lib":     symlink(filepath.Join(outside, "lib")),
+			},
+			wantRegular:  []string{"main.go"},
+			wantSymlinks: []string{"lib"},
+		},
+		{
+			name: "directory followed",
+			fsys: fstest.MapFS{
+				"main.go": text("package main\n"),
+				"lib":     symlink(filepath.Join(outside, "lib")),
+			},
+			opts:         combine.CollectOptions{FollowSymlinks: true},
+			wantRegular:  []string{"lib/helper.go", "main.go"},
+			wantSymlinks: []string{"lib"},
+		},
+		{
+			name: "dangling",
+			fsys: fstest.MapFS{
+				"main.go": text("package main\n"),
+				"missing": symlink("does-not-exist.txt"),
+			},
+			opts:        combine.CollectOptions{FollowSymlinks: true},
+			wantRegular: []string{"main.go"},
+		},
+		{
+			// long resolves in three hops and short in two
+			name: "chain longer than the maximum depth",
+			fsys: fstest.MapFS{
+				"long":  symlink(filepath.Join(outside, "chain", "hop2")),
+				"short": symlink(filepath.Join(outside, "chain", "hop3")),
+			},
+			opts:        combine.CollectOptions{MaxSymlinkDepth: 2},
+			wantRegular: []string{"short"},
+		},
+		{
+			name: "chain without a maximum depth",
+			fsys: fstest.MapFS{
+				"long": symlink(filepath.Join(outside, "chain", "hop2")),
+			},
+			wantRegular: []string{"long"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := writeTree(t, tt.fsys)
+			collected, err := combine.CollectFiles([]string{root}, combinetest.NewTestCombineIgnore(nil), tt.opts)
+			if err != nil {
+				t.Fatalf("CollectFiles() = %v", err)
+			}
+			if got := relPaths(t, root, collected.Regular); !slices.Equal(got, tt.wantRegular) {
+				t.Errorf("regular files = %q, want %q", got, tt.wantRegular)
+			}
+			if got := relPaths(t, root, collected.Symlinks); !slices.Equal(got, tt.wantSymlinks) {
+				t.Errorf("symlinks = %q, want %q", got, tt.wantSymlinks)
+			}
+		})
+	}
+}
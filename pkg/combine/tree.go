@@ -2,18 +2,25 @@
 package combine
 
 import (
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 // GenerateFullTree generates a complete tree structure for all input paths.
 // It returns the tree as a string and any error encountered during generation.
-func GenerateFullTree(paths []string, gi IgnoreParser, logger *zap.Logger) (string, error) {
+// It honors the WithLogger option.
+func GenerateFullTree(paths []string, gi IgnoreParser, opts TreeOptions, options ...Option) (string, error) {
+	logger := newOptions(options).logger
+
 	// Option 1: Using var without initialization
 	var treeBuilder strings.Builder
 
@@ -28,22 +35,24 @@ func GenerateFullTree(paths []string, gi IgnoreParser, logger *zap.Logger) (stri
 			continue
 		}
 
+		statStart := time.Now()
 		info, err := os.Stat(absPath)
+		opts.ioProfile.observe(ioStat, statStart)
 		if err != nil {
 			logger.Warn("Cannot stat path for tree generation", zap.String("path", absPath), zap.Error(err))
 			continue
 		}
 
 		if info.IsDir() {
-			// Add the directory root
-			treeBuilder.WriteString(fmt.Sprintf("%s/\n", absPath))
-
 			// Generate subtree
-			subtree, err := generateTreeRecursively(absPath, absPath, gi, "", logger)
+			subtree, count, err := generateTreeRecursively(absPath, absPath, opts.scopedParser(gi, absPath, logger), "", opts.withRoot(absPath), logger)
 			if err != nil {
 				logger.Warn("Failed to generate subtree", zap.String("directory", absPath), zap.Error(err))
 				continue
 			}
+
+			// Add the directory root
+			treeBuilder.WriteString(fmt.Sprintf("%s/%s\n", opts.rootLabel(absPath), opts.countAnnotation(count)))
 			if subtree != "" {
 				treeBuilder.WriteString(subtree)
 				treeBuilder.WriteString("\n")
@@ -51,10 +60,10 @@ func GenerateFullTree(paths []string, gi IgnoreParser, logger *zap.Logger) (stri
 		} else {
 			relPath, relErr := filepath.Rel(filepath.Dir(absPath), absPath)
 			if relErr != nil {
-				relPath = absPath // Fallback to absolute path if relative path fails
+				relPath = opts.Sanitizer.Sanitize(absPath) // Fallback to absolute path if relative path fails
 			}
 			relPath = normalizePath(relPath)
-			treeBuilder.WriteString(relPath + "\n")
+			treeBuilder.WriteString(relPath + opts.modTimeAnnotation(func() (fs.FileInfo, error) { return info, nil }) + "\n")
 		}
 	}
 
@@ -62,23 +71,20 @@ func GenerateFullTree(paths []string, gi IgnoreParser, logger *zap.Logger) (stri
 }
 
 // generateTreeRecursively builds the tree structure recursively.
-// It returns the subtree as a string and any error encountered.
-func generateTreeRecursively(directory, parentDir string, gi IgnoreParser, prefix string, logger *zap.Logger) (string, error) {
+// It returns the subtree as a string, the number of opts.IncludedFiles within it, and any error encountered.
+func generateTreeRecursively(directory, parentDir string, gi IgnoreParser, prefix string, opts TreeOptions, logger *zap.Logger) (string, int, error) {
 	var output []string
+	var count int
 
+	readDirStart := time.Now()
 	entries, err := os.ReadDir(directory)
+	opts.ioProfile.observe(ioReadDir, readDirStart)
 	if err != nil {
 		logger.Warn("Failed to read directory for tree structure", zap.String("directory", directory), zap.Error(err))
-		return "", fmt.Errorf("failed to read directory '%s': %w", directory, err)
+		return "", 0, fmt.Errorf("failed to read directory '%s': %w", directory, err)
 	}
 
-	// Sort entries: directories first, then files, alphabetically
-	sort.Slice(entries, func(i, j int) bool {
-		if entries[i].IsDir() != entries[j].IsDir() {
-			return entries[i].IsDir()
-		}
-		return strings.ToLower(entries[i].Name()) < strings.ToLower(entries[j].Name())
-	})
+	sortTreeEntries(entries, opts.DirsLast)
 
 	for i, entry := range entries {
 		connector := "├── "
@@ -92,30 +98,406 @@ func generateTreeRecursively(directory, parentDir string, gi IgnoreParser, prefi
 		relPath, _ := filepath.Rel(parentDir, entryPath)
 		relPath = normalizePath(relPath)
 
-		if entry.IsDir() {
+		if entry.Type()&os.ModeSymlink != 0 {
+			if gi.MatchesPath(relPath) {
+				if opts.ShowIgnored {
+					output = append(output, prefix+connector+opts.ignoredName(entry.Name()))
+				}
+				continue
+			}
+			target, isDir := symlinkTarget(entryPath)
+			name := entry.Name()
+			if isDir {
+				name += "/"
+			}
+			line := fmt.Sprintf("%s%s%s -> %s", prefix, connector, name, opts.Sanitizer.Sanitize(target))
+			if !isDir {
+				output = append(output, line)
+				if _, ok := opts.IncludedFiles[entryPath]; ok {
+					count++
+				}
+				continue
+			}
+			var subtree string
+			var subCount int
+			if !atMaxDepth(relPath, opts.MaxDepth) && opts.followSymlinkDir(entryPath) {
+				subtree, subCount, err = generateTreeRecursively(entryPath, parentDir, gi, prefix+extension, opts, logger)
+				if err != nil {
+					logger.Warn("Failed to generate subtree", zap.String("directory", entryPath), zap.Error(err))
+					subtree, subCount = "", 0
+				}
+				count += subCount
+				line += opts.countAnnotation(subCount)
+			}
+			output = append(output, line)
+			if subtree != "" {
+				output = append(output, subtree)
+			}
+		} else if entry.IsDir() {
 			if gi.MatchesPath(relPath) {
 				logger.Debug("Skipping ignored directory in tree", zap.String("directory", entryPath))
+				if opts.ShowIgnored {
+					output = append(output, prefix+connector+opts.ignoredName(entry.Name()+"/"))
+				}
 				continue // Skip ignored directories
 			}
+			// Generate subtree with updated prefix, unless it lies beyond the depth limit
+			var subtree string
+			var subCount int
+			if !atMaxDepth(relPath, opts.MaxDepth) {
+				subtree, subCount, err = generateTreeRecursively(entryPath, parentDir, gi, prefix+extension, opts, logger)
+				if err != nil {
+					logger.Warn("Failed to generate subtree", zap.String("directory", entryPath), zap.Error(err))
+					subtree, subCount = "", 0
+				}
+			}
+			count += subCount
 			// Append '/' to directory names
-			line := fmt.Sprintf("%s%s%s/", prefix, connector, entry.Name())
+			line := fmt.Sprintf("%s%s%s/%s", prefix, connector, entry.Name(), opts.countAnnotation(subCount))
 			output = append(output, line)
-			// Generate subtree with updated prefix
-			subtree, err := generateTreeRecursively(entryPath, parentDir, gi, prefix+extension, logger)
-			if err != nil {
-				logger.Warn("Failed to generate subtree", zap.String("directory", entryPath), zap.Error(err))
-				continue
-			}
 			if subtree != "" {
 				output = append(output, subtree)
 			}
 		} else {
 			if !gi.MatchesPath(relPath) {
-				line := fmt.Sprintf("%s%s%s", prefix, connector, entry.Name())
+				line := fmt.Sprintf("%s%s%s%s", prefix, connector, entry.Name(), opts.modTimeAnnotation(entry.Info))
 				output = append(output, line)
+				if _, ok := opts.IncludedFiles[entryPath]; ok {
+					count++
+				}
+			} else if opts.ShowIgnored {
+				output = append(output, prefix+connector+opts.ignoredName(entry.Name()))
+			}
+		}
+	}
+
+	return strings.Join(output, "\n"), count, nil
+}
+
+// TreeOptions holds the options that control how the tree structure is rendered.
+type TreeOptions struct {
+	DirsLast      bool                // If true, files are listed before directories instead of after them.
+	IncludedFiles map[string]struct{} // If non-nil, directories are annotated with the number of these absolute paths beneath them.
+
+	RespectGitignore bool // If true, `.gitignore` files are applied to the directories containing them.
+	FollowSymlinks   bool // If true, symlinks to directories are expanded, each real directory at most once.
+
+	ShowIgnored   bool   // If true, ignored entries are listed, without their contents, and marked with IgnoredMarker.
+	IgnoredMarker string // Marker for ignored entries; "%s" stands for the name, otherwise the marker precedes it. Defaults to DefaultTreeIgnoredMarker.
+
+	MaxDepth int // If positive, directories at this depth below a root are listed without their contents.
+
+	ModTimeFormat string // If non-empty, each file in the text tree is followed by its modification time in this time.Format layout, e.g. "[2024-01-15]".
+
+	Sanitizer    *PathSanitizer // If non-nil, absolute root paths and symlink targets are sanitized.
+	RelativeRoot string         // If non-empty, root paths are shown relative to this directory, e.g. "./project", instead of absolute.
+
+	seenDirs  map[string]struct{} // Real paths of the directories entered below the current root.
+	ioProfile *ioProfile          // If non-nil, the latency of filesystem operations is recorded.
+}
+
+// rootLabel returns the label of the root directory at absPath: its path relative to
+// o.RelativeRoot if set, such as "./project" or "../project", and otherwise the absolute path,
+// sanitized if enabled.
+func (o TreeOptions) rootLabel(absPath string) string {
+	if o.RelativeRoot != "" {
+		base, err := filepath.Abs(o.RelativeRoot)
+		if err == nil {
+			if rel, err := filepath.Rel(base, absPath); err == nil {
+				rel = filepath.ToSlash(rel)
+				if rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+					return rel
+				}
+				return "./" + rel
 			}
 		}
 	}
+	return o.Sanitizer.Sanitize(absPath)
+}
+
+// withRoot returns a copy of o that tracks the directories entered below root.
+func (o TreeOptions) withRoot(root string) TreeOptions {
+	o.seenDirs = make(map[string]struct{})
+	if realPath, err := filepath.EvalSymlinks(root); err == nil {
+		o.seenDirs[realPath] = struct{}{}
+	}
+	return o
+}
+
+// followSymlinkDir reports whether the symlinked directory at path is expanded, which is the case
+// when following symlinks and its real directory has not been entered yet.
+func (o TreeOptions) followSymlinkDir(path string) bool {
+	if !o.FollowSymlinks || o.seenDirs == nil {
+		return false
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	if _, seen := o.seenDirs[realPath]; seen {
+		return false
+	}
+	o.seenDirs[realPath] = struct{}{}
+	return true
+}
+
+// scopedParser wraps gi in a ScopedIgnoreParser for the tree rooted at root.
+func (o TreeOptions) scopedParser(gi IgnoreParser, root string, logger *zap.Logger) *ScopedIgnoreParser {
+	scoped := NewScopedIgnoreParser(gi, root, logger)
+	if o.RespectGitignore {
+		scoped.EnableGitignore()
+	}
+	return scoped
+}
+
+// DefaultTreeIgnoredMarker is the marker placed before ignored entries shown in the tree.
+const DefaultTreeIgnoredMarker = "[ignored]"
+
+// ignoredName returns name marked as ignored, either substituted for "%s" in the marker, as in
+// "~~%s~~", or preceded by the marker.
+func (o TreeOptions) ignoredName(name string) string {
+	marker := o.IgnoredMarker
+	if marker == "" {
+		marker = DefaultTreeIgnoredMarker
+	}
+	if strings.Contains(marker, "%s") {
+		return strings.ReplaceAll(marker, "%s", name)
+	}
+	return marker + " " + name
+}
+
+// countAnnotation returns the file count suffix for a directory line, or "" when counts are disabled.
+func (o TreeOptions) countAnnotation(count int) string {
+	if o.IncludedFiles == nil {
+		return ""
+	}
+	if count == 1 {
+		return " (1 file)"
+	}
+	return fmt.Sprintf(" (%d files)", count)
+}
+
+// DefaultTreeAnnotationFormat is the layout of the modification times shown with --tree-annotations.
+const DefaultTreeAnnotationFormat = "2006-01-02"
+
+// modTimeAnnotation returns the modification time suffix for a file line, or "" when annotations
+// are disabled or the time is unavailable.
+func (o TreeOptions) modTimeAnnotation(info func() (fs.FileInfo, error)) string {
+	if o.ModTimeFormat == "" {
+		return ""
+	}
+	fileInfo, err := info()
+	if err != nil {
+		return ""
+	}
+	return " [" + fileInfo.ModTime().Format(o.ModTimeFormat) + "]"
+}
+
+// sortTreeEntries sorts directory entries for tree output: directories first, then files,
+// alphabetically within each group. When dirsLast is true, files are listed first instead.
+func sortTreeEntries(entries []os.DirEntry, dirsLast bool) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir() != entries[j].IsDir() {
+			return entries[i].IsDir() != dirsLast
+		}
+		return strings.ToLower(entries[i].Name()) < strings.ToLower(entries[j].Name())
+	})
+}
 
-	return strings.Join(output, "\n"), nil
+// TreeNode is a structured representation of a file or directory in the tree.
+type TreeNode struct {
+	XMLName  xml.Name    `json:"-"`                                            // Element name in XML output ("directory" or "file").
+	Name     string      `json:"name" xml:"name,attr"`                         // Entry name; the absolute path for root directories.
+	Type     string      `json:"type" xml:"-"`                                 // Either "directory" or "file".
+	Target   string      `json:"target,omitempty" xml:"target,attr,omitempty"` // Link target if the entry is a symlink.
+	Children []*TreeNode `json:"children,omitempty" xml:"node"`
+}
+
+// Node types used in structured tree output.
+const (
+	TreeNodeDirectory = "directory"
+	TreeNodeFile      = "file"
+)
+
+// newTreeNode creates a tree node of the given type.
+func newTreeNode(name, nodeType string) *TreeNode {
+	return &TreeNode{XMLName: xml.Name{Local: nodeType}, Name: name, Type: nodeType}
+}
+
+// GenerateTreeNodes builds the structured tree for all input paths, applying the same
+// ignore rules and ordering as GenerateFullTree.
+func GenerateTreeNodes(paths []string, gi IgnoreParser, opts TreeOptions, logger *zap.Logger) ([]*TreeNode, error) {
+	var roots []*TreeNode
+	for _, path := range paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			logger.Warn("Failed to get absolute path for tree generation", zap.String("path", path), zap.Error(err))
+			continue
+		}
+
+		statStart := time.Now()
+		info, err := os.Stat(absPath)
+		opts.ioProfile.observe(ioStat, statStart)
+		if err != nil {
+			logger.Warn("Cannot stat path for tree generation", zap.String("path", absPath), zap.Error(err))
+			continue
+		}
+
+		if !info.IsDir() {
+			roots = append(roots, newTreeNode(filepath.Base(absPath), TreeNodeFile))
+			continue
+		}
+
+		root := newTreeNode(normalizePath(opts.rootLabel(absPath)), TreeNodeDirectory)
+		if err := buildTreeNode(root, absPath, absPath, opts.scopedParser(gi, absPath, logger), opts.withRoot(absPath), logger); err != nil {
+			logger.Warn("Failed to generate subtree", zap.String("directory", absPath), zap.Error(err))
+		}
+		roots = append(roots, root)
+	}
+	return roots, nil
+}
+
+// buildTreeNode populates node with the non-ignored entries of directory.
+func buildTreeNode(node *TreeNode, directory, parentDir string, gi IgnoreParser, opts TreeOptions, logger *zap.Logger) error {
+	readDirStart := time.Now()
+	entries, err := os.ReadDir(directory)
+	opts.ioProfile.observe(ioReadDir, readDirStart)
+	if err != nil {
+		logger.Warn("Failed to read directory for tree structure", zap.String("directory", directory), zap.Error(err))
+		return fmt.Errorf("failed to read directory '%s': %w", directory, err)
+	}
+
+	sortTreeEntries(entries, opts.DirsLast)
+
+	for _, entry := range entries {
+		entryPath := filepath.Join(directory, entry.Name())
+		relPath, _ := filepath.Rel(parentDir, entryPath)
+		relPath = normalizePath(relPath)
+
+		if gi.MatchesPath(relPath) {
+			continue
+		}
+
+		if entry.Type()&os.ModeSymlink != 0 {
+			target, isDir := symlinkTarget(entryPath)
+			nodeType := TreeNodeFile
+			if isDir {
+				nodeType = TreeNodeDirectory
+			}
+			child := newTreeNode(entry.Name(), nodeType)
+			child.Target = opts.Sanitizer.Sanitize(target)
+			if isDir && !atMaxDepth(relPath, opts.MaxDepth) && opts.followSymlinkDir(entryPath) {
+				if err := buildTreeNode(child, entryPath, parentDir, gi, opts, logger); err != nil {
+					logger.Warn("Failed to generate subtree", zap.String("directory", entryPath), zap.Error(err))
+				}
+			}
+			node.Children = append(node.Children, child)
+		} else if entry.IsDir() {
+			child := newTreeNode(entry.Name(), TreeNodeDirectory)
+			if !atMaxDepth(relPath, opts.MaxDepth) {
+				if err := buildTreeNode(child, entryPath, parentDir, gi, opts, logger); err != nil {
+					logger.Warn("Failed to generate subtree", zap.String("directory", entryPath), zap.Error(err))
+				}
+			}
+			node.Children = append(node.Children, child)
+		} else {
+			node.Children = append(node.Children, newTreeNode(entry.Name(), TreeNodeFile))
+		}
+	}
+	return nil
+}
+
+// GenerateTreeJSON generates the tree structure for all input paths as a JSON array of nodes.
+func GenerateTreeJSON(paths []string, gi IgnoreParser, opts TreeOptions, logger *zap.Logger) (string, error) {
+	roots, err := GenerateTreeNodes(paths, gi, opts, logger)
+	if err != nil {
+		return "", err
+	}
+	if roots == nil {
+		roots = []*TreeNode{}
+	}
+
+	data, err := json.MarshalIndent(roots, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode tree as JSON: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// GenerateTreeXML generates the tree structure for all input paths as an XML document.
+func GenerateTreeXML(paths []string, gi IgnoreParser, opts TreeOptions, logger *zap.Logger) (string, error) {
+	roots, err := GenerateTreeNodes(paths, gi, opts, logger)
+	if err != nil {
+		return "", err
+	}
+
+	doc := struct {
+		XMLName xml.Name    `xml:"tree"`
+		Nodes   []*TreeNode `xml:"node"`
+	}{Nodes: roots}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode tree as XML: %w", err)
+	}
+	return xml.Header + string(data) + "\n", nil
+}
+
+// GeneratePathTree renders a text tree of the given slash-separated relative file paths,
+// using the same layout and ordering as GenerateFullTree.
+func GeneratePathTree(paths []string, opts TreeOptions) string {
+	root := newTreeNode("", TreeNodeDirectory)
+	for _, path := range paths {
+		node := root
+		components := strings.Split(strings.Trim(filepath.ToSlash(path), "/"), "/")
+		for i, component := range components {
+			nodeType := TreeNodeDirectory
+			if i == len(components)-1 {
+				nodeType = TreeNodeFile
+			}
+			var child *TreeNode
+			for _, existing := range node.Children {
+				if existing.Name == component && existing.Type == nodeType {
+					child = existing
+					break
+				}
+			}
+			if child == nil {
+				child = newTreeNode(component, nodeType)
+				node.Children = append(node.Children, child)
+			}
+			node = child
+		}
+	}
+
+	var sb strings.Builder
+	writeTreeNodeText(&sb, root, "", opts)
+	return sb.String()
+}
+
+// writeTreeNodeText writes the children of node as indented text tree lines.
+func writeTreeNodeText(sb *strings.Builder, node *TreeNode, prefix string, opts TreeOptions) {
+	sort.Slice(node.Children, func(i, j int) bool {
+		a, b := node.Children[i], node.Children[j]
+		if a.Type != b.Type {
+			return (a.Type == TreeNodeDirectory) != opts.DirsLast
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+
+	for i, child := range node.Children {
+		connector := "├── "
+		extension := "│   "
+		if i == len(node.Children)-1 {
+			connector = "└── "
+			extension = "    "
+		}
+
+		if child.Type == TreeNodeDirectory {
+			sb.WriteString(prefix + connector + child.Name + "/\n")
+			writeTreeNodeText(sb, child, prefix+extension, opts)
+		} else {
+			sb.WriteString(prefix + connector + child.Name + "\n")
+		}
+	}
 }
@@ -0,0 +1,227 @@
+// File: pkg/combine/validate.go
+package combine
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// xmlTagPattern matches the tag names accepted for WrapInXMLTags.
+var xmlTagPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// Validate reports the first invalid or conflicting setting of a, naming the combine command
+// flag it corresponds to. Zero values are valid and select the documented defaults, so that
+// arguments built from a configuration file or API request are checked the same way as those
+// parsed from the command line. Enumerated values are expected in lower case.
+func (a Arguments) Validate() error {
+	switch {
+	case a.MaxMemoryMB < 0:
+		return fmt.Errorf("invalid 'max-memory-mb' flag: %d is negative", a.MaxMemoryMB)
+	case a.MaxWorkersPerDir < 0:
+		return fmt.Errorf("invalid 'max-workers-per-dir' flag: %d is negative", a.MaxWorkersPerDir)
+	case a.MaxTokens < 0:
+		return fmt.Errorf("invalid 'max-tokens' flag: %d must not be negative", a.MaxTokens)
+	case a.ReadRetries < 0:
+		return fmt.Errorf("invalid 'read-retries' flag: must not be negative")
+	case a.ExcludeOlderThan < 0:
+		return fmt.Errorf("invalid 'exclude-older-than' flag: duration must be positive")
+	case a.SectionPaddingBefore != nil && *a.SectionPaddingBefore < 0:
+		return fmt.Errorf("invalid 'section-padding-before' flag: %d must not be negative", *a.SectionPaddingBefore)
+	case a.SectionPaddingAfter != nil && *a.SectionPaddingAfter < 0:
+		return fmt.Errorf("invalid 'section-padding-after' flag: %d must not be negative", *a.SectionPaddingAfter)
+	case a.BinaryThreshold < 0 || a.BinaryThreshold > 1:
+		return fmt.Errorf("invalid 'binary-threshold' flag: %g must be greater than 0 and at most 1", a.BinaryThreshold)
+	case a.BinarySampleBytes < 0:
+		return fmt.Errorf("invalid 'binary-sample-bytes' flag: %d must be positive", a.BinarySampleBytes)
+	case a.ProgressInterval < 0:
+		return fmt.Errorf("invalid 'progress-interval' flag: %d must be positive", a.ProgressInterval)
+	case a.MaxDepth < 0:
+		return fmt.Errorf("invalid 'max-depth' flag: %d must not be negative", a.MaxDepth)
+	case a.WarnOnLargeCombinedMB < 0:
+		return fmt.Errorf("invalid 'warn-on-large-combined' flag: %d is negative", a.WarnOnLargeCombinedMB)
+	case a.MinUniqueLines < 0 || a.MinUniqueLines > 100:
+		return fmt.Errorf("invalid 'min-unique-lines' flag: %d is not a percentage between 0 and 100", a.MinUniqueLines)
+	case a.Preview < 0:
+		return fmt.Errorf("invalid 'preview' flag: %d must not be negative", a.Preview)
+	case a.IncludeGitLog < 0:
+		return fmt.Errorf("invalid 'include-git-log' flag: %d must not be negative", a.IncludeGitLog)
+	case a.RequireMinFiles > 0 && a.RequireMaxFiles > 0 && a.RequireMinFiles > a.RequireMaxFiles:
+		return fmt.Errorf("invalid 'require-min-files' flag: %d exceeds --require-max-files %d", a.RequireMinFiles, a.RequireMaxFiles)
+	case a.ReadChunkSize < 0:
+		return fmt.Errorf("invalid 'read-chunk-size' flag: %d must be positive", a.ReadChunkSize)
+	case a.SplitBytes < 0:
+		return fmt.Errorf("invalid 'split-bytes' flag: %d must not be negative", a.SplitBytes)
+	}
+
+	if err := a.validateEnums(); err != nil {
+		return err
+	}
+	if err := a.validatePatterns(); err != nil {
+		return err
+	}
+	return a.validateCombinations()
+}
+
+// validateEnums checks the settings taking one of a fixed set of values.
+func (a Arguments) validateEnums() error {
+	switch a.IgnoreSyntax {
+	case "", SyntaxGitignore, SyntaxGlob, SyntaxRegex:
+	default:
+		return fmt.Errorf("invalid 'ignore-syntax' flag: unsupported syntax %q", a.IgnoreSyntax)
+	}
+	switch a.OutputFormat {
+	case "", FormatText, FormatJSON, FormatYAML, FormatZip:
+	default:
+		return fmt.Errorf("invalid 'format' flag: unsupported format %q", a.OutputFormat)
+	}
+	switch a.TreeFormat {
+	case "", TreeFormatText, TreeFormatJSON, TreeFormatXML:
+	default:
+		return fmt.Errorf("invalid 'tree-format' flag: unsupported format %q", a.TreeFormat)
+	}
+	switch a.OutputMode {
+	case "", OutputModeOverwrite, OutputModeAppend, OutputModeFailIfExists:
+	default:
+		return fmt.Errorf("invalid 'output-mode' flag: unsupported mode %q", a.OutputMode)
+	}
+	switch a.OnError {
+	case "", OnErrorSkip, OnErrorFail:
+	default:
+		return fmt.Errorf("invalid 'on-error' flag: unsupported mode %q", a.OnError)
+	}
+	switch a.RequireEncoding {
+	case "", EncodingUTF8:
+	default:
+		return fmt.Errorf("invalid 'require-encoding' flag: unsupported encoding %q", a.RequireEncoding)
+	}
+	if a.HashAlgorithm != "" && !IsSupportedHashAlgorithm(a.HashAlgorithm) {
+		return fmt.Errorf("invalid 'hash-algorithm' flag: unsupported algorithm %q", a.HashAlgorithm)
+	}
+	switch a.PathNormalization {
+	case "", PathNormalizationSlash, PathNormalizationOS, PathNormalizationNone:
+	default:
+		return fmt.Errorf("invalid 'path-normalization' flag: unsupported mode %q", a.PathNormalization)
+	}
+	switch a.Sort {
+	case "", SortPath, SortDepth:
+	default:
+		return fmt.Errorf("invalid 'sort' flag: unsupported order %q", a.Sort)
+	}
+	switch a.EncodingDeclaration {
+	case "", EncodingDeclarationNone, EncodingDeclarationPython, EncodingDeclarationXML:
+	default:
+		return fmt.Errorf("invalid 'encoding-declaration-style' flag: unsupported style %q", a.EncodingDeclaration)
+	}
+	switch a.Prioritize {
+	case "", PrioritizeStdin:
+	default:
+		return fmt.Errorf("invalid 'prioritize' flag: unsupported value %q", a.Prioritize)
+	}
+	return nil
+}
+
+// validatePatterns checks the regular expressions, templates, and names given as strings.
+func (a Arguments) validatePatterns() error {
+	if _, err := regexp.Compile(a.FilterByRegex); err != nil {
+		return fmt.Errorf("invalid 'filter-by-regex' flag: %w", err)
+	}
+	if _, err := regexp.Compile(a.GrepPattern); err != nil {
+		return fmt.Errorf("invalid 'grep' flag: %w", err)
+	}
+	if a.HeaderTemplate != "" {
+		if _, err := ParseHeaderTemplate(a.HeaderTemplate); err != nil {
+			return fmt.Errorf("invalid 'header-template' flag: %w", err)
+		}
+	}
+	if _, err := ParseContentReplacements(a.ContentReplacements); err != nil {
+		return fmt.Errorf("invalid 'content-regex-replace' flag: %w", err)
+	}
+	if a.VirtualRoot != "" {
+		root := filepath.Clean(a.VirtualRoot)
+		if filepath.IsAbs(root) || root == "." || root == ".." || strings.HasPrefix(root, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid 'virtual-root' flag: %q must be a relative name", a.VirtualRoot)
+		}
+	}
+	for ext, prefix := range a.CommentPrefixes {
+		if strings.TrimSpace(prefix) == "" {
+			return fmt.Errorf("invalid 'file-comment-format' flag: empty comment prefix for %q", ext)
+		}
+	}
+	if a.WrapInXMLTags != "" && !xmlTagPattern.MatchString(a.WrapInXMLTags) {
+		return fmt.Errorf("invalid 'wrap-in-xml-tags' flag: %q is not a valid XML tag name", a.WrapInXMLTags)
+	}
+	return nil
+}
+
+// validateCombinations checks for settings that cannot be used together.
+func (a Arguments) validateCombinations() error {
+	format := a.OutputFormat
+	if format == "" {
+		format = FormatText
+	}
+	appendMode := a.OutputMode == OutputModeAppend
+
+	if a.FollowDirSymlinksOnly && a.FollowSymlinks {
+		return fmt.Errorf("invalid 'follow-symlinks-to-dirs-only' flag: cannot be combined with --follow-symlinks")
+	}
+	if appendMode && a.WriteIfChanged {
+		return fmt.Errorf("invalid 'output-mode' flag: %q cannot be combined with --write-if-changed", a.OutputMode)
+	}
+	if a.SplitOnPattern != "" && a.OutputPerExtension {
+		return fmt.Errorf("invalid 'split-on-pattern' flag: cannot be combined with --output-per-extension")
+	}
+	if a.SplitBytes > 0 && (a.SplitOnPattern != "" || a.OutputPerExtension) {
+		return fmt.Errorf("invalid 'split-bytes' flag: cannot be combined with --split-on-pattern or --output-per-extension")
+	}
+
+	// A ZIP archive is a single self-contained file that cannot be appended to or split
+	if format == FormatZip && (appendMode || a.SplitOnPattern != "" || a.SplitBytes > 0 || a.OutputPerExtension) {
+		return fmt.Errorf("invalid 'format' flag: %q cannot be combined with append mode, --split-on-pattern, --split-bytes, or --output-per-extension", format)
+	}
+
+	// Streamed lines are written as files finish processing, so nothing can be sorted, limited, or split afterwards
+	if a.OutputJSONStream && (format != FormatText || appendMode || a.WriteIfChanged || a.SplitOnPattern != "" || a.SplitBytes > 0 || a.OutputPerExtension || a.CombineIntoArchive != "" || a.Preview > 0 || a.DryRun || a.MaxTokens > 0 || a.Deduplicate || a.ContentHashDedup || a.CombineChangelog || a.DiffOutput != "") {
+		return fmt.Errorf("invalid 'output-json-stream' flag: cannot be combined with --format, append mode, --write-if-changed, --split-on-pattern, --split-bytes, --output-per-extension, --combine-into-archive, --preview, --dry-run, --max-tokens, --deduplicate, --content-hash-dedup, --combine-changelog, or --diff-output")
+	}
+
+	textOnly := format != FormatText || a.OutputJSONStream || a.OutputTemplateDir != ""
+	if a.WrapInXMLTags != "" && textOnly {
+		return fmt.Errorf("invalid 'wrap-in-xml-tags' flag: requires --format text and cannot be combined with --output-json-stream or --output-template-dir")
+	}
+	if a.EncodingDeclaration != "" && a.EncodingDeclaration != EncodingDeclarationNone && textOnly {
+		return fmt.Errorf("invalid 'encoding-declaration-style' flag: requires --format text and cannot be combined with --output-json-stream or --output-template-dir")
+	}
+
+	if len(a.WatchAfter) > 0 && !a.Watch {
+		return fmt.Errorf("invalid 'watch-after' flag: requires --watch")
+	}
+	if !a.watchesOnStart() && !a.Watch {
+		return fmt.Errorf("invalid 'watch-on-start' flag: requires --watch")
+	}
+	if a.Watch && (a.Preview > 0 || a.DryRun) {
+		return fmt.Errorf("invalid 'watch' flag: cannot be combined with --preview or --dry-run")
+	}
+
+	// Writing to stdout leaves no file to compare against, append to, or fill with per-extension files
+	if a.Output == StdoutPath {
+		switch {
+		case a.OutputPerExtension:
+			return fmt.Errorf("invalid 'output' flag: stdout cannot be used with --output-per-extension")
+		case a.SplitOnPattern != "":
+			return fmt.Errorf("invalid 'output' flag: stdout cannot be used with --split-on-pattern")
+		case a.SplitBytes > 0:
+			return fmt.Errorf("invalid 'output' flag: stdout cannot be used with --split-bytes")
+		case a.WriteIfChanged:
+			return fmt.Errorf("invalid 'output' flag: stdout cannot be used with --write-if-changed")
+		case a.OutputMode != "" && a.OutputMode != OutputModeOverwrite:
+			return fmt.Errorf("invalid 'output' flag: stdout cannot be used with --output-mode %s", a.OutputMode)
+		}
+	}
+
+	if a.StdinExtension != "" && a.PathsFile == StdinPath {
+		return fmt.Errorf("invalid 'stdin-extension' flag: standard input cannot be read as a file and as --paths-file at once")
+	}
+	return nil
+}
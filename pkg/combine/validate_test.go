@@ -0,0 +1,83 @@
+// File: pkg/combine/validate_test.go
+package combine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	negative := -1
+	tests := []struct {
+		name    string
+		args    Arguments
+		wantErr string // Substring of the error; empty if the arguments are valid
+	}{
+		{name: "zero value", args: Arguments{}},
+		{name: "defaults spelled out", args: Arguments{OutputFormat: FormatText, OutputMode: OutputModeOverwrite, Sort: SortPath, HashAlgorithm: HashSHA256}},
+		{name: "unsupported format", args: Arguments{OutputFormat: "bogus"}, wantErr: "'format' flag"},
+		{name: "upper case format", args: Arguments{OutputFormat: "JSON"}, wantErr: "'format' flag"},
+		{name: "unsupported sort", args: Arguments{Sort: "random"}, wantErr: "'sort' flag"},
+		{name: "unsupported output mode", args: Arguments{OutputMode: "clobber"}, wantErr: "'output-mode' flag"},
+		{name: "unsupported hash", args: Arguments{HashAlgorithm: "crc32"}, wantErr: "'hash-algorithm' flag"},
+		{name: "negative workers per dir", args: Arguments{MaxWorkersPerDir: -3}, wantErr: "'max-workers-per-dir' flag"},
+		{name: "binary threshold above 1", args: Arguments{BinaryThreshold: 7}, wantErr: "'binary-threshold' flag"},
+		{name: "negative padding", args: Arguments{SectionPaddingAfter: &negative}, wantErr: "'section-padding-after' flag"},
+		{name: "negative large output warning", args: Arguments{WarnOnLargeCombinedMB: -1}, wantErr: "'warn-on-large-combined' flag"},
+		{name: "min unique lines above 100", args: Arguments{MinUniqueLines: 101}, wantErr: "'min-unique-lines' flag"},
+		{name: "min files above max", args: Arguments{RequireMinFiles: 5, RequireMaxFiles: 2}, wantErr: "'require-min-files' flag"},
+		{name: "invalid grep", args: Arguments{GrepPattern: "("}, wantErr: "'grep' flag"},
+		{name: "invalid replacement", args: Arguments{ContentReplacements: []string{"no separator"}}, wantErr: "'content-regex-replace' flag"},
+		{name: "absolute virtual root", args: Arguments{VirtualRoot: "/abs"}, wantErr: "'virtual-root' flag"},
+		{name: "escaping virtual root", args: Arguments{VirtualRoot: "../up"}, wantErr: "'virtual-root' flag"},
+		{name: "blank comment prefix", args: Arguments{CommentPrefixes: map[string]string{"go": " "}}, wantErr: "'file-comment-format' flag"},
+		{name: "invalid xml tag", args: Arguments{WrapInXMLTags: "1bad"}, wantErr: "'wrap-in-xml-tags' flag"},
+		{name: "xml tags with json", args: Arguments{WrapInXMLTags: "docs", OutputFormat: FormatJSON}, wantErr: "'wrap-in-xml-tags' flag"},
+		{name: "encoding declaration with yaml", args: Arguments{EncodingDeclaration: EncodingDeclarationXML, OutputFormat: FormatYAML}, wantErr: "'encoding-declaration-style' flag"},
+		{name: "append with write if changed", args: Arguments{OutputMode: OutputModeAppend, WriteIfChanged: true}, wantErr: "'output-mode' flag"},
+		{name: "zip split", args: Arguments{OutputFormat: FormatZip, SplitBytes: 10}, wantErr: "'format' flag"},
+		{name: "json stream with dedup", args: Arguments{OutputJSONStream: true, Deduplicate: true}, wantErr: "'output-json-stream' flag"},
+		{name: "watch after without watch", args: Arguments{WatchAfter: []string{"true"}}, wantErr: "'watch-after' flag"},
+		{name: "watch on start without watch", args: Arguments{WatchOnStart: new(bool)}, wantErr: "'watch-on-start' flag"},
+		{name: "watch with dry run", args: Arguments{Watch: true, DryRun: true}, wantErr: "'watch' flag"},
+		{name: "stdout with append", args: Arguments{Output: StdoutPath, OutputMode: OutputModeAppend}, wantErr: "'output' flag"},
+		{name: "stdin twice", args: Arguments{StdinExtension: ".go", PathsFile: StdinPath}, wantErr: "'stdin-extension' flag"},
+		{name: "unsupported prioritize", args: Arguments{Prioritize: "first"}, wantErr: "'prioritize' flag"},
+		{name: "both symlink modes", args: Arguments{FollowSymlinks: true, FollowDirSymlinksOnly: true}, wantErr: "'follow-symlinks-to-dirs-only' flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.args.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Validate() = %v, want nil", err)
+			case tt.wantErr != "" && err == nil:
+				t.Fatalf("Validate() = nil, want error containing %q", tt.wantErr)
+			case err != nil && !strings.Contains(err.Error(), tt.wantErr):
+				t.Fatalf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateMergedConfig checks that values from a configuration file are validated like flags.
+func TestValidateMergedConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agentexec.yaml")
+	if err := os.WriteFile(path, []byte("outputFormat: bogus\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fileArgs, err := LoadConfigFile(path)
+	if err != nil {
+		t.Fatalf("LoadConfigFile() = %v", err)
+	}
+
+	base := Arguments{OutputFormat: FormatText, MaxFileSizeKB: 1024}
+	if err := base.Validate(); err != nil {
+		t.Fatalf("Validate() of the flags = %v, want nil", err)
+	}
+	if err := MergeConfig(base, fileArgs).Validate(); err == nil {
+		t.Fatal("Validate() of the merged arguments = nil, want an error for the unsupported format")
+	}
+}
@@ -0,0 +1,212 @@
+// File: pkg/combine/watch.go
+package combine
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// watchDebounce is how long the watcher waits after the last filesystem event before re-running.
+const watchDebounce = 500 * time.Millisecond
+
+// WatchWithContext runs the combine process once, unless args.WatchOnStart is false, and then
+// re-runs it whenever files under args.Paths change, until ctx is cancelled. Re-runs never prompt
+// about binary files. After every successful run, the args.WatchAfter commands are run.
+func WatchWithContext(ctx context.Context, args Arguments, logger *zap.Logger) error {
+	if args.watchesOnStart() {
+		result, err := executeProcess(ctx, args, logger)
+		if err != nil {
+			return err
+		}
+		runWatchCommands(ctx, args.WatchAfter, watchOutputPath(args, result), logger)
+	}
+
+	gi, err := loadIgnorePatterns(args, logger)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logger.Error("Failed to create file watcher", zap.Error(err))
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer func() {
+		if err := watcher.Close(); err != nil {
+			logger.Warn("Failed to close file watcher", zap.Error(err))
+		}
+	}()
+
+	roots := make([]string, 0, len(args.Paths))
+	for _, path := range args.Paths {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			logger.Warn("Failed to get absolute path for watching", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		info, err := os.Stat(absPath)
+		if err != nil {
+			logger.Warn("Cannot stat path for watching", zap.String("path", absPath), zap.Error(err))
+			continue
+		}
+		if !info.IsDir() {
+			// Watch the parent so that editors replacing the file do not drop the watch
+			absPath = filepath.Dir(absPath)
+		}
+		roots = append(roots, absPath)
+		addWatchDirs(watcher, absPath, absPath, gi, logger)
+	}
+
+	// Re-runs overwrite the output from the previous run and cannot wait for user input
+	rerunArgs := args
+	rerunArgs.NonInteractive = true
+	if rerunArgs.OutputMode == OutputModeFailIfExists {
+		rerunArgs.OutputMode = OutputModeOverwrite
+	}
+
+	logger.Info("Watching for changes", zap.Strings("paths", roots))
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Stopping watch")
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if isOwnOutput(event.Name, args) {
+				continue
+			}
+			logger.Debug("Detected file change", zap.String("path", event.Name), zap.Stringer("op", event.Op))
+
+			// Directories created under a watched root are watched from now on
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					for _, root := range roots {
+						if isWithinDir(event.Name, root) {
+							addWatchDirs(watcher, root, event.Name, gi, logger)
+							break
+						}
+					}
+				}
+			}
+			debounce = time.After(watchDebounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logger.Warn("File watcher error", zap.Error(err))
+
+		case <-debounce:
+			debounce = nil
+			result, err := executeProcess(ctx, rerunArgs, logger)
+			if err != nil {
+				logger.Error("Combine re-run failed", zap.Error(err))
+				continue
+			}
+			logger.Info("Combine re-run completed",
+				zap.Int("filesIncluded", result.FilesIncluded),
+				zap.Int("filesSkipped", result.FilesSkipped),
+				zap.Duration("duration", result.Duration),
+			)
+			runWatchCommands(ctx, args.WatchAfter, watchOutputPath(args, result), logger)
+		}
+	}
+}
+
+// watchOutputPath returns the output path passed to watch commands for a run.
+func watchOutputPath(args Arguments, result CombineResult) string {
+	if result.OutputPath != "" {
+		return result.OutputPath
+	}
+	return args.Output
+}
+
+// runWatchCommands runs each command through the system shell, one after another, with the
+// combined output path in $AGENTEXEC_OUTPUT. A failing command is logged and does not stop
+// the remaining commands.
+func runWatchCommands(ctx context.Context, commands []string, outputPath string, logger *zap.Logger) {
+	for _, command := range commands {
+		cmd := shellCommand(ctx, command)
+		cmd.Env = append(os.Environ(), "AGENTEXEC_OUTPUT="+outputPath)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		logger.Debug("Running watch command", zap.String("command", command))
+		if err := cmd.Run(); err != nil {
+			logger.Error("Watch command failed", zap.String("command", command), zap.Error(err))
+		}
+	}
+}
+
+// addWatchDirs adds dir and all directories beneath it to watcher, skipping those that gi ignores
+// relative to the watched root.
+func addWatchDirs(watcher *fsnotify.Watcher, root, dir string, gi IgnoreParser, logger *zap.Logger) {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			logger.Warn("Error accessing path while adding watches", zap.String("path", path), zap.Error(err))
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root {
+			relPath, _ := filepath.Rel(root, path)
+			if gi.MatchesDirectory(normalizePath(relPath)) {
+				return filepath.SkipDir
+			}
+		}
+		if err := watcher.Add(path); err != nil {
+			logger.Warn("Failed to watch directory", zap.String("directory", path), zap.Error(err))
+			return nil
+		}
+		logger.Debug("Watching directory", zap.String("directory", path))
+		return nil
+	})
+	if err != nil {
+		logger.Warn("Failed to add directory watches", zap.String("directory", dir), zap.Error(err))
+	}
+}
+
+// isOwnOutput reports whether path is written by the combine run itself: the tree file, the archive,
+// the cache file, the stats file, the progress file, the output file with its shards, chunks, and temporary files, or the per-extension output directory.
+func isOwnOutput(path string, args Arguments) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, own := range []string{args.Tree, args.CombineIntoArchive, args.CacheFile, args.Checkpoint, args.StatsOutput, args.ProgressFile} {
+		if own == "" || own == StdoutPath {
+			continue
+		}
+		if absOwn, err := filepath.Abs(own); err == nil && absOwn == absPath {
+			return true
+		}
+	}
+	if args.Output == "" || args.Output == StdoutPath {
+		return false
+	}
+	absOutput, err := filepath.Abs(args.Output)
+	if err != nil {
+		return false
+	}
+	if args.OutputPerExtension {
+		return isWithinDir(absPath, absOutput)
+	}
+	outputStem := strings.TrimSuffix(filepath.Base(absOutput), filepath.Ext(absOutput))
+	base := filepath.Base(absPath)
+	return filepath.Dir(absPath) == filepath.Dir(absOutput) &&
+		(base == filepath.Base(absOutput) || strings.HasPrefix(base, outputStem+".") || strings.HasPrefix(base, outputStem+"_"))
+}
@@ -2,77 +2,307 @@
 package combine
 
 import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"runtime"
-	"sync"
+	"slices"
+	"sort"
+	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
-// ProcessFilesConcurrently processes files using a worker pool and returns the combined contents.
-func ProcessFilesConcurrently(files []string, maxWorkers int, parentDir string, logger *zap.Logger) ([]FileContent, error) {
-	jobs := make(chan string, len(files))
-	results := make(chan FileContent, len(files))
-	var wg sync.WaitGroup
+// orderedWindowPerWorker is the number of files per worker that may be handed out ahead of the
+// next file to emit when results are ordered, which bounds the completed files held back.
+const orderedWindowPerWorker = 16
+
+// ProcessFilesConcurrently processes files using a worker pool and returns the combined contents,
+// in completion order, along with the number of files that failed to process. Files dropped by
+// opts.Grep are not failures. Source paths are relative to parentDir. It honors the WithLogger,
+// WithMaxWorkers, WithOrderedResults, WithFailFast, WithMaxMemoryMB, WithMaxWorkersPerDir, and
+// WithProgress options.
+func ProcessFilesConcurrently(ctx context.Context, files []string, parentDir string, opts ProcessOptions, options ...Option) ([]FileContent, int, error) {
+	var combinedContents []FileContent
+	failed, err := StreamFilesConcurrently(ctx, files, parentDir, opts, func(content FileContent) error {
+		combinedContents = append(combinedContents, content)
+		return nil
+	}, options...)
+	return combinedContents, failed, err
+}
+
+// StreamFilesConcurrently processes files using a worker pool and passes each processed file to
+// emit as soon as it is ready, in completion order, or in path order with WithOrderedResults.
+// emit is never called concurrently. It returns the number of files that failed to process; if
+// emit fails, or a file fails to process with WithFailFast, the remaining files are abandoned
+// and that error is returned. It honors the WithLogger, WithMaxWorkers, WithOrderedResults,
+// WithFailFast, WithMaxMemoryMB, WithMaxWorkersPerDir, and WithProgress options.
+func StreamFilesConcurrently(ctx context.Context, files []string, parentDir string, opts ProcessOptions, emit func(FileContent) error, options ...Option) (int, error) {
+	o := newOptions(options)
+	logger, maxWorkers := o.logger, o.maxWorkers
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan job, len(files))
+	results := make(chan jobResult, len(files))
+	var failed atomic.Int64
 
 	if maxWorkers <= 0 {
 		maxWorkers = runtime.NumCPU()
 		logger.Debug("Adjusted worker count", zap.Int("workers", maxWorkers))
 	}
 
-	logger.Debug("Initializing worker pool", zap.Int("workers", maxWorkers))
+	// Ordered results are handed out in path order, each taking a slot of the window that is
+	// freed once the file is emitted. The next file to emit has therefore always been handed out,
+	// and a slow file holds back at most the window's worth of completed files.
+	var window chan struct{}
+	if o.ordered {
+		files = slices.Clone(files)
+		sort.Strings(files)
+		window = make(chan struct{}, maxWorkers*orderedWindowPerWorker)
+	}
+
+	// The first worker error, only returned with WithFailFast, cancels the group's context so
+	// that the other workers skip their remaining files and distribution stops
+	group, groupCtx := errgroup.WithContext(ctx)
+	dirs := newDirLimiter(o.maxWorkersPerDir)
+	logger.Debug("Initializing worker pool", zap.Int("workers", maxWorkers), zap.Int("maxWorkersPerDir", o.maxWorkersPerDir))
 	for w := 0; w < maxWorkers; w++ {
-		wg.Add(1)
 		workerLogger := logger.With(zap.Int("workerID", w))
-		go worker(w, jobs, results, parentDir, &wg, workerLogger)
+		group.Go(func() error {
+			return worker(groupCtx, w, jobs, results, parentDir, opts, dirs, o.progress, o.failFast, &failed, workerLogger)
+		})
 	}
 
-	logger.Debug("Distributing files to workers")
-	for _, file := range files {
-		jobs <- file
+	// With a memory limit, files are only handed out while the monitor grants permits
+	var memory *memoryMonitor
+	if o.maxMemoryMB > 0 {
+		monitorCtx, stopMonitor := context.WithCancel(groupCtx)
+		defer stopMonitor()
+		memory = startMemoryMonitor(monitorCtx, o.maxMemoryMB, logger)
 	}
-	close(jobs)
-	logger.Debug("All files distributed to workers")
 
-	// Collect results concurrently
+	group.Go(func() error {
+		defer close(jobs)
+		logger.Debug("Distributing files to workers")
+		for rank, file := range files {
+			if memory != nil && !memory.acquire(groupCtx) {
+				return nil
+			}
+			if window != nil {
+				select {
+				case window <- struct{}{}:
+				case <-groupCtx.Done():
+					return nil
+				}
+			}
+			jobs <- job{rank: rank, file: file}
+		}
+		logger.Debug("All files distributed to workers")
+		return nil
+	})
+
+	// Collect results concurrently; groupErr is set before results is closed
+	var groupErr error
 	go func() {
-		wg.Wait()
+		groupErr = group.Wait()
 		close(results)
 	}()
 
-	var combinedContents []FileContent
-	for content := range results {
-		logger.Debug("Received processed file", zap.String("file", content.Path))
-		combinedContents = append(combinedContents, content)
+	// Keep draining after an emit error so that no worker blocks on a full results channel
+	processed := 0
+	var emitErr error
+	deliver := func(result jobResult) {
+		if !result.ok || emitErr != nil {
+			return
+		}
+		content := result.content
+		if emitErr = emit(content); emitErr != nil {
+			logger.Error("Failed to emit processed file", zap.String("file", content.Path), zap.Error(emitErr))
+			cancel()
+			return
+		}
+		processed++
+	}
+
+	var pending resultHeap
+	next := 0
+	for result := range results {
+		if memory != nil {
+			memory.done()
+		}
+		if result.ok {
+			logger.Debug("Received processed file", zap.String("file", result.content.Path))
+		}
+		if !o.ordered {
+			deliver(result)
+			continue
+		}
+		heap.Push(&pending, result)
+		for pending.Len() > 0 && pending[0].rank == next {
+			deliver(heap.Pop(&pending).(jobResult))
+			next++
+			<-window
+		}
 	}
+	// Files abandoned after cancellation leave gaps; emit the files processed before it in order
+	for pending.Len() > 0 {
+		deliver(heap.Pop(&pending).(jobResult))
+	}
+	if emitErr != nil {
+		return int(failed.Load()), emitErr
+	}
+	if groupErr != nil {
+		return int(failed.Load()), groupErr
+	}
+
+	logger.Debug("All files processed", zap.Int("processedFiles", processed), zap.Int64("failedFiles", failed.Load()))
+	return int(failed.Load()), nil
+}
+
+// job is a file to process, along with its position among the files handed out.
+type job struct {
+	rank int
+	file string
+}
 
-	logger.Debug("All files processed", zap.Int("processedFiles", len(combinedContents)))
-	return combinedContents, nil
+// jobResult is the outcome of a job. ok is false if the file failed to process or was dropped by
+// the grep pattern, so that ordered results do not wait for it.
+type jobResult struct {
+	rank    int
+	content FileContent
+	ok      bool
 }
 
-// worker is a goroutine that processes files from the jobs channel.
-func worker(id int, jobs <-chan string, results chan<- FileContent, parentDir string, wg *sync.WaitGroup, logger *zap.Logger) {
-	defer wg.Done()
+// resultHeap is a min-heap of job results by rank, implementing heap.Interface.
+type resultHeap []jobResult
+
+func (h resultHeap) Len() int           { return len(h) }
+func (h resultHeap) Less(i, j int) bool { return h[i].rank < h[j].rank }
+func (h resultHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+// Push appends x, which must be a jobResult, for heap.Push.
+func (h *resultHeap) Push(x any) { *h = append(*h, x.(jobResult)) }
+
+// Pop removes and returns the last result for heap.Pop.
+func (h *resultHeap) Pop() any {
+	old := *h
+	result := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return result
+}
+
+// worker processes files from the jobs channel, reporting each file to progress. Files failing to
+// process are counted in failed and skipped, or, if failFast is set, end the worker with an error.
+// dirs, if not nil, limits the files of a directory processed at once across workers.
+func worker(ctx context.Context, id int, jobs <-chan job, results chan<- jobResult, parentDir string, opts ProcessOptions, dirs *dirLimiter, progress Progress, failFast bool, failed *atomic.Int64, logger *zap.Logger) error {
 	logger.Debug("Worker started", zap.Int("workerID", id))
 
-	for file := range jobs {
+	for j := range jobs {
+		// Skip the remaining files once processing has been abandoned
+		if ctx.Err() != nil {
+			continue
+		}
+		file := j.file
+
 		logger.Debug("Worker received file to process",
 			zap.Int("workerID", id),
 			zap.String("filePath", file))
 
-		content, err := ProcessSingleFile(file, parentDir, logger)
+		// Wait for a slot of the file's directory; none is left once processing is abandoned
+		release, ok := dirs.acquire(ctx, filepath.Dir(file))
+		if !ok {
+			continue
+		}
+		progress.OnFileStart(file)
+		content, err := processJob(ctx, id, file, parentDir, opts, logger)
+		release()
+		if errors.Is(err, errGrepMismatch) {
+			progress.OnFileDone(file, nil)
+			logger.Debug("Skipping file not matching grep pattern",
+				zap.String("filePath", file),
+				zap.String("pattern", opts.Grep.String()))
+			results <- jobResult{rank: j.rank}
+			continue
+		}
+		if errors.Is(err, errSecretDetected) {
+			progress.OnFileDone(file, nil)
+			logger.Debug("Skipping file with detected secret", zap.String("filePath", file))
+			results <- jobResult{rank: j.rank}
+			continue
+		}
+		progress.OnFileDone(file, err)
 		if err != nil {
+			failed.Add(1)
 			logger.Error("Worker failed to process file",
 				zap.Int("workerID", id),
 				zap.String("filePath", file),
 				zap.Error(err))
-			continue // Decide whether to skip or halt on error
+			if failFast {
+				return fmt.Errorf("failed to process %s: %w", file, err)
+			}
+			results <- jobResult{rank: j.rank}
+			continue
 		}
-
-		results <- content
-		logger.Debug("Worker successfully processed file",
-			zap.Int("workerID", id),
-			zap.String("filePath", file))
+		results <- jobResult{rank: j.rank, content: content, ok: true}
 	}
 
 	logger.Debug("Worker finished processing", zap.Int("workerID", id))
+	return nil
+}
+
+// processJob returns the processed content of file, served from the checkpoint or cache when the
+// file is unchanged since it was recorded there.
+func processJob(ctx context.Context, id int, file, parentDir string, opts ProcessOptions, logger *zap.Logger) (FileContent, error) {
+	// Serve files unchanged since the previous or interrupted run without reading them
+	var info fs.FileInfo
+	if opts.cache != nil || opts.checkpoint != nil {
+		statStart := time.Now()
+		info, _ = os.Stat(file)
+		opts.ioProfile.observe(ioStat, statStart)
+	}
+	if info != nil {
+		if content, ok := opts.checkpoint.lookup(file, info); ok {
+			if opts.cache != nil {
+				opts.cache.store(file, info, content)
+			}
+			logger.Debug("Worker resumed file from checkpoint",
+				zap.Int("workerID", id),
+				zap.String("filePath", file))
+			return content, nil
+		}
+		if opts.cache != nil {
+			if content, ok := opts.cache.lookup(file, info); ok {
+				logger.Debug("Worker reused cached file content",
+					zap.Int("workerID", id),
+					zap.String("filePath", file))
+				return content, nil
+			}
+		}
+	}
+
+	content, err := ProcessSingleFile(ctx, file, parentDir, opts, logger)
+	if err != nil {
+		return FileContent{}, err
+	}
+
+	if opts.cache != nil && info != nil {
+		opts.cache.store(file, info, content)
+	}
+	if info != nil {
+		if err := opts.checkpoint.record(file, info, content); err != nil {
+			logger.Warn("Failed to record file in checkpoint", zap.String("filePath", file), zap.Error(err))
+		}
+	}
+	logger.Debug("Worker successfully processed file",
+		zap.Int("workerID", id),
+		zap.String("filePath", file))
+	return content, nil
 }
@@ -2,8 +2,11 @@
 package version
 
 import (
+	"encoding/json"
 	"fmt"
 	"runtime"
+	"strconv"
+	"strings"
 )
 
 // These variables are populated at build time using -ldflags.
@@ -17,11 +20,11 @@ var (
 
 // Info contains comprehensive version information.
 type Info struct {
-	Version   string // Semantic version
-	GitCommit string // Git commit hash
-	BuildTime string // Build timestamp
-	GoVersion string // Go runtime version
-	Platform  string // OS and architecture
+	Version   string `json:"version"`    // Semantic version
+	GitCommit string `json:"git_commit"` // Git commit hash
+	BuildTime string `json:"build_time"` // Build timestamp
+	GoVersion string `json:"go_version"` // Go runtime version
+	Platform  string `json:"platform"`   // OS and architecture
 }
 
 // Get returns the current version information.
@@ -48,3 +51,134 @@ func (i Info) String() string {
 		i.Platform,
 	)
 }
+
+// MarshalText implements encoding.TextMarshaler so that Info can be logged directly, e.g. with
+// zap.Any("version", version.Get()). It returns the String representation.
+func (i Info) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// MarshalJSON encodes Info as an object of its fields. It takes precedence over MarshalText,
+// which would otherwise make Info encode as a JSON string.
+func (i Info) MarshalJSON() ([]byte, error) {
+	type fields Info // Drops the methods so that encoding does not recurse
+	return json.Marshal(fields(i))
+}
+
+// CheckCompatibility returns an error if the running Version is older than minVersion
+// or is a development build ("dev"). Both versions are semantic versions such as
+// "1.2.3" or "v1.2.3-rc.1"; a pre-release sorts before its release.
+func CheckCompatibility(minVersion string) error {
+	minimum, err := parseSemver(minVersion)
+	if err != nil {
+		return fmt.Errorf("invalid minimum version %q: %w", minVersion, err)
+	}
+
+	if Version == "dev" {
+		return fmt.Errorf("agentexec development build is not guaranteed to satisfy minimum version %s", minVersion)
+	}
+
+	current, err := parseSemver(Version)
+	if err != nil {
+		return fmt.Errorf("invalid agentexec version %q: %w", Version, err)
+	}
+
+	if current.compare(minimum) < 0 {
+		return fmt.Errorf("agentexec version %s is older than required minimum %s", Version, minVersion)
+	}
+	return nil
+}
+
+// semver is a parsed semantic version. Build metadata is discarded.
+type semver struct {
+	major, minor, patch int
+	prerelease          string
+}
+
+// parseSemver parses a semantic version string with an optional leading "v".
+func parseSemver(s string) (semver, error) {
+	var v semver
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.prerelease = s[i+1:]
+		s = s[:i]
+		if v.prerelease == "" {
+			return semver{}, fmt.Errorf("empty pre-release")
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return semver{}, fmt.Errorf("expected MAJOR.MINOR.PATCH")
+	}
+	numbers := []*int{&v.major, &v.minor, &v.patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semver{}, fmt.Errorf("invalid numeric component %q", part)
+		}
+		*numbers[i] = n
+	}
+	return v, nil
+}
+
+// compare returns -1, 0, or 1 depending on whether v is less than, equal to, or greater than other.
+func (v semver) compare(other semver) int {
+	for _, pair := range [][2]int{{v.major, other.major}, {v.minor, other.minor}, {v.patch, other.patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return comparePrerelease(v.prerelease, other.prerelease)
+}
+
+// comparePrerelease orders pre-release identifiers according to the semver specification.
+// A version without a pre-release has higher precedence than one with a pre-release.
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	aIDs, bIDs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		aNum, aErr := strconv.Atoi(aIDs[i])
+		bNum, bErr := strconv.Atoi(bIDs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum != bNum {
+				if aNum < bNum {
+					return -1
+				}
+				return 1
+			}
+		case aErr == nil:
+			return -1 // Numeric identifiers have lower precedence than alphanumeric ones
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(aIDs[i], bIDs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	switch {
+	case len(aIDs) < len(bIDs):
+		return -1
+	case len(aIDs) > len(bIDs):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package version
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    string
+		minVersion string
+		wantErr    string // Substring of the error; empty if the versions are compatible
+	}{
+		{name: "equal", version: "1.2.3", minVersion: "1.2.3"},
+		{name: "newer patch", version: "1.2.4", minVersion: "1.2.3"},
+		{name: "newer minor", version: "1.3.0", minVersion: "1.2.9"},
+		{name: "newer major", version: "2.0.0", minVersion: "1.9.9"},
+		{name: "older patch", version: "1.2.2", minVersion: "1.2.3", wantErr: "older than"},
+		{name: "older minor", version: "1.1.9", minVersion: "1.2.0", wantErr: "older than"},
+		{name: "older major", version: "0.9.9", minVersion: "1.0.0", wantErr: "older than"},
+		{name: "numeric not lexical", version: "1.10.0", minVersion: "1.9.0"},
+		{name: "leading v", version: "v1.2.3", minVersion: "1.2.3"},
+		{name: "leading v on minimum", version: "1.2.3", minVersion: "v1.2.3"},
+		{name: "build metadata ignored", version: "1.2.3+abc", minVersion: "1.2.3+def"},
+		{name: "release after pre-release", version: "1.2.3", minVersion: "1.2.3-rc.1"},
+		{name: "pre-release before release", version: "1.2.3-rc.1", minVersion: "1.2.3", wantErr: "older than"},
+		{name: "pre-release of newer version", version: "1.3.0-alpha", minVersion: "1.2.3"},
+		{name: "numeric pre-release identifiers", version: "1.2.3-rc.10", minVersion: "1.2.3-rc.9"},
+		{name: "numeric before alphanumeric", version: "1.2.3-1", minVersion: "1.2.3-alpha", wantErr: "older than"},
+		{name: "alphanumeric pre-release identifiers", version: "1.2.3-beta", minVersion: "1.2.3-alpha"},
+		{name: "longer pre-release", version: "1.2.3-alpha.1", minVersion: "1.2.3-alpha"},
+		{name: "shorter pre-release", version: "1.2.3-alpha", minVersion: "1.2.3-alpha.1", wantErr: "older than"},
+		{name: "dev build", version: "dev", minVersion: "0.0.1", wantErr: "development build"},
+		{name: "invalid minimum", version: "1.2.3", minVersion: "1.2", wantErr: "invalid minimum version"},
+		{name: "invalid minimum with dev build", version: "dev", minVersion: "latest", wantErr: "invalid minimum version"},
+		{name: "empty pre-release", version: "1.2.3", minVersion: "1.2.3-", wantErr: "invalid minimum version"},
+		{name: "invalid version", version: "1.x.3", minVersion: "1.2.3", wantErr: "invalid agentexec version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(version string) { Version = version }(Version)
+			Version = tt.version
+
+			err := CheckCompatibility(tt.minVersion)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("CheckCompatibility(%q) with version %q = %v, want nil", tt.minVersion, tt.version, err)
+			case tt.wantErr != "" && err == nil:
+				t.Fatalf("CheckCompatibility(%q) with version %q = nil, want error containing %q", tt.minVersion, tt.version, tt.wantErr)
+			case err != nil && !strings.Contains(err.Error(), tt.wantErr):
+				t.Fatalf("CheckCompatibility(%q) with version %q = %v, want error containing %q", tt.minVersion, tt.version, err, tt.wantErr)
+			}
+		})
+	}
+}